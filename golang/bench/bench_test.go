@@ -0,0 +1,232 @@
+// Package bench compares the context-based logger with log/slog, zap and
+// zerolog on the same workloads, all writing JSON to io.Discard:
+//
+//	go test -bench . -benchmem
+package bench
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type order struct {
+	OrderID string  `json:"orderId"`
+	Amount  float64 `json:"amount"`
+	Items   int     `json:"items"`
+}
+
+var (
+	payload    = order{OrderID: "ord-42", Amount: 99.5, Items: 3}
+	errPayment = errors.New("payment declined")
+)
+
+func newContextLogger() *logger.StandardLogger {
+	return logger.New(logger.WithWriter(io.Discard), logger.WithEncoder(logger.JSONEncoder{}))
+}
+
+func newSlog() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, nil))
+}
+
+func newZap() *zap.Logger {
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	return zap.New(zapcore.NewCore(enc, zapcore.AddSync(io.Discard), zapcore.DebugLevel))
+}
+
+func newZerolog() zerolog.Logger {
+	return zerolog.New(io.Discard).With().Timestamp().Logger()
+}
+
+func enriched() *logger.LogContext {
+	return logger.NewLogContext(logger.LogContextData{}).
+		WithCategory("http.request").
+		WithSessionID("sess-1").
+		WithUserID("user-7").
+		WithTags("checkout", "api").
+		WithMetadata(map[string]string{"region": "eu-west-1", "version": "1.4.2", "route": "/orders"})
+}
+
+// BenchmarkMessage logs a plain message without context.
+func BenchmarkMessage(b *testing.B) {
+	ctx := context.Background()
+	b.Run("contextlogger", func(b *testing.B) {
+		l := newContextLogger()
+		for b.Loop() {
+			l.Info(ctx, "request handled")
+		}
+	})
+	b.Run("slog", func(b *testing.B) {
+		l := newSlog()
+		for b.Loop() {
+			l.Info("request handled")
+		}
+	})
+	b.Run("zap", func(b *testing.B) {
+		l := newZap()
+		for b.Loop() {
+			l.Info("request handled")
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		l := newZerolog()
+		for b.Loop() {
+			l.Info().Msg("request handled")
+		}
+	})
+}
+
+// BenchmarkContext logs within an already enriched scope: session, user,
+// category, tags and three metadata keys.
+func BenchmarkContext(b *testing.B) {
+	b.Run("contextlogger", func(b *testing.B) {
+		l := newContextLogger()
+		_, _ = logger.WithLogContext(context.Background(), enriched(), func(ctx context.Context) (struct{}, error) {
+			for b.Loop() {
+				l.Info(ctx, "request handled")
+			}
+			return struct{}{}, nil
+		})
+	})
+	b.Run("slog", func(b *testing.B) {
+		l := newSlog().With(
+			"category", "http.request", "sessionId", "sess-1", "userId", "user-7",
+			"tags", []string{"api", "checkout"},
+			slog.Group("metadata", "region", "eu-west-1", "version", "1.4.2", "route", "/orders"),
+		)
+		for b.Loop() {
+			l.Info("request handled")
+		}
+	})
+	b.Run("zap", func(b *testing.B) {
+		l := newZap().With(
+			zap.String("category", "http.request"), zap.String("sessionId", "sess-1"), zap.String("userId", "user-7"),
+			zap.Strings("tags", []string{"api", "checkout"}),
+			zap.Dict("metadata", zap.String("region", "eu-west-1"), zap.String("version", "1.4.2"), zap.String("route", "/orders")),
+		)
+		for b.Loop() {
+			l.Info("request handled")
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		l := newZerolog().With().
+			Str("category", "http.request").Str("sessionId", "sess-1").Str("userId", "user-7").
+			Strs("tags", []string{"api", "checkout"}).
+			Dict("metadata", zerolog.Dict().Str("region", "eu-west-1").Str("version", "1.4.2").Str("route", "/orders")).
+			Logger()
+		for b.Loop() {
+			l.Info().Msg("request handled")
+		}
+	})
+}
+
+// BenchmarkScope builds the enriched scope for every entry, as middleware
+// does once per request.
+func BenchmarkScope(b *testing.B) {
+	b.Run("contextlogger", func(b *testing.B) {
+		l := newContextLogger()
+		ctx := context.Background()
+		for b.Loop() {
+			_, _ = logger.WithLogContext(ctx, enriched(), func(ctx context.Context) (struct{}, error) {
+				l.Info(ctx, "request handled")
+				return struct{}{}, nil
+			})
+		}
+	})
+	b.Run("slog", func(b *testing.B) {
+		base := newSlog()
+		for b.Loop() {
+			base.With(
+				"category", "http.request", "sessionId", "sess-1", "userId", "user-7",
+				"tags", []string{"api", "checkout"},
+				slog.Group("metadata", "region", "eu-west-1", "version", "1.4.2", "route", "/orders"),
+			).Info("request handled")
+		}
+	})
+	b.Run("zap", func(b *testing.B) {
+		base := newZap()
+		for b.Loop() {
+			base.With(
+				zap.String("category", "http.request"), zap.String("sessionId", "sess-1"), zap.String("userId", "user-7"),
+				zap.Strings("tags", []string{"api", "checkout"}),
+				zap.Dict("metadata", zap.String("region", "eu-west-1"), zap.String("version", "1.4.2"), zap.String("route", "/orders")),
+			).Info("request handled")
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		base := newZerolog()
+		for b.Loop() {
+			l := base.With().
+				Str("category", "http.request").Str("sessionId", "sess-1").Str("userId", "user-7").
+				Strs("tags", []string{"api", "checkout"}).
+				Dict("metadata", zerolog.Dict().Str("region", "eu-west-1").Str("version", "1.4.2").Str("route", "/orders")).
+				Logger()
+			l.Info().Msg("request handled")
+		}
+	})
+}
+
+// BenchmarkFields logs a structured payload with each entry.
+func BenchmarkFields(b *testing.B) {
+	ctx := context.Background()
+	b.Run("contextlogger", func(b *testing.B) {
+		l := newContextLogger()
+		for b.Loop() {
+			l.Info(ctx, payload)
+		}
+	})
+	b.Run("slog", func(b *testing.B) {
+		l := newSlog()
+		for b.Loop() {
+			l.Info("order placed", "orderId", payload.OrderID, "amount", payload.Amount, "items", payload.Items)
+		}
+	})
+	b.Run("zap", func(b *testing.B) {
+		l := newZap()
+		for b.Loop() {
+			l.Info("order placed", zap.String("orderId", payload.OrderID), zap.Float64("amount", payload.Amount), zap.Int("items", payload.Items))
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		l := newZerolog()
+		for b.Loop() {
+			l.Info().Str("orderId", payload.OrderID).Float64("amount", payload.Amount).Int("items", payload.Items).Msg("order placed")
+		}
+	})
+}
+
+// BenchmarkError logs an error with its message.
+func BenchmarkError(b *testing.B) {
+	ctx := context.Background()
+	b.Run("contextlogger", func(b *testing.B) {
+		l := newContextLogger()
+		for b.Loop() {
+			l.Error(ctx, "Charge failed:", errPayment)
+		}
+	})
+	b.Run("slog", func(b *testing.B) {
+		l := newSlog()
+		for b.Loop() {
+			l.Error("Charge failed", "error", errPayment)
+		}
+	})
+	b.Run("zap", func(b *testing.B) {
+		l := newZap()
+		for b.Loop() {
+			l.Error("Charge failed", zap.Error(errPayment))
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		l := newZerolog()
+		for b.Loop() {
+			l.Error().Err(errPayment).Msg("Charge failed")
+		}
+	})
+}
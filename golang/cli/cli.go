@@ -0,0 +1,98 @@
+// Package cli binds the logger's configuration to Cobra flags and Viper
+// keys, so Cobra CLIs share one logging setup:
+//
+//	root := &cobra.Command{Use: "app"}
+//	cli.Bind(root, nil)
+//	_ = root.Execute()
+package cli
+
+import (
+	"io"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Viper keys the flags are bound to, so the configuration can also come from
+// config files and environment variables.
+const (
+	LevelKey  = "log.level"
+	FormatKey = "log.format"
+	FileKey   = "log.file"
+)
+
+// Bind adds --log-level, --log-format and --log-file persistent flags to cmd,
+// binds them to LevelKey, FormatKey and FileKey in v (the global Viper when
+// nil), and sets the default logger from those keys in cmd's
+// PersistentPreRunE, after running any pre-run hook cmd already has. A log
+// file is closed in PersistentPostRunE.
+//
+// Cobra only runs the closest persistent hooks, so subcommands that define
+// their own need cobra.EnableTraverseRunHooks for the logger to be set up.
+func Bind(cmd *cobra.Command, v *viper.Viper) {
+	if v == nil {
+		v = viper.GetViper()
+	}
+	flags := cmd.PersistentFlags()
+	flags.String("log-level", string(logger.LevelInfo), "minimum level logged: debug, info, warn or error")
+	flags.String("log-format", "json", "log format: json, pretty, logfmt, ecs, datadog, gcp, cbor, msgpack, protobuf, cef or leef")
+	flags.String("log-file", "", "append logs to this path instead of stdout")
+	_ = v.BindPFlag(LevelKey, flags.Lookup("log-level"))
+	_ = v.BindPFlag(FormatKey, flags.Lookup("log-format"))
+	_ = v.BindPFlag(FileKey, flags.Lookup("log-file"))
+
+	var file io.Closer
+	preRun, preRunE := cmd.PersistentPreRun, cmd.PersistentPreRunE
+	cmd.PersistentPreRun = nil
+	cmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		opts, f, err := Options(v)
+		if err != nil {
+			return err
+		}
+		if f != nil {
+			file = f
+		}
+		logger.SetDefault(logger.New(opts...))
+		switch {
+		case preRunE != nil:
+			return preRunE(c, args)
+		case preRun != nil:
+			preRun(c, args)
+		}
+		return nil
+	}
+
+	postRun, postRunE := cmd.PersistentPostRun, cmd.PersistentPostRunE
+	cmd.PersistentPostRun = nil
+	cmd.PersistentPostRunE = func(c *cobra.Command, args []string) error {
+		var err error
+		switch {
+		case postRunE != nil:
+			err = postRunE(c, args)
+		case postRun != nil:
+			postRun(c, args)
+		}
+		if file != nil {
+			file.Close()
+		}
+		return err
+	}
+}
+
+// Options returns the logger options LevelKey, FormatKey and FileKey in v
+// select, opening the log file, if any, for the caller to close.
+func Options(v *viper.Viper) ([]logger.Option, *logger.FileSink, error) {
+	f := &logger.LogFlags{Level: logger.LevelInfo, Format: "json", File: v.GetString(FileKey)}
+	if level := v.GetString(LevelKey); level != "" {
+		parsed, err := logger.ParseLevel(level)
+		if err != nil {
+			return nil, nil, err
+		}
+		f.Level = parsed
+	}
+	if format := v.GetString(FormatKey); format != "" {
+		f.Format = format
+	}
+	return f.Options()
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestBind(t *testing.T) {
+	previous := logger.Default()
+	defer logger.SetDefault(previous)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	ran := false
+	root := &cobra.Command{
+		Use: "app",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			ran = true
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			logger.Info(cmd.Context(), "filtered")
+			logger.Warn(cmd.Context(), "disk low")
+		},
+	}
+	v := viper.New()
+	Bind(root, v)
+	v.Set(FormatKey, "logfmt")
+	root.SetArgs([]string{"--log-level", "warn", "--log-file", path})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("Expected the command's own pre-run hook to run")
+	}
+
+	raw, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(raw)), "\n"); len(lines) != 1 ||
+		!strings.Contains(lines[0], "level=warn") || !strings.Contains(lines[0], `msg="disk low"`) {
+		t.Errorf("Expected one logfmt warn entry in the file, got %q", raw)
+	}
+}
+
+func TestBind_InvalidConfig(t *testing.T) {
+	previous := logger.Default()
+	defer logger.SetDefault(previous)
+
+	root := &cobra.Command{Use: "app", Run: func(*cobra.Command, []string) {}}
+	root.SilenceErrors, root.SilenceUsage = true, true
+	v := viper.New()
+	Bind(root, v)
+	v.Set(LevelKey, "loud")
+	root.SetArgs(nil)
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "unknown level") {
+		t.Errorf("Expected an unknown level error, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+// Command logview pretty-prints NDJSON logs written by the logger package.
+//
+//	logview [flags] [file ...]
+//
+// It reads the files in order, or stdin when none are given. Lines that are
+// not log entries are printed unchanged.
+package main
+
+import (
+	"bufio"
+	"crypto/ecdh"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+	"github.com/peterzzshi/context-based-logger/logger/logreader"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("logview", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	level := flags.String("level", "", "show entries at this level or above (debug, info, warn, error)")
+	session := flags.String("session", "", "show only entries of this session ID")
+	columns := flags.String("columns", "", "comma-separated metadata keys to show as columns, one line per entry")
+	follow := flags.Bool("follow-session", false, "print each session as a timeline of nested operations")
+	color := flags.String("color", "auto", "colorize output: auto, always or never")
+	keyFile := flags.String("key", "", "`file` holding the base64 X25519 private key that opens sealed entries and tokens")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: logview [flags] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	v := &view{out: stdout, errOut: stderr, follow: *follow}
+	defer v.flush()
+	switch *color {
+	case "always":
+		v.color = true
+	case "never":
+	case "auto":
+		v.color = isTerminal(stdout) && os.Getenv("NO_COLOR") == ""
+	default:
+		fmt.Fprintf(stderr, "logview: invalid -color %q\n", *color)
+		return 2
+	}
+	if *level != "" {
+		min, err := logger.ParseLevel(*level)
+		if err != nil {
+			fmt.Fprintf(stderr, "logview: invalid -level %q\n", *level)
+			return 2
+		}
+		v.filters = append(v.filters, logreader.MinLevel(min))
+	}
+	if *session != "" {
+		v.filters = append(v.filters, logreader.Session(*session))
+	}
+	if *columns != "" {
+		v.columns = strings.Split(*columns, ",")
+	}
+	if *keyFile != "" {
+		opener, err := readKey(*keyFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "logview: %v\n", err)
+			return 2
+		}
+		v.opener = opener
+	}
+
+	if flags.NArg() == 0 {
+		if err := v.read(stdin); err != nil {
+			fmt.Fprintf(stderr, "logview: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	status := 0
+	for _, name := range flags.Args() {
+		if err := v.readFile(name); err != nil {
+			fmt.Fprintf(stderr, "logview: %v\n", err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// readKey reads the private key of sealed entries, encoded as base64.
+func readKey(name string) (logger.Opener, error) {
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	key, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return logger.NewBoxOpener(key), nil
+}
+
+// view renders the entries that match its filters.
+type view struct {
+	out     io.Writer
+	errOut  io.Writer
+	opener  logger.Opener
+	filters []logreader.Filter
+	columns []string
+	color   bool
+
+	// follow collects entries for a session timeline, printed by flush.
+	follow  bool
+	entries []logreader.Entry
+}
+
+func (v *view) readFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return v.read(f)
+}
+
+func (v *view) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if v.opener != nil {
+			plaintext, _, err := logger.Unseal(v.opener, raw)
+			if err != nil {
+				fmt.Fprintf(v.errOut, "logview: line %d: %v\n", line, err)
+				continue
+			}
+			raw = plaintext
+		}
+		entry, err := logreader.Parse(raw)
+		if err != nil {
+			fmt.Fprintln(v.out, string(raw))
+			continue
+		}
+		entry.Line = line
+		if v.opener != nil {
+			if err := logger.OpenTokens(v.opener, &entry.LogOutput); err != nil {
+				fmt.Fprintf(v.errOut, "logview: line %d: %v\n", line, err)
+			}
+		}
+		if logreader.Match(entry, v.filters...) {
+			v.render(entry)
+		}
+	}
+	return scanner.Err()
+}
+
+func (v *view) render(entry logreader.Entry) {
+	if v.follow {
+		v.entries = append(v.entries, entry)
+		return
+	}
+	if v.columns == nil {
+		encoded, _ := logger.ConsoleEncoder{Color: v.color}.Encode(entry.LogOutput)
+		fmt.Fprintf(v.out, "%s\n", encoded)
+		return
+	}
+
+	var b strings.Builder
+	if !entry.Time.IsZero() {
+		b.WriteString(v.paint(ansiGray, entry.Time.Format("15:04:05")))
+		b.WriteByte(' ')
+	}
+	b.WriteString(v.summary(entry))
+	if entry.SessionID != "" {
+		b.WriteString(" " + v.paint(ansiCyan, "["+entry.SessionID+"]"))
+	}
+	b.WriteString(v.columnValues(entry))
+	fmt.Fprintln(v.out, b.String())
+}
+
+// summary renders the entry's level, event name and message.
+func (v *view) summary(entry logreader.Entry) string {
+	var b strings.Builder
+	b.WriteString(v.paint(levelColors[entry.Level], fmt.Sprintf("%-5s", strings.ToUpper(string(entry.Level)))))
+	if entry.Event != "" {
+		b.WriteString(" " + v.paint(ansiCyan, entry.Event))
+	}
+	if entry.Message != nil {
+		b.WriteString(" " + fmt.Sprint(entry.Message))
+	}
+	return b.String()
+}
+
+func (v *view) columnValues(entry logreader.Entry) string {
+	var b strings.Builder
+	metadata := entry.Metadata()
+	for _, key := range v.columns {
+		value, ok := metadata[key]
+		if !ok {
+			value = "-"
+		}
+		b.WriteString(" " + v.paint(ansiGray, key+"=") + value)
+	}
+	return b.String()
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiGray   = "\x1b[90m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+var levelColors = map[logger.LogLevel]string{
+	logger.LevelDebug: ansiGray,
+	logger.LevelInfo:  ansiBlue,
+	logger.LevelWarn:  ansiYellow,
+	logger.LevelError: ansiRed,
+}
+
+func (v *view) paint(color, s string) string {
+	if !v.color || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+const input = `{"level":"info","message":"started","sessionId":"req-1","details":{"metadata":{"orderId":"42"},"timestamp":"2024-01-01T10:00:00Z"}}
+plain text
+{"level":"debug","message":"noise","sessionId":"req-1","details":{}}
+{"level":"error","message":"failed","sessionId":"req-2","details":{"timestamp":"2024-01-01T10:00:01Z"}}
+`
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "columns",
+			args: []string{"-color", "never", "-level", "info", "-columns", "orderId"},
+			want: "10:00:00 INFO  started [req-1] orderId=42\nplain text\n10:00:01 ERROR failed [req-2] orderId=-\n",
+		},
+		{
+			name: "session",
+			args: []string{"--session", "req-2", "-color=never"},
+			want: "plain text\n10:00:01 ERROR failed [req-2]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, strings.NewReader(input), &stdout, &stderr); code != 0 {
+				t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+			}
+			if stdout.String() != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_InvalidLevel(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-level", "loud"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+}
+
+func TestRun_FollowSession(t *testing.T) {
+	input := `{"level":"info","message":"request","sessionId":"s1","operationId":"a","details":{"timestamp":"2024-01-01T00:00:00Z"}}
+{"level":"info","message":"other","sessionId":"s2","operationId":"x","details":{"timestamp":"2024-01-01T00:00:01Z"}}
+{"level":"debug","message":"query","sessionId":"s1","operationId":"b","parentOperationId":"a","details":{"timestamp":"2024-01-01T00:00:02Z"}}
+{"level":"info","message":"done","sessionId":"s1","operationId":"a","details":{"timestamp":"2024-01-01T00:00:03Z"}}
+`
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-follow-session", "-session", "s1", "-color", "never"}, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	want := `session s1 (3s)
+  +0s      operation a (3s)
+    +0s      INFO  request
+    +2s      operation b (0s)
+      +2s      DEBUG query
+    +3s      INFO  done
+`
+	if stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+}
+
+func TestRun_SealedEntries(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "log.key")
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(key.Bytes())+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	enc := logger.SealedEncoder{Sealer: logger.NewBoxSealer(key.PublicKey())}
+	sealed, err := enc.Encode(logger.LogOutput{Level: logger.LevelWarn, Message: "secret", Details: map[string]interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	input := string(sealed) + "\nplain text\n" + `{"sealed":"AAAA"}` + "\n"
+	if code := run([]string{"-key", keyFile, "-color", "never"}, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if want := " WARN  secret\nplain text\n"; stdout.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "line 3") {
+		t.Errorf("Expected the malformed line to be reported, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/peterzzshi/context-based-logger/logger/logreader"
+)
+
+// flush prints the collected sessions as timelines: entries indented under
+// their operations, with offsets from the session start and durations.
+func (v *view) flush() {
+	for i, session := range logreader.Timelines(v.entries) {
+		if i > 0 {
+			fmt.Fprintln(v.out)
+		}
+		fmt.Fprintf(v.out, "%s %s\n", v.paint(ansiCyan, "session "+session.ID), v.paint(ansiGray, span(session.Duration(), session.Start)))
+		for _, op := range session.Scopes {
+			v.printOperation(op, session.Start, 1)
+		}
+	}
+	v.entries = nil
+}
+
+func (v *view) printOperation(op *logreader.Scope, start time.Time, depth int) {
+	if op.ID != "" {
+		fmt.Fprintf(v.out, "%s%s %s %s\n", indent(depth), v.paint(ansiGray, offset(op.Start, start)),
+			v.paint(ansiCyan, "operation "+op.ID), v.paint(ansiGray, span(op.Duration(), op.Start)))
+		depth++
+	}
+
+	// Interleave the operation's entries with its children by time.
+	type item struct {
+		at    time.Time
+		entry *logreader.Entry
+		child *logreader.Scope
+	}
+	items := make([]item, 0, len(op.Entries)+len(op.Children))
+	for i := range op.Entries {
+		items = append(items, item{at: op.Entries[i].Time, entry: &op.Entries[i]})
+	}
+	for _, child := range op.Children {
+		items = append(items, item{at: child.Start, child: child})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return !items[i].at.IsZero() && !items[j].at.IsZero() && items[i].at.Before(items[j].at)
+	})
+
+	for _, it := range items {
+		if it.child != nil {
+			v.printOperation(it.child, start, depth)
+			continue
+		}
+		fmt.Fprintf(v.out, "%s%s %s%s\n", indent(depth), v.paint(ansiGray, offset(it.at, start)),
+			v.summary(*it.entry), v.columnValues(*it.entry))
+	}
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// offset renders t relative to the session start, padded to align entries.
+func offset(t, start time.Time) string {
+	if t.IsZero() || start.IsZero() {
+		return fmt.Sprintf("%-8s", "?")
+	}
+	return fmt.Sprintf("%-8s", "+"+t.Sub(start).String())
+}
+
+func span(d time.Duration, start time.Time) string {
+	if start.IsZero() {
+		return ""
+	}
+	return "(" + d.String() + ")"
+}
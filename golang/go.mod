@@ -1,3 +1,3 @@
 module github.com/peterzzshi/context-based-logger
 
-go 1.23
+go 1.24
@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+	"github.com/peterzzshi/context-based-logger/logger/loggertest"
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconciler(t *testing.T) {
+	rec := loggertest.Install(t, logger.WithLevel(logger.LevelDebug))
+	log := NewLogger(nil).WithName("controller").WithValues("controller", "deployment")
+	ctx := logf.IntoContext(context.Background(), log)
+
+	r := Reconciler("deployment", reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		logf.FromContext(ctx).V(1).Info("Scaling", "replicas", 3)
+		return reconcile.Result{}, errors.New("conflict")
+	}))
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "prod", Name: "web"}})
+	if err == nil {
+		t.Fatal("Expected the reconciler's error")
+	}
+
+	scaling, ok := rec.Query().MessageContains("Scaling").First()
+	if !ok {
+		t.Fatalf("Expected the logr entry, got %v", rec.Entries())
+	}
+	fields := scaling.Details["fields"].(map[string]interface{})
+	if scaling.Level != logger.LevelDebug || scaling.Details["category"] != "reconcile.deployment" ||
+		fields["replicas"] != 3 || fields["logger"] != "controller" || fields["controller"] != "deployment" {
+		t.Errorf("Expected the logr entry logged in the reconcile's context, got %+v", scaling)
+	}
+	if !rec.Query().Level(logger.LevelError).MetadataEquals("namespace", "prod").MetadataEquals("name", "web").Exists() {
+		t.Errorf("Expected the failure logged with the request, got %v", rec.Entries())
+	}
+}
@@ -0,0 +1,121 @@
+// Package k8s integrates the logger with Kubernetes controller-runtime:
+// a logr sink, so controller-runtime and client-go log through the
+// structured pipeline, and a reconciler wrapper that scopes a LogContext to
+// each reconcile request.
+//
+//	ctrl.SetLogger(k8s.NewLogger(nil))
+//	err := ctrl.NewControllerManagedBy(mgr).
+//		For(&appsv1.Deployment{}).
+//		Complete(k8s.Reconciler("deployment", &DeploymentReconciler{}))
+package k8s
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+// LogSink is a logr.LogSink writing to a logger.Logger. logr names become
+// the "logger" field and key-value pairs become fields. V(0) logs at info
+// and higher verbosities at debug.
+type LogSink struct {
+	ctx    context.Context
+	logger logger.Logger
+	name   string
+	fields []logger.Field
+}
+
+// NewLogger returns a logr.Logger writing to l, or to the default logger
+// when l is nil.
+func NewLogger(l logger.Logger) logr.Logger {
+	return logr.New(&LogSink{ctx: context.Background(), logger: l})
+}
+
+func (s *LogSink) Init(logr.RuntimeInfo) {}
+
+func (s *LogSink) Enabled(level int) bool {
+	if l, ok := s.target().(interface {
+		Enabled(context.Context, logger.LogLevel) bool
+	}); ok {
+		return l.Enabled(s.ctx, verbosityLevel(level))
+	}
+	return true
+}
+
+func (s *LogSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.target().Log(s.ctx, verbosityLevel(level), s.args(msg, keysAndValues, nil)...)
+}
+
+func (s *LogSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.target().Log(s.ctx, logger.LevelError, s.args(msg, keysAndValues, err)...)
+}
+
+func (s *LogSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	clone := *s
+	clone.fields = append(append([]logger.Field(nil), s.fields...), pairs(keysAndValues)...)
+	return &clone
+}
+
+func (s *LogSink) WithName(name string) logr.LogSink {
+	clone := *s
+	if clone.name != "" {
+		name = clone.name + "." + name
+	}
+	clone.name = name
+	return &clone
+}
+
+// withContext returns a copy of s logging with ctx's LogContext.
+func (s *LogSink) withContext(ctx context.Context) *LogSink {
+	clone := *s
+	clone.ctx = ctx
+	return &clone
+}
+
+func (s *LogSink) target() logger.Logger {
+	if s.logger == nil {
+		return logger.Default()
+	}
+	return s.logger
+}
+
+func (s *LogSink) args(msg string, keysAndValues []interface{}, err error) []interface{} {
+	args := []interface{}{msg}
+	if s.name != "" {
+		args = append(args, logger.F("logger", s.name))
+	}
+	for _, f := range s.fields {
+		args = append(args, f)
+	}
+	for _, f := range pairs(keysAndValues) {
+		args = append(args, f)
+	}
+	if err != nil {
+		args = append(args, err)
+	}
+	return args
+}
+
+func verbosityLevel(level int) logger.LogLevel {
+	if level > 0 {
+		return logger.LevelDebug
+	}
+	return logger.LevelInfo
+}
+
+func pairs(keysAndValues []interface{}) []logger.Field {
+	fields := make([]logger.Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			continue
+		}
+		var value interface{}
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		fields = append(fields, logger.F(key, value))
+	}
+	return fields
+}
@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/peterzzshi/context-based-logger/logger"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Reconciler runs each reconcile of r in a new operation, categorized
+// "reconcile.<name>" with the request's namespace and name as metadata and
+// controller-runtime's reconcile ID as the session, so every entry of one
+// reconcile groups together. The logr.Logger controller-runtime puts in the
+// context logs with that LogContext when it is a NewLogger one. A final
+// entry records the duration and requeue at debug, or the error at error
+// level.
+func Reconciler(name string, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		lc := logger.GetLogContext(ctx).
+			WithCategory("reconcile." + name).
+			WithMetadata(map[string]string{"namespace": req.Namespace, "name": req.Name})
+		if id := controller.ReconcileIDFromContext(ctx); id != "" {
+			lc = lc.WithSessionID(string(id))
+		}
+
+		return logger.WithLogContext(ctx, lc, func(ctx context.Context) (reconcile.Result, error) {
+			log := logf.FromContext(ctx)
+			if sink, ok := log.GetSink().(*LogSink); ok {
+				ctx = logr.NewContext(ctx, log.WithSink(sink.withContext(ctx)))
+			}
+
+			start := time.Now()
+			result, err := r.Reconcile(ctx, req)
+			duration := logger.DurationField("duration", time.Since(start))
+			if err != nil {
+				logger.Error(ctx, "Reconcile failed:", duration, err)
+			} else {
+				logger.Debug(ctx, "Reconcile finished", duration,
+					logger.F("requeue", !result.IsZero()))
+			}
+			return result, err
+		})
+	})
+}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AccessLogFormat string
+
+const (
+	AccessLogJSON     AccessLogFormat = "json"
+	AccessLogCommon   AccessLogFormat = "common"
+	AccessLogCombined AccessLogFormat = "combined"
+)
+
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// AccessRecord describes a single completed HTTP request.
+type AccessRecord struct {
+	Time       time.Time
+	RemoteAddr string
+	User       string
+	Method     string
+	Route      string
+	Path       string
+	Protocol   string
+	Status     int
+	Bytes      int64
+	Duration   time.Duration
+	Referer    string
+	UserAgent  string
+}
+
+var (
+	accessMu     sync.RWMutex
+	accessFormat = AccessLogJSON
+	accessOutput io.Writer
+)
+
+// SetAccessLogFormat selects how LogAccess renders entries.
+func SetAccessLogFormat(format AccessLogFormat) {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+	accessFormat = format
+}
+
+// SetAccessLogOutput sends access entries to w instead of the application log output.
+// Passing nil restores the default.
+func SetAccessLogOutput(w io.Writer) {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+	accessOutput = w
+}
+
+// LogAccess emits one canonical access log entry for a request, carrying the
+// session, tags and metadata of the LogContext in ctx. The entry is stamped
+// with record.Time and, when the default logger is a StandardLogger, passes
+// through its filters, redaction and hooks even when written to the access
+// log output.
+func LogAccess(ctx context.Context, record AccessRecord) {
+	accessMu.RLock()
+	format, w := accessFormat, accessOutput
+	accessMu.RUnlock()
+
+	def := Default()
+	l, ok := def.(*StandardLogger)
+	if !ok {
+		l = New()
+	}
+	if record.Time.IsZero() {
+		record.Time = l.now()
+	}
+	output := buildOutput(ctx, LevelInfo)
+	output.Message = fmt.Sprintf("%s %s %d", record.Method, record.target(), record.Status)
+	output.Details["access"] = record.fields()
+	l.stampAt(output.Details, record.Time)
+
+	switch format {
+	case AccessLogCommon, AccessLogCombined:
+		if w == nil {
+			w = stdout
+		}
+		l.emit(ctx, output, []Sink{WriterSink{W: w, Encoder: clfEncoder{combined: format == AccessLogCombined}}})
+	default:
+		if w == nil {
+			def.Emit(ctx, output)
+			return
+		}
+		l.emit(ctx, output, []Sink{WriterSink{W: w}})
+	}
+}
+
+// clfEncoder renders access entries as Common or Combined Log Format lines,
+// reading the request back from the entry so hooks' changes are kept.
+type clfEncoder struct {
+	combined bool
+}
+
+func (e clfEncoder) Encode(output LogOutput) ([]byte, error) {
+	access, _ := output.Details["access"].(map[string]interface{})
+	text := func(key string) string {
+		s, _ := access[key].(string)
+		return s
+	}
+	r := AccessRecord{
+		RemoteAddr: text("remoteAddr"),
+		User:       text("user"),
+		Method:     text("method"),
+		Route:      text("route"),
+		Path:       text("path"),
+		Protocol:   text("protocol"),
+		Referer:    text("referer"),
+		UserAgent:  text("userAgent"),
+	}
+	r.Status, _ = access["status"].(int)
+	r.Bytes, _ = access["bytes"].(int64)
+	if ts, ok := output.Details["timestamp"].(string); ok {
+		r.Time, _ = time.Parse(time.RFC3339, ts)
+	}
+	return []byte(formatCLF(output.SessionID, r, e.combined)), nil
+}
+
+func (r AccessRecord) target() string {
+	if r.Path != "" {
+		return r.Path
+	}
+	return r.Route
+}
+
+func (r AccessRecord) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"method":     r.Method,
+		"status":     r.Status,
+		"bytes":      r.Bytes,
+		"durationMs": float64(r.Duration) / float64(time.Millisecond),
+	}
+	if r.Route != "" {
+		fields["route"] = r.Route
+	}
+	if r.Path != "" {
+		fields["path"] = r.Path
+	}
+	if r.Protocol != "" {
+		fields["protocol"] = r.Protocol
+	}
+	if r.RemoteAddr != "" {
+		fields["remoteAddr"] = r.RemoteAddr
+	}
+	if r.User != "" {
+		fields["user"] = r.User
+	}
+	if r.Referer != "" {
+		fields["referer"] = r.Referer
+	}
+	if r.UserAgent != "" {
+		fields["userAgent"] = r.UserAgent
+	}
+	return fields
+}
+
+func formatCLF(ident string, r AccessRecord, combined bool) string {
+	protocol := r.Protocol
+	if protocol == "" {
+		protocol = "HTTP/1.1"
+	}
+	bytes := "-"
+	if r.Bytes > 0 {
+		bytes = strconv.FormatInt(r.Bytes, 10)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s [%s] \"%s %s %s\" %d %s",
+		clfField(r.RemoteAddr),
+		clfField(ident),
+		clfField(r.User),
+		r.Time.Format(clfTimeLayout),
+		r.Method,
+		r.target(),
+		protocol,
+		r.Status,
+		bytes,
+	)
+	if combined {
+		fmt.Fprintf(&b, " %q %q", clfField(r.Referer), clfField(r.UserAgent))
+	}
+	return b.String()
+}
+
+func clfField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
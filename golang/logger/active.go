@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	trackScopes  atomic.Bool
+	activeScopes sync.Map // *activeScope -> struct{}
+)
+
+type activeScope struct {
+	lc        *LogContext
+	started   time.Time
+	goroutine uint64
+}
+
+// TrackActiveContexts turns on recording of the LogContexts of running
+// WithLogContext scopes, for DumpActiveContexts. It is off by default since
+// every scope then pays for registration.
+func TrackActiveContexts(enabled bool) {
+	trackScopes.Store(enabled)
+}
+
+// trackScope registers lc as active until the returned function is called.
+func trackScope(lc *LogContext) (done func()) {
+	if !trackScopes.Load() {
+		return func() {}
+	}
+	scope := &activeScope{lc: lc, started: time.Now(), goroutine: goroutineID()}
+	activeScopes.Store(scope, struct{}{})
+	return func() { activeScopes.Delete(scope) }
+}
+
+// DumpActiveContexts writes one JSON line per running WithLogContext scope,
+// oldest first, so a SIGQUIT or panic handler can show what was in flight.
+// Scopes are only recorded while TrackActiveContexts is enabled.
+func DumpActiveContexts(w io.Writer) error {
+	var scopes []*activeScope
+	activeScopes.Range(func(key, _ interface{}) bool {
+		scopes = append(scopes, key.(*activeScope))
+		return true
+	})
+	sort.Slice(scopes, func(i, j int) bool {
+		return scopes[i].started.Before(scopes[j].started)
+	})
+
+	now := time.Now()
+	enc := json.NewEncoder(w)
+	for _, scope := range scopes {
+		fields := buildOutput(context.WithValue(context.Background(), logContextKey, scope.lc), "").fields()
+		delete(fields, "level")
+		delete(fields, "schemaVersion")
+		fields["startedAt"] = scope.started.UTC().Format(time.RFC3339Nano)
+		fields["runningFor"] = now.Sub(scope.started).String()
+		fields["goroutine"] = scope.goroutine
+		if err := enc.Encode(fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// AlertRule selects the entries that trigger an alert. Every set criterion
+// must match.
+type AlertRule struct {
+	// MinLevel matches entries at or above this level.
+	MinLevel LogLevel
+	// Tags must all be present on the entry.
+	Tags []string
+	// Category matches this category and those nested below it.
+	Category string
+	// Match, when set, is consulted last for anything else.
+	Match func(output LogOutput) bool
+}
+
+func (r AlertRule) Matches(output LogOutput) bool {
+	if r.MinLevel != "" && output.Level.severity() < r.MinLevel.severity() {
+		return false
+	}
+	if r.Category != "" {
+		category, _ := output.Details["category"].(string)
+		if !CategoryHasPrefix(category, r.Category) {
+			return false
+		}
+	}
+	if len(r.Tags) > 0 {
+		tags, _ := output.Details["tags"].([]string)
+		for _, want := range r.Tags {
+			found := false
+			for _, tag := range tags {
+				if tag == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return r.Match == nil || r.Match(output)
+}
+
+// AlertHook calls notify for each entry matching rule, for lightweight
+// alerting without a log pipeline. notify runs inline, so it should hand slow
+// work off, as WebhookNotifier and SlackNotifier do.
+//
+//	logger.New(logger.WithHooks(logger.AlertHook(
+//		logger.AlertRule{MinLevel: logger.LevelError, Tags: []string{"payments"}},
+//		logger.SlackNotifier(slackURL),
+//	)))
+func AlertHook(rule AlertRule, notify func(output LogOutput)) Hook {
+	return func(_ context.Context, output *LogOutput) {
+		if rule.Matches(*output) {
+			notify(*output)
+		}
+	}
+}
+
+// WebhookNotifier posts each alerted entry as JSON to url. Posts run in the
+// background; failures go to the internal error handler.
+func WebhookNotifier(url string) func(output LogOutput) {
+	return func(output LogOutput) {
+		body, err := json.Marshal(output)
+		if err != nil {
+			reportError(nil, fmt.Errorf("alert webhook: %w", err))
+			return
+		}
+		go postAlert(url, body)
+	}
+}
+
+// SlackNotifier posts each alerted entry as a message to a Slack incoming
+// webhook URL.
+func SlackNotifier(webhookURL string) func(output LogOutput) {
+	return func(output LogOutput) {
+		text := fmt.Sprintf("*%s*", output.Level)
+		if category, ok := output.Details["category"].(string); ok {
+			text += " [" + category + "]"
+		}
+		text += " " + renderArg(output.Message)
+		if output.SessionID != "" {
+			text += "\nsession: `" + output.SessionID + "`"
+		}
+		body, _ := json.Marshal(map[string]string{"text": text})
+		go postAlert(webhookURL, body)
+	}
+}
+
+var alertClient = &http.Client{Timeout: 10 * time.Second}
+
+func postAlert(url string, body []byte) {
+	resp, err := alertClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		reportError(nil, fmt.Errorf("alert webhook: %w", err))
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		reportError(nil, fmt.Errorf("alert webhook: %s", resp.Status))
+	}
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlertHook(t *testing.T) {
+	var alerts []LogOutput
+	rule := AlertRule{MinLevel: LevelError, Tags: []string{"payments"}, Category: "billing"}
+	l := New(WithSinks(&recordingSink{}), WithHooks(AlertHook(rule, func(o LogOutput) { alerts = append(alerts, o) })))
+
+	payments := NewLogContext(LogContextData{Category: "billing.charges"}).WithTags("payments")
+	_, _ = WithLogContext(context.Background(), payments, func(ctx context.Context) (struct{}, error) {
+		l.Warn(ctx, "slow charge")
+		l.Error(ctx, "charge failed")
+		return struct{}{}, nil
+	})
+	l.Error(context.Background(), "untagged failure")
+
+	if len(alerts) != 1 || alerts[0].Message != "charge failed" {
+		t.Errorf("Expected one alert for the tagged error, got %v", alerts)
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	received := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]string
+		json.Unmarshal(raw, &body)
+		received <- body
+	}))
+	defer srv.Close()
+
+	SlackNotifier(srv.URL)(LogOutput{
+		Level:     LevelError,
+		Message:   "charge failed",
+		SessionID: "s1",
+		Details:   map[string]interface{}{"category": "billing"},
+	})
+
+	select {
+	case body := <-received:
+		if body["text"] != "*error* [billing] charge failed\nsession: `s1`" {
+			t.Errorf("Unexpected Slack text %q", body["text"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a Slack post")
+	}
+}
@@ -0,0 +1,266 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// OverflowPolicy decides what an AsyncSink does with an entry that arrives
+// while its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDropNewest drops the arriving entry.
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued entry to make room.
+	OverflowDropOldest
+	// OverflowBlock makes the caller wait for room, trading latency for
+	// completeness.
+	OverflowBlock
+	// OverflowSample keeps one arriving entry in SampleRate, making room by
+	// dropping the oldest, and drops the rest.
+	OverflowSample
+)
+
+type AsyncOptions struct {
+	// QueueSize is the number of entries waiting for delivery before the
+	// Overflow policy applies. Defaults to 10000.
+	QueueSize int
+	// Overflow defaults to OverflowDropNewest.
+	Overflow OverflowPolicy
+	// SampleRate is the sampling ratio of OverflowSample. Defaults to 10.
+	SampleRate int
+	// Dir, when set, keeps the queue in segment files in this directory
+	// instead of memory, so entries not yet delivered survive a crash or
+	// restart and are delivered by the next AsyncSink opened on Dir. Delivery
+	// is at least once: an entry being written when the process dies is
+	// written again.
+	Dir string
+	// SegmentSize is the size at which a new segment file is started.
+	// Defaults to 4MiB.
+	SegmentSize int64
+}
+
+// AsyncStats counts the outcomes of an AsyncSink's entries.
+type AsyncStats struct {
+	Delivered uint64 `json:"delivered"`
+	Failed    uint64 `json:"failed"`
+	// Blocked counts writes that waited for room under OverflowBlock.
+	Blocked       uint64 `json:"blocked"`
+	DroppedNewest uint64 `json:"droppedNewest"`
+	DroppedOldest uint64 `json:"droppedOldest"`
+	// SampledOut counts entries dropped by OverflowSample.
+	SampledOut uint64 `json:"sampledOut"`
+}
+
+func (s AsyncStats) dropped() uint64 {
+	return s.DroppedNewest + s.DroppedOldest + s.SampledOut
+}
+
+// AsyncSink hands entries to a background goroutine that writes them to the
+// wrapped sink, so a slow sink never blocks the caller. Close it before
+// exiting to deliver what is still queued.
+type AsyncSink struct {
+	sink Sink
+	opts AsyncOptions
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queue     entryQueue
+	writing   bool
+	closed    bool
+	stats     AsyncStats
+	overflows uint64
+	lastError error
+	done      chan struct{}
+}
+
+func NewAsyncSink(sink Sink, opts AsyncOptions) (*AsyncSink, error) {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 10000
+	}
+	if opts.SampleRate <= 0 {
+		opts.SampleRate = 10
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = 4 << 20
+	}
+	var queue entryQueue = &memQueue{entries: newRing[LogOutput](opts.QueueSize)}
+	if opts.Dir != "" {
+		dq, err := openDiskQueue(opts.Dir, opts.SegmentSize)
+		if err != nil {
+			return nil, err
+		}
+		queue = dq
+	}
+	s := &AsyncSink{sink: sink, opts: opts, queue: queue, done: make(chan struct{})}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+	return s, nil
+}
+
+// Write queues output and returns at once, unless the queue is full under
+// OverflowBlock. It reports an error only if the entry could not be queued.
+func (s *AsyncSink) Write(output LogOutput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue.len() >= s.opts.QueueSize && !s.closed {
+		switch s.opts.Overflow {
+		case OverflowBlock:
+			s.stats.Blocked++
+			for s.queue.len() >= s.opts.QueueSize && !s.closed {
+				s.cond.Wait()
+			}
+		case OverflowDropOldest:
+			s.queue.pop()
+			s.stats.DroppedOldest++
+		case OverflowSample:
+			s.overflows++
+			if (s.overflows-1)%uint64(s.opts.SampleRate) != 0 {
+				s.stats.SampledOut++
+				return nil
+			}
+			s.queue.pop()
+			s.stats.DroppedOldest++
+		default:
+			s.stats.DroppedNewest++
+			return nil
+		}
+	}
+	if s.closed {
+		return fmt.Errorf("async sink closed")
+	}
+	if err := s.queue.push(output); err != nil {
+		return err
+	}
+	s.cond.Broadcast()
+	return nil
+}
+
+func (s *AsyncSink) run() {
+	defer close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for s.queue.len() == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		output, ok := s.queue.pop()
+		if !ok {
+			return
+		}
+		s.writing = true
+		s.cond.Broadcast()
+		s.mu.Unlock()
+		err := s.sink.Write(output)
+		s.mu.Lock()
+		s.writing = false
+		s.queue.commit()
+		if err != nil {
+			s.stats.Failed++
+			s.lastError = err
+			reportError(nil, &SinkError{Sink: s.sink, Err: err})
+		} else {
+			s.stats.Delivered++
+		}
+		s.cond.Broadcast()
+	}
+}
+
+// Flush waits until every queued entry has been written, then flushes the
+// wrapped sink if it buffers.
+func (s *AsyncSink) Flush() error {
+	s.mu.Lock()
+	for (s.queue.len() > 0 || s.writing) && !s.closed {
+		s.cond.Wait()
+	}
+	s.mu.Unlock()
+	if f, ok := s.sink.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Reopen reopens the wrapped sink if it implements Reopener. Entries already
+// queued are written to the reopened destination.
+func (s *AsyncSink) Reopen() error {
+	if r, ok := s.sink.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+func (s *AsyncSink) Stats() AsyncStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Buffered reports how many entries are waiting for delivery.
+func (s *AsyncSink) Buffered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queue.len()
+}
+
+func (s *AsyncSink) SinkState() SinkState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := SinkState{
+		Name:     fmt.Sprintf("async(%T)", s.sink),
+		Buffered: s.queue.len(),
+		Dropped:  s.stats.dropped(),
+	}
+	if s.lastError != nil {
+		state.LastError = s.lastError.Error()
+	}
+	return state
+}
+
+// Close delivers the queued entries, then closes the queue and the wrapped
+// sink if it implements io.Closer.
+func (s *AsyncSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+	<-s.done
+
+	err := s.queue.close()
+	if c, ok := s.sink.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// entryQueue holds the entries waiting for an AsyncSink's worker. commit
+// records that the entries popped so far have been handled, for queues that
+// persist their position.
+type entryQueue interface {
+	push(output LogOutput) error
+	pop() (LogOutput, bool)
+	commit()
+	len() int
+	close() error
+}
+
+type memQueue struct {
+	entries *ring[LogOutput]
+}
+
+func (q *memQueue) push(output LogOutput) error {
+	q.entries.push(output)
+	return nil
+}
+
+func (q *memQueue) pop() (LogOutput, bool) { return q.entries.pop() }
+func (q *memQueue) commit()                {}
+func (q *memQueue) len() int               { return q.entries.len() }
+func (q *memQueue) close() error           { return nil }
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAsyncSink(t *testing.T) {
+	for _, dir := range []string{"", t.TempDir()} {
+		sink := &lockedSink{}
+		async, err := NewAsyncSink(sink, AsyncOptions{Dir: dir, SegmentSize: 256})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := New(WithSinks(async))
+		for i := 0; i < 20; i++ {
+			l.Info(context.Background(), fmt.Sprint(i))
+		}
+		if err := async.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got := messages(sink.entries)
+		if len(got) != 20 || got[0] != "0" || got[19] != "19" {
+			t.Errorf("dir %q: unexpected delivery %v", dir, got)
+		}
+	}
+}
+
+func TestDiskQueue_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	q, err := openDiskQueue(dir, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		entry := newEntry(context.Background(), LevelError, fmt.Errorf("flush: %w", errors.Join(fmt.Errorf("wrapped: %w", io.EOF), io.ErrUnexpectedEOF)))
+		entry.Message = fmt.Sprint(i)
+		entry.Details["tags"] = []string{"a"}
+		if err := q.push(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := q.pop(); !ok {
+		t.Fatal("Expected an entry")
+	}
+	q.commit()
+	q.pop()
+	q.close()
+
+	q, err = openDiskQueue(dir, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.close()
+	if q.len() != 4 {
+		t.Fatalf("Expected 4 uncommitted entries after reopening, got %d", q.len())
+	}
+	var got []interface{}
+	for {
+		entry, ok := q.pop()
+		if !ok {
+			break
+		}
+		if _, ok := entry.Details["tags"].([]string); !ok {
+			t.Errorf("Expected tags restored as []string, got %T", entry.Details["tags"])
+		}
+		if _, ok := entry.Details["errorChain"].([]string); !ok {
+			t.Errorf("Expected the error chain restored as []string, got %T", entry.Details["errorChain"])
+		}
+		joined := entry.Details["errors"].([]interface{})[0].(map[string]interface{})
+		if _, ok := joined["errorChain"].([]string); !ok {
+			t.Errorf("Expected joined errors' chains restored as []string, got %T", joined["errorChain"])
+		}
+		got = append(got, entry.Message)
+	}
+	if fmt.Sprint(got) != "[1 2 3 4]" {
+		t.Errorf("Unexpected entries %v", got)
+	}
+}
+
+// gateSink blocks writes until release is closed.
+type gateSink struct {
+	lockedSink
+	release chan struct{}
+}
+
+func (s *gateSink) Write(output LogOutput) error {
+	<-s.release
+	return s.lockedSink.Write(output)
+}
+
+func TestAsyncSink_Overflow(t *testing.T) {
+	for _, tc := range []struct {
+		policy OverflowPolicy
+		want   string
+		stats  AsyncStats
+	}{
+		{OverflowDropNewest, "[0 1 2]", AsyncStats{Delivered: 3, DroppedNewest: 4}},
+		{OverflowDropOldest, "[0 5 6]", AsyncStats{Delivered: 3, DroppedOldest: 4}},
+		{OverflowSample, "[0 3 5]", AsyncStats{Delivered: 3, DroppedOldest: 2, SampledOut: 2}},
+	} {
+		sink := &gateSink{release: make(chan struct{})}
+		async, err := NewAsyncSink(sink, AsyncOptions{QueueSize: 2, Overflow: tc.policy, SampleRate: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		async.Write(LogOutput{Message: "0"})
+		waitFor(t, func() bool { return async.Buffered() == 0 })
+		for i := 1; i < 7; i++ {
+			async.Write(LogOutput{Message: fmt.Sprint(i)})
+		}
+		close(sink.release)
+		async.Close()
+
+		if got := fmt.Sprint(messages(sink.entries)); got != tc.want {
+			t.Errorf("Policy %d: expected %s, got %s", tc.policy, tc.want, got)
+		}
+		if stats := async.Stats(); stats != tc.stats {
+			t.Errorf("Policy %d: expected %+v, got %+v", tc.policy, tc.stats, stats)
+		}
+	}
+}
+
+func TestAsyncSink_Block(t *testing.T) {
+	sink := &gateSink{release: make(chan struct{})}
+	async, err := NewAsyncSink(sink, AsyncOptions{QueueSize: 1, Overflow: OverflowBlock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	async.Write(LogOutput{Message: "0"})
+	waitFor(t, func() bool { return async.Buffered() == 0 })
+	async.Write(LogOutput{Message: "1"})
+
+	written := make(chan struct{})
+	go func() {
+		async.Write(LogOutput{Message: "2"})
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Expected Write to block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(sink.release)
+	<-written
+	async.Close()
+
+	if got := fmt.Sprint(messages(sink.entries)); got != "[0 1 2]" {
+		t.Errorf("Expected every entry delivered, got %s", got)
+	}
+	if async.Stats().Blocked != 1 {
+		t.Errorf("Expected one blocked write, got %+v", async.Stats())
+	}
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+type BatchOptions struct {
+	// Encoder renders each entry; JSONEncoder when nil. Binary encoders
+	// (MsgpackEncoder, CBOREncoder) are written without newlines.
+	Encoder Encoder
+	// MaxEntries flushes once this many entries are buffered. Defaults to 100.
+	MaxEntries int
+	// MaxBytes flushes before a batch would exceed this size. A single entry
+	// larger than MaxBytes is written on its own. Defaults to 1MiB.
+	MaxBytes int
+	// FlushInterval flushes a partial batch this long after its first entry.
+	// Defaults to 100ms.
+	FlushInterval time.Duration
+}
+
+// BatchSink buffers entries and writes them as newline-delimited JSON in a
+// single Write per batch, trading a little latency for far fewer syscalls.
+// Close it (or call Flush) before exiting so the last batch is not lost.
+type BatchSink struct {
+	w    io.Writer
+	opts BatchOptions
+
+	mu      sync.Mutex
+	buf     []byte
+	entries int
+	timer   *time.Timer
+	err     error
+}
+
+func NewBatchSink(w io.Writer, opts BatchOptions) *BatchSink {
+	if opts.Encoder == nil {
+		opts.Encoder = JSONEncoder{}
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 100
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 1 << 20
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 100 * time.Millisecond
+	}
+	return &BatchSink{w: w, opts: opts}
+}
+
+// Write reports the error of a previous timed flush, if any, since that flush
+// had no caller to return it to.
+func (s *BatchSink) Write(output LogOutput) error {
+	encoded, err := s.opts.Encoder.Encode(output)
+	if err != nil {
+		return err
+	}
+	encoded = frame(s.opts.Encoder, encoded)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries > 0 && len(s.buf)+len(encoded) > s.opts.MaxBytes {
+		if err := s.flushLocked(); err != nil {
+			return err
+		}
+	}
+	s.buf = append(s.buf, encoded...)
+	s.entries++
+	if s.entries >= s.opts.MaxEntries || len(s.buf) >= s.opts.MaxBytes {
+		return s.flushLocked()
+	}
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.opts.FlushInterval, s.timedFlush)
+	}
+	err, s.err = s.err, nil
+	return err
+}
+
+// Flush writes any buffered entries now.
+func (s *BatchSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+func (s *BatchSink) Close() error {
+	err := s.Flush()
+	if c, ok := s.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (s *BatchSink) timedFlush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timer = nil
+	if err := s.flushLocked(); err != nil {
+		s.err = err
+	}
+}
+
+func (s *BatchSink) flushLocked() error {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.entries == 0 {
+		return nil
+	}
+	_, err := s.w.Write(s.buf)
+	s.buf = s.buf[:0]
+	s.entries = 0
+	return err
+}
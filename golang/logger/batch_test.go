@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingWriter struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *countingWriter) snapshot() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.writes...)
+}
+
+func TestBatchSink_FlushesOnMaxEntries(t *testing.T) {
+	w := &countingWriter{}
+	s := NewBatchSink(w, BatchOptions{MaxEntries: 3, FlushInterval: time.Hour})
+
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		if err := s.Write(LogOutput{Level: LevelInfo, Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writes := w.snapshot()
+	if len(writes) != 1 || strings.Count(writes[0], "\n") != 3 {
+		t.Fatalf("Expected one write of 3 lines, got %q", writes)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if writes = w.snapshot(); len(writes) != 2 || !strings.Contains(writes[1], `"message":"d"`) {
+		t.Errorf("Expected Close to flush the remainder, got %q", writes)
+	}
+}
+
+func TestBatchSink_FlushesBeforeMaxBytes(t *testing.T) {
+	w := &countingWriter{}
+	line, _ := JSONEncoder{}.Encode(LogOutput{Level: LevelInfo, Message: "x"})
+	s := NewBatchSink(w, BatchOptions{MaxBytes: 2*len(line) + 3, FlushInterval: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		s.Write(LogOutput{Level: LevelInfo, Message: "x"})
+	}
+
+	writes := w.snapshot()
+	if len(writes) != 1 || len(writes[0]) != 2*(len(line)+1) {
+		t.Errorf("Expected a batch of two entries within MaxBytes, got %q", writes)
+	}
+}
+
+func TestBatchSink_FlushesOnInterval(t *testing.T) {
+	w := &countingWriter{}
+	s := NewBatchSink(w, BatchOptions{FlushInterval: 10 * time.Millisecond})
+	defer s.Close()
+
+	s.Write(LogOutput{Level: LevelInfo, Message: "a"})
+	deadline := time.Now().Add(time.Second)
+	for len(w.snapshot()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(w.snapshot()) != 1 {
+		t.Error("Expected the partial batch to be flushed after the interval")
+	}
+}
@@ -0,0 +1,224 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultRedactedHeaders are the headers BodyLogger masks unless
+// BodyLogOptions.RedactHeaders is set.
+var DefaultRedactedHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key",
+}
+
+// BodyLogOptions configures BodyLogger.
+type BodyLogOptions struct {
+	// MaxBytes caps the body captured per request and response. Defaults
+	// to 4 KiB.
+	MaxBytes int
+	// RedactHeaders are logged as "[REDACTED]". Defaults to
+	// DefaultRedactedHeaders.
+	RedactHeaders []string
+	// Enabled turns body logging on for a request, e.g. one carrying a
+	// support token. Requests whose LogContext has WithBodyLogging(true)
+	// are logged regardless.
+	Enabled func(r *http.Request) bool
+}
+
+// WithBodyLogging marks requests handled in this context for BodyLogger.
+func (lc *LogContext) WithBodyLogging(enabled bool) *LogContext {
+	newData := lc.copyData()
+	newData.logBodies = enabled
+	return &LogContext{data: newData}
+}
+
+func (lc *LogContext) BodyLogging() bool {
+	return lc.data.logBodies
+}
+
+// BodyLogger logs request and response bodies at debug for requests with
+// body logging on, with headers redacted and bodies capped at MaxBytes:
+// JSON is indented, text kept as is and binary content summarized by size.
+// Bodies often hold personal data, so enable it per request rather than
+// globally. Place it inside Middleware so the entries carry the request's
+// context:
+//
+//	handler := logger.Middleware(logger.BodyLogger(logger.BodyLogOptions{})(mux))
+func BodyLogger(opts BodyLogOptions) func(http.Handler) http.Handler {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 4 << 10
+	}
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = DefaultRedactedHeaders
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if !GetLogContext(ctx).BodyLogging() && (opts.Enabled == nil || !opts.Enabled(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var captured []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				captured, _ = io.ReadAll(io.LimitReader(r.Body, int64(opts.MaxBytes)+1))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(captured), r.Body), r.Body}
+			}
+			size := int64(len(captured))
+			if r.ContentLength > size {
+				size = r.ContentLength
+			}
+			logBody(ctx, "HTTP request body", map[string]interface{}{
+				"method": r.Method,
+				"url":    r.URL.RequestURI(),
+			}, r.Header, captured, size, opts)
+
+			rec := &bodyRecorder{ResponseWriter: w, status: http.StatusOK, max: opts.MaxBytes}
+			next.ServeHTTP(rec, r)
+			logBody(ctx, "HTTP response body", map[string]interface{}{
+				"status": rec.status,
+			}, w.Header(), rec.body.Bytes(), rec.size, opts)
+		})
+	}
+}
+
+func logBody(ctx context.Context, message string, fields map[string]interface{}, header http.Header, body []byte, size int64, opts BodyLogOptions) {
+	fields["headers"] = redactHeaders(header, opts.RedactHeaders)
+	contentType := header.Get("Content-Type")
+	if contentType != "" {
+		fields["contentType"] = contentType
+	}
+	if size > 0 {
+		truncated := len(body) > opts.MaxBytes
+		if truncated {
+			body = body[:opts.MaxBytes]
+		}
+		fields["body"] = renderBody(contentType, body, truncated, size)
+		fields["bodyBytes"] = size
+		if truncated {
+			fields["truncated"] = true
+		}
+	}
+	output := newEntry(ctx, LevelDebug, message)
+	output.Details["http"] = fields
+	Default().Emit(ctx, output)
+}
+
+func redactHeaders(header http.Header, redact []string) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	for _, name := range redact {
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := headers[name]; ok {
+			headers[name] = "[REDACTED]"
+		}
+	}
+	return headers
+}
+
+// renderBody indents complete JSON, keeps text and summarizes binary
+// content.
+func renderBody(contentType string, body []byte, truncated bool, size int64) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if isJSON && !truncated {
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") == nil {
+			return indented.String()
+		}
+	}
+	if !isText(mediaType, body) {
+		return fmt.Sprintf("<binary %s, %s>", firstNonEmpty(mediaType, "unknown type"), formatBytes(size))
+	}
+	if truncated {
+		return string(trimPartialRune(body)) + "…"
+	}
+	return string(body)
+}
+
+func isText(mediaType string, body []byte) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json", strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/javascript":
+		return true
+	case mediaType == "":
+		// Untyped bodies are text if they decode as UTF-8.
+		body = trimPartialRune(body)
+		return utf8.Valid(body) && bytes.IndexByte(body, 0) < 0
+	}
+	return false
+}
+
+// trimPartialRune drops a multi-byte character cut off by truncation.
+func trimPartialRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax-1 && len(b) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(b); r != utf8.RuneError || size != 1 {
+			break
+		}
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// bodyRecorder captures up to max bytes of a response body.
+type bodyRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	max         int
+	body        bytes.Buffer
+	size        int64
+}
+
+func (r *bodyRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	if room := r.max + 1 - r.body.Len(); room > 0 {
+		r.body.Write(b[:min(room, len(b))])
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *bodyRecorder) Flush() {
+	r.wroteHeader = true
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *bodyRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil && !r.wroteHeader {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+func (r *bodyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+)
+
+// Budget caps how much a scope may log, so one chatty request cannot flood
+// the logs. Zero values mean unlimited.
+type Budget struct {
+	MaxEntries int
+	// MaxBytes caps the entries' size, measured as their JSON encoding.
+	MaxBytes int64
+}
+
+// budgetState accounts for the entries of the scope a budget was set on,
+// including its nested scopes.
+type budgetState struct {
+	Budget
+	entries  atomic.Int64
+	bytes    atomic.Int64
+	dropped  atomic.Int64
+	exceeded atomic.Bool
+}
+
+// WithBudget tracks the entries and bytes logged in the WithLogContext scope
+// opened with the returned context, nested scopes included, and logs the
+// totals when the scope ends. Once the budget is exceeded, a warning is
+// logged and only error entries are kept for the rest of the scope; the
+// others are counted as dropped. A zero Budget only tracks.
+//
+// Accounting happens in StandardLogger, so the budget has no effect on
+// other Logger implementations.
+func (lc *LogContext) WithBudget(b Budget) *LogContext {
+	newData := lc.copyData()
+	newData.budget = &budgetState{Budget: b}
+	return &LogContext{data: newData}
+}
+
+// admit records output, reporting whether it is within the budget and
+// whether it is the first entry over it.
+func (b *budgetState) admit(output LogOutput) (keep, exceeded bool) {
+	var size int64
+	if raw, err := json.Marshal(output); err == nil {
+		size = int64(len(raw))
+	}
+	over := b.MaxEntries > 0 && b.entries.Load() >= int64(b.MaxEntries) ||
+		b.MaxBytes > 0 && b.bytes.Load()+size > b.MaxBytes
+	if over && output.Level != LevelError {
+		b.dropped.Add(1)
+		return false, b.exceeded.CompareAndSwap(false, true)
+	}
+	b.entries.Add(1)
+	b.bytes.Add(size)
+	return true, false
+}
+
+// unbudgeted returns a context for the budget's own entries, which are not
+// counted against it.
+func unbudgeted(ctx context.Context) context.Context {
+	lc := GetLogContext(ctx)
+	if lc.data.budget == nil {
+		return ctx
+	}
+	newData := lc.copyData()
+	newData.budget = nil
+	return context.WithValue(ctx, logContextKey, &LogContext{data: newData})
+}
+
+// budgetScope logs the totals of a budget set on inner's scope when the
+// scope ends.
+func budgetScope(inner context.Context, outer, child *LogContext) (done func()) {
+	b := child.data.budget
+	if b == nil || b == outer.data.budget {
+		return func() {}
+	}
+	return func() {
+		ctx := unbudgeted(inner)
+		entries, dropped := b.entries.Load(), b.dropped.Load()
+		fields := []interface{}{F("logEntries", entries), BytesField("logSize", b.bytes.Load())}
+		if dropped > 0 {
+			fields = append(fields, F("droppedEntries", dropped))
+			Warn(ctx, append([]interface{}{"Log budget exceeded: logged " + strconv.FormatInt(entries, 10) +
+				" entries, dropped " + strconv.FormatInt(dropped, 10)}, fields...)...)
+			return
+		}
+		Info(ctx, append([]interface{}{"Logged " + strconv.FormatInt(entries, 10) + " entries"}, fields...)...)
+	}
+}
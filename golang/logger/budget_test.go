@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithBudget(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	lc := NewLogContext(LogContextData{SessionID: "s1"}).WithBudget(Budget{MaxEntries: 2})
+	_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+		Info(ctx, "one")
+		_, _ = WithLogContext(ctx, GetLogContext(ctx).WithCategory("db"), func(ctx context.Context) (struct{}, error) {
+			Info(ctx, "two")
+			Info(ctx, "three")
+			return struct{}{}, nil
+		})
+		Info(ctx, "four")
+		Error(ctx, "five")
+		return struct{}{}, nil
+	})
+
+	var messages []interface{}
+	for _, entry := range sink.entries {
+		messages = append(messages, entry.Message)
+	}
+	if len(sink.entries) != 5 || messages[2] != "Log budget exceeded, keeping only errors for the rest of the scope" || messages[3] != "five" {
+		t.Fatalf("Expected entries over the budget dropped, got %v", messages)
+	}
+	summary := sink.entries[4]
+	fields := summary.Details["fields"].(map[string]interface{})
+	if summary.Level != LevelWarn || summary.SessionID != "s1" || fields["logEntries"] != int64(3) || fields["droppedEntries"] != int64(2) {
+		t.Errorf("Expected the totals on scope exit, got %+v", summary)
+	}
+}
+
+func TestWithBudget_TracksOnly(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	_, _ = WithLogContext(context.Background(), NewLogContext(LogContextData{}).WithBudget(Budget{}), func(ctx context.Context) (struct{}, error) {
+		Info(ctx, "hello")
+		return struct{}{}, nil
+	})
+	if len(sink.entries) != 2 {
+		t.Fatalf("Expected the entry and the totals, got %v", sink.entries)
+	}
+	fields := sink.entries[1].Details["fields"].(map[string]interface{})
+	if fields["logEntries"] != int64(1) || fields["logSize_bytes"].(int64) <= 0 {
+		t.Errorf("Expected the entry's size tracked, got %v", fields)
+	}
+}
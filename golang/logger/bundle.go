@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+)
+
+// bundleInfo is the bundle's info.json, describing the process.
+type bundleInfo struct {
+	GeneratedAt    time.Time `json:"generatedAt"`
+	Hostname       string    `json:"hostname,omitempty"`
+	PID            int       `json:"pid"`
+	GoVersion      string    `json:"goVersion"`
+	Goroutines     int       `json:"goroutines"`
+	InternalErrors uint64    `json:"internalErrors"`
+}
+
+// WriteSupportBundle writes a zip archive of l's diagnostics for attaching
+// to support tickets:
+//
+//   - info.json: the host, process, Go version and pipeline failure count.
+//   - config.json: the logger's Config.
+//   - state.json: its State, with sink health and filtered and dropped
+//     entry counts.
+//   - recent.jsonl: the entries kept by WithRecentEntries, redacted with the
+//     current configuration.
+func WriteSupportBundle(w io.Writer, l *StandardLogger) error {
+	zw := zip.NewWriter(w)
+	info := bundleInfo{
+		GeneratedAt:    l.now().UTC(),
+		PID:            os.Getpid(),
+		GoVersion:      runtime.Version(),
+		Goroutines:     runtime.NumGoroutine(),
+		InternalErrors: InternalErrors(),
+	}
+	info.Hostname, _ = os.Hostname()
+	for _, file := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"info.json", info},
+		{"config.json", l.Config()},
+		{"state.json", l.State()},
+	} {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.v); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("recent.jsonl")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, output := range l.Recent() {
+		if err := enc.Encode(output); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// SupportBundleHandler serves WriteSupportBundle's archive as a download,
+// for an admin endpoint.
+func SupportBundleHandler(l *StandardLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="logger-support-bundle.zip"`)
+		_ = WriteSupportBundle(w, l)
+	})
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteSupportBundle(t *testing.T) {
+	l := New(WithSinks(&recordingSink{}), WithLevel(LevelInfo), WithRecentEntries(10))
+	if err := l.ApplyConfig(Config{Level: LevelInfo, Redact: []string{"token"}}); err != nil {
+		t.Fatal(err)
+	}
+	l.Debug(context.Background(), "connecting", F("token", "secret"))
+	l.Info(context.Background(), "connected")
+
+	var buf bytes.Buffer
+	if err := WriteSupportBundle(&buf, l); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	var state LoggerState
+	if err := json.Unmarshal([]byte(files["state.json"]), &state); err != nil || state.Emitted != 1 || state.Filtered != 1 {
+		t.Errorf("Expected the logger's state, got %s (%v)", files["state.json"], err)
+	}
+	if !strings.Contains(files["config.json"], `"token"`) || !strings.Contains(files["info.json"], `"goVersion"`) {
+		t.Errorf("Expected the configuration and process info, got %v", files)
+	}
+	recent := files["recent.jsonl"]
+	if strings.Count(recent, "\n") != 2 || strings.Contains(recent, "secret") || !strings.Contains(recent, redactedValue) {
+		t.Errorf("Expected both recent entries, redacted, got %s", recent)
+	}
+	if got := l.Recent()[0].Details["fields"].(map[string]interface{})["token"]; got != redactedValue {
+		t.Errorf("Expected the filtered entry to be kept redacted, got %v", got)
+	}
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// packagePrefix is this package's import path plus a dot, the prefix of every
+// function name in it.
+var packagePrefix = reflect.TypeOf(LogOutput{}).PkgPath() + "."
+
+// SourceLocation is a Hook that records where each entry was logged as
+// details.caller ({"file", "line", "function"}): the first frame outside this
+// package.
+func SourceLocation(_ context.Context, output *LogOutput) {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.Function, packagePrefix) && !strings.HasSuffix(frame.File, "_test.go")
+		if !internal {
+			if output.Details == nil {
+				output.Details = make(map[string]interface{})
+			}
+			output.Details["caller"] = map[string]interface{}{
+				"file":     frame.File,
+				"line":     frame.Line,
+				"function": frame.Function,
+			}
+			return
+		}
+		if !more {
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+type canonicalKey struct{}
+
+// CanonicalLine accumulates fields over a request's lifetime for one
+// "canonical log line" summarizing it, emitted when the request completes.
+// Its methods are safe for concurrent use.
+type CanonicalLine struct {
+	mu     sync.Mutex
+	fields map[string]interface{}
+}
+
+// WithCanonicalLine returns a context carrying a new canonical line, for
+// Canonical to find, and the line, which the caller emits with Emit.
+// Middleware does this for every request.
+func WithCanonicalLine(ctx context.Context) (context.Context, *CanonicalLine) {
+	line := &CanonicalLine{fields: make(map[string]interface{})}
+	return context.WithValue(ctx, canonicalKey{}, line), line
+}
+
+// Canonical returns the canonical line of the request ctx belongs to:
+//
+//	logger.Canonical(ctx).Set("plan", account.Plan)
+//	logger.Canonical(ctx).Add("db_ms", elapsed.Milliseconds())
+//
+// Outside a request, it returns a line that is never emitted.
+func Canonical(ctx context.Context) *CanonicalLine {
+	if line, ok := ctx.Value(canonicalKey{}).(*CanonicalLine); ok {
+		return line
+	}
+	return &CanonicalLine{fields: make(map[string]interface{})}
+}
+
+// Set sets a field, replacing any earlier value.
+func (c *CanonicalLine) Set(key string, value interface{}) {
+	if structured, ok := structuredValue(value); ok {
+		value = structured
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fields[key] = value
+}
+
+// Add adds delta to a counter field, such as the number of queries or the
+// time spent in them.
+func (c *CanonicalLine) Add(key string, delta int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, _ := c.fields[key].(int64)
+	c.fields[key] = n + delta
+}
+
+// setDefaults sets the fields not already set, if any field is.
+func (c *CanonicalLine) setDefaults(defaults map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.fields) == 0 {
+		return
+	}
+	for key, value := range defaults {
+		if _, ok := c.fields[key]; !ok {
+			c.fields[key] = value
+		}
+	}
+}
+
+// Emit logs the line at info as "canonical-log-line", with its fields under
+// details.fields and ctx's LogContext. Lines no field was set on are not
+// logged.
+func (c *CanonicalLine) Emit(ctx context.Context) {
+	c.mu.Lock()
+	fields := cloneMap(c.fields, 0)
+	c.mu.Unlock()
+	if len(fields) == 0 {
+		return
+	}
+	output := newEntry(ctx, LevelInfo, "canonical-log-line")
+	output.Details["fields"] = fields
+	Default().Emit(ctx, output)
+}
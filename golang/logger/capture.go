@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"context"
+	"slices"
+)
+
+// CaptureScope returns a context whose entries, and those of the scopes
+// nested in it, even ones replacing its LogContext, are also written to sink
+// in addition to the logger's sinks. It collects the logs of one operation,
+// e.g. to return them in an API error response or attach them to a support
+// bundle:
+//
+//	var buf bytes.Buffer
+//	ctx = logger.CaptureScope(ctx, logger.WriterSink{W: &buf})
+//	if err := process(ctx); err != nil {
+//		writeError(w, err, buf.String())
+//	}
+//
+// Entries are captured once they pass the logger's filters, as they are
+// written. sink must be safe for concurrent use if the scope logs from
+// several goroutines. Capturing happens in StandardLogger, so it has no
+// effect on other Logger implementations.
+func CaptureScope(ctx context.Context, sink Sink) context.Context {
+	lc := GetLogContext(ctx)
+	newData := lc.copyData()
+	newData.captures = append(slices.Clip(newData.captures), sink)
+	return context.WithValue(ctx, logContextKey, &LogContext{data: newData})
+}
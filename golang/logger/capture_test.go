@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCaptureScope(t *testing.T) {
+	sink := &lockedSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink), WithLevel(LevelInfo)))
+	defer SetDefault(previous)
+
+	captured := &recordingSink{}
+	ctx := CaptureScope(context.Background(), captured)
+	Info(context.Background(), "outside")
+	Info(ctx, "inside")
+	Debug(ctx, "filtered")
+	_, _ = WithLogContext(ctx, NewLogContext(LogContextData{Category: "db"}), func(ctx context.Context) (struct{}, error) {
+		Warn(ctx, "nested")
+		return struct{}{}, nil
+	})
+
+	if len(captured.entries) != 2 || captured.entries[0].Message != "inside" || captured.entries[1].Message != "nested" {
+		t.Errorf("Expected the scope's written entries, got %+v", captured.entries)
+	}
+	if len(sink.entries) != 3 {
+		t.Errorf("Expected the logger's sinks to get every entry, got %d", len(sink.entries))
+	}
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// Catalog resolves message keys to localized text, for products whose
+// operator-facing logs must be readable in several languages.
+type Catalog interface {
+	// Text returns the message for key in lang with params substituted, or
+	// false when the catalog has no such message.
+	Text(lang, key string, params map[string]interface{}) (string, bool)
+}
+
+// MapCatalog is a Catalog of message templates by language and key, whose
+// {placeholders} are replaced with the named params:
+//
+//	logger.MapCatalog{
+//		"en": {"disk.full": "Disk {path} is full"},
+//		"de": {"disk.full": "Festplatte {path} ist voll"},
+//	}
+type MapCatalog map[string]map[string]string
+
+func (c MapCatalog) Text(lang, key string, params map[string]interface{}) (string, bool) {
+	template, ok := c[lang][key]
+	if !ok {
+		return "", false
+	}
+	return expandTemplate(template, func(name string) (string, bool) {
+		v, ok := params[name]
+		if !ok {
+			return "", false
+		}
+		return renderArg(v), true
+	})
+}
+
+type catalogConfig struct {
+	catalog   Catalog
+	languages []string
+}
+
+var catalog atomic.Pointer[catalogConfig]
+
+// SetCatalog resolves Msg messages through c. An entry's message is the text
+// in the first language; with more than one, details.localized holds the
+// text in each. Passing nil removes the catalog, leaving messages as their
+// keys.
+func SetCatalog(c Catalog, languages ...string) {
+	if c == nil {
+		catalog.Store(nil)
+		return
+	}
+	catalog.Store(&catalogConfig{catalog: c, languages: languages})
+}
+
+// Message is a localizable message: a catalog key and its parameters.
+type Message struct {
+	Key    string
+	Params map[string]interface{}
+}
+
+// Msg returns a message logged by key, with the key and params emitted as
+// details.messageKey and details.messageParams whatever the language, so
+// entries can be queried and aggregated by message:
+//
+//	logger.Warn(ctx, logger.Msg("disk.full", logger.F("path", "/var")))
+func Msg(key string, params ...Field) Message {
+	m := Message{Key: key}
+	if len(params) > 0 {
+		m.Params = make(map[string]interface{})
+		for _, p := range params {
+			p.addTo(m.Params)
+		}
+	}
+	return m
+}
+
+// String returns the message in the catalog's first language, or its key.
+func (m Message) String() string {
+	if config := catalog.Load(); config != nil && len(config.languages) > 0 {
+		if text, ok := config.catalog.Text(config.languages[0], m.Key, m.Params); ok {
+			return text
+		}
+	}
+	return m.Key
+}
+
+// addTo records m in an entry's details.
+func (m Message) addTo(details map[string]interface{}) {
+	details["messageKey"] = m.Key
+	if len(m.Params) > 0 {
+		details["messageParams"] = m.Params
+	}
+	config := catalog.Load()
+	if config == nil || len(config.languages) < 2 {
+		return
+	}
+	localized := make(map[string]string, len(config.languages))
+	for _, lang := range config.languages {
+		if text, ok := config.catalog.Text(lang, m.Key, m.Params); ok {
+			localized[lang] = text
+		}
+	}
+	if len(localized) > 0 {
+		details["localized"] = localized
+	}
+}
+
+// expandTemplate replaces each {name} in template with value(name), failing
+// when a name has no value.
+func expandTemplate(template string, value func(name string) (string, bool)) (string, bool) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		v, ok := value(template[start+1 : start+end])
+		if !ok {
+			return "", false
+		}
+		b.WriteString(template[:start])
+		b.WriteString(v)
+		template = template[start+end+1:]
+	}
+	b.WriteString(template)
+	return b.String(), true
+}
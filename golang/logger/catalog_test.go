@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMsg(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink))
+	ctx := context.Background()
+
+	l.Warn(ctx, Msg("disk.full", F("path", "/var")))
+	if entry := sink.entries[0]; entry.Message != "disk.full" || entry.Data != nil ||
+		entry.Details["messageKey"] != "disk.full" || entry.Details["messageParams"].(map[string]interface{})["path"] != "/var" {
+		t.Errorf("Expected the key as message without a catalog, got %+v", entry)
+	}
+
+	SetCatalog(MapCatalog{
+		"en": {"disk.full": "Disk {path} is full"},
+		"de": {"disk.full": "Festplatte {path} ist voll"},
+	}, "en", "de")
+	defer SetCatalog(nil)
+	l.Warn(ctx, Msg("disk.full", F("path", "/var")))
+	entry := sink.entries[1]
+	localized := entry.Details["localized"].(map[string]string)
+	if entry.Message != "Disk /var is full" || localized["de"] != "Festplatte /var ist voll" || entry.Details["messageKey"] != "disk.full" {
+		t.Errorf("Expected the localized texts, got %+v", entry)
+	}
+}
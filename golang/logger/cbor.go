@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// CBOREncoder renders entries as CBOR (RFC 8949) maps with the same keys as
+// JSONEncoder. Map keys are sorted, so identical entries encode identically.
+// Entries are self-delimiting, so sinks write them back to back without a
+// newline.
+type CBOREncoder struct{}
+
+func (CBOREncoder) Encode(output LogOutput) ([]byte, error) {
+	return appendCBOR(nil, output.fields()), nil
+}
+
+func (CBOREncoder) binary() {}
+
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+)
+
+// appendCBOR appends the CBOR encoding of v. Values of types it does not know
+// are converted through their JSON representation.
+func appendCBOR(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xf6)
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	case int:
+		return appendCBORInt(b, int64(v))
+	case int32:
+		return appendCBORInt(b, int64(v))
+	case int64:
+		return appendCBORInt(b, v)
+	case uint:
+		return appendCBORHead(b, cborUint, uint64(v))
+	case uint32:
+		return appendCBORHead(b, cborUint, uint64(v))
+	case uint64:
+		return appendCBORHead(b, cborUint, v)
+	case float32:
+		return appendCBORFloat(b, float64(v))
+	case float64:
+		return appendCBORFloat(b, v)
+	case string:
+		return append(appendCBORHead(b, cborText, uint64(len(v))), v...)
+	case LogLevel:
+		return appendCBOR(b, string(v))
+	case []byte:
+		return append(appendCBORHead(b, cborBytes, uint64(len(v))), v...)
+	case time.Duration:
+		return appendCBORInt(b, int64(v))
+	case time.Time:
+		return appendCBOR(b, v.Format(time.RFC3339Nano))
+	case []string:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, s := range v {
+			b = appendCBOR(b, s)
+		}
+		return b
+	case []interface{}:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, e := range v {
+			b = appendCBOR(b, e)
+		}
+		return b
+	case map[string]string:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		for _, k := range sortedKeys(v) {
+			b = appendCBOR(b, k)
+			b = appendCBOR(b, v[k])
+		}
+		return b
+	case map[string]interface{}:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		for _, k := range sortedKeys(v) {
+			b = appendCBOR(b, k)
+			b = appendCBOR(b, v[k])
+		}
+		return b
+	case error:
+		return appendCBOR(b, v.Error())
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return appendCBOR(b, fmt.Sprint(v))
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return appendCBOR(b, string(raw))
+	}
+	return appendCBOR(b, generic)
+}
+
+func appendCBORInt(b []byte, v int64) []byte {
+	if v >= 0 {
+		return appendCBORHead(b, cborUint, uint64(v))
+	}
+	return appendCBORHead(b, cborNegInt, uint64(-1-v))
+}
+
+// appendCBORFloat writes integral values as integers, since JSON-decoded
+// numbers are all floats, and others as single precision when lossless.
+func appendCBORFloat(b []byte, v float64) []byte {
+	if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+		return appendCBORInt(b, int64(v))
+	}
+	if f := float32(v); float64(f) == v {
+		return binary.BigEndian.AppendUint32(append(b, 0xfa), math.Float32bits(f))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(v))
+}
+
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"flag"
+)
+
+// LogFlags holds the values of the flags RegisterFlags defines.
+type LogFlags struct {
+	Level LogLevel
+	// Format is an encoder name accepted by EncoderByName.
+	Format string
+	// File is the path entries are appended to instead of stdout.
+	File string
+}
+
+// LevelFlag defines -log-level on fs (flag.CommandLine when nil), defaulting
+// to info, and returns the level it holds.
+func LevelFlag(fs *flag.FlagSet) *LogLevel {
+	level := new(LogLevel)
+	defineLevelFlag(fs, level)
+	return level
+}
+
+func defineLevelFlag(fs *flag.FlagSet, level *LogLevel) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.TextVar(level, "log-level", LevelInfo, "minimum `level` logged: debug, info, warn or error")
+}
+
+// formatFlag validates -log-format as it is parsed.
+type formatFlag struct{ format *string }
+
+func (f formatFlag) String() string {
+	if f.format == nil {
+		return ""
+	}
+	return *f.format
+}
+
+func (f formatFlag) Set(s string) error {
+	if _, err := EncoderByName(s); err != nil {
+		return err
+	}
+	*f.format = s
+	return nil
+}
+
+// RegisterFlags defines -log-level, -log-format and -log-file on fs
+// (flag.CommandLine when nil), so CLIs get the same logging flags without
+// boilerplate:
+//
+//	logFlags := logger.RegisterFlags(nil)
+//	flag.Parse()
+//	opts, file, err := logFlags.Options()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if file != nil {
+//		defer file.Close()
+//	}
+//	logger.SetDefault(logger.New(opts...))
+func RegisterFlags(fs *flag.FlagSet) *LogFlags {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	f := &LogFlags{Format: "json"}
+	defineLevelFlag(fs, &f.Level)
+	fs.Var(formatFlag{&f.Format}, "log-format", "log `format`: json, pretty, logfmt, ecs, datadog, gcp, cbor, msgpack, protobuf, cef or leef")
+	fs.StringVar(&f.File, "log-file", "", "append logs to `path` instead of stdout")
+	return f
+}
+
+// Options returns the logger options the flags select. When -log-file is
+// set, the file is opened and returned for the caller to close on exit.
+func (f *LogFlags) Options() ([]Option, *FileSink, error) {
+	enc, err := EncoderByName(f.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := []Option{WithLevel(f.Level)}
+	if f.File == "" {
+		return append(opts, WithEncoder(enc)), nil, nil
+	}
+	file, err := NewFileSink(f.File, enc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(opts, WithSinks(file)), file, nil
+}
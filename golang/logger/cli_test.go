@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	logFlags := RegisterFlags(fs)
+	if err := fs.Parse([]string{"-log-level", "WARN", "-log-format", "logfmt", "-log-file", path}); err != nil {
+		t.Fatal(err)
+	}
+	opts, file, err := logFlags.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(opts...)
+	l.Info(context.Background(), "filtered")
+	l.Warn(context.Background(), "disk low")
+	file.Close()
+
+	raw, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(raw)), "\n"); len(lines) != 1 ||
+		!strings.Contains(lines[0], "level=warn") || !strings.Contains(lines[0], `msg="disk low"`) {
+		t.Errorf("Expected one logfmt warn entry in the file, got %q", raw)
+	}
+
+	fs = flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-log-format", "yaml"}); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+	if err := fs.Parse([]string{"-log-level", "loud"}); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	level := LevelFlag(fs)
+	if *level != LevelInfo {
+		t.Errorf("Expected info by default, got %q", *level)
+	}
+	_ = fs.Parse([]string{"-log-level=debug"})
+	if *level != LevelDebug {
+		t.Errorf("Expected the parsed level, got %q", *level)
+	}
+}
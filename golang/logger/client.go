@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transport is an http.RoundTripper that logs each outbound request with the
+// LogContext of its context, and propagates the session's request ID and
+// trace to the server, so Middleware downstream continues them. The rest of
+// the LogContext, such as the user ID and metadata, is only sent to
+// PropagateHosts. Headers already set on the request are kept.
+//
+//	client := &http.Client{Transport: &logger.Transport{MaxRetries: 2}}
+//
+// One entry is logged per request once its response headers arrive, at info,
+// at warn for 5xx statuses and at error when the request fails, with the
+// method, URL (credentials removed), status, duration and retries under
+// details.http.
+type Transport struct {
+	// Base performs the requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// MaxRetries retries idempotent requests that fail to connect or get a
+	// 429, 502, 503 or 504 response up to this many times. Requests whose
+	// body cannot be replayed are never retried.
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubling for each one
+	// after it. Defaults to 100ms.
+	Backoff time.Duration
+	// PropagateHosts lists the hosts, typically internal services, that get
+	// all the headers InjectLogContext writes, including the user and tenant
+	// IDs, tags and metadata. Metadata keys the default logger redacts or
+	// tokenizes are sent redacted. Entries match the request URL's host
+	// name; one starting with a dot, as in ".svc.cluster.local", matches its
+	// subdomains, and "*" matches every host.
+	PropagateHosts []string
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	backoff := t.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	start := time.Now()
+	out := t.propagate(ctx, req)
+	resp, err := base.RoundTrip(out)
+	retries := 0
+	for ; retries < t.MaxRetries && retryable(out, resp, err); retries++ {
+		next, ok := rewind(out)
+		if !ok {
+			break
+		}
+		Debug(ctx, "Retrying "+req.Method+" "+req.URL.Redacted()+" after "+retryReason(resp, err))
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			logOutbound(ctx, req, nil, ctx.Err(), time.Since(start), retries)
+			return nil, ctx.Err()
+		case <-time.After(backoff << retries):
+		}
+		out = next
+		resp, err = base.RoundTrip(out)
+	}
+
+	logOutbound(ctx, req, resp, err, time.Since(start), retries)
+	return resp, err
+}
+
+// propagate returns a copy of req carrying the LogContext headers it does
+// not already have.
+func (t *Transport) propagate(ctx context.Context, req *http.Request) *http.Request {
+	data := GetLogContext(ctx).data
+	headers := MapCarrier{}
+	if t.propagatesTo(req.URL.Hostname()) {
+		data.Metadata = propagatedMetadata(data.Metadata)
+		injectLogContext(data, headers)
+	} else {
+		setHeader(headers, RequestIDHeader, data.SessionID)
+		injectTrace(data, headers)
+	}
+	out := req.Clone(ctx)
+	for key, value := range headers {
+		if out.Header.Get(key) == "" {
+			out.Header.Set(key, value)
+		}
+	}
+	return out
+}
+
+func (t *Transport) propagatesTo(host string) bool {
+	for _, pattern := range t.PropagateHosts {
+		if pattern == "*" || strings.EqualFold(host, pattern) ||
+			strings.HasPrefix(pattern, ".") && len(host) > len(pattern) && strings.EqualFold(host[len(host)-len(pattern):], pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// propagatedMetadata masks the metadata keys the default logger's
+// configuration redacts, and its tokenized keys, whose hooks only run for
+// entries.
+func propagatedMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return metadata
+	}
+	l, ok := Default().(*StandardLogger)
+	if !ok {
+		return metadata
+	}
+	output := LogOutput{Details: map[string]interface{}{"metadata": metadata}}
+	l.currentTuning().redactOutput(&output)
+	l.redactTokenized(&output)
+	return output.Details["metadata"].(map[string]string)
+}
+
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewind returns a copy of req with a fresh body, if it can be replayed.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context()), true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, true
+}
+
+func retryReason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
+
+func logOutbound(ctx context.Context, req *http.Request, resp *http.Response, err error, duration time.Duration, retries int) {
+	fields := map[string]interface{}{
+		"method":     req.Method,
+		"url":        req.URL.Redacted(),
+		"durationMs": float64(duration) / float64(time.Millisecond),
+	}
+	if retries > 0 {
+		fields["retries"] = retries
+	}
+	message := req.Method + " " + req.URL.Redacted()
+
+	var output LogOutput
+	switch {
+	case err != nil:
+		output = newEntry(ctx, LevelError, message+" failed:", err)
+	case resp.StatusCode >= 500:
+		output = newEntry(ctx, LevelWarn, message+" "+strconv.Itoa(resp.StatusCode))
+	default:
+		output = newEntry(ctx, LevelInfo, message+" "+strconv.Itoa(resp.StatusCode))
+	}
+	if resp != nil {
+		fields["status"] = resp.StatusCode
+	}
+	output.Details["http"] = fields
+	Default().Emit(ctx, output)
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	sink := &lockedSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	var calls atomic.Int32
+	var sessions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessions = append(sessions, r.Header.Get(RequestIDHeader))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	lc := NewLogContext(LogContextData{SessionID: "s1", Category: "billing"})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/invoices?page=2", nil)
+	client := &http.Client{Transport: &Transport{MaxRetries: 2, Backoff: 1}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent || len(sessions) != 2 || sessions[0] != "s1" || sessions[1] != "s1" {
+		t.Errorf("Expected a retried request propagating the session, got %d %v", resp.StatusCode, sessions)
+	}
+	if req.Header.Get(RequestIDHeader) != "" {
+		t.Error("Expected the caller's request to be left unmodified")
+	}
+	entry := sink.entries[len(sink.entries)-1]
+	fields := entry.Details["http"].(map[string]interface{})
+	if entry.Level != LevelInfo || entry.SessionID != "s1" || entry.Details["category"] != "billing" ||
+		fields["status"] != http.StatusNoContent || fields["retries"] != 1 || fields["url"] != server.URL+"/invoices?page=2" {
+		t.Errorf("Unexpected outbound entry: %+v", entry)
+	}
+}
+
+func TestTransport_NoRetryForUnsafeMethods(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sink := &lockedSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	client := &http.Client{Transport: &Transport{MaxRetries: 3, Backoff: 1}}
+	resp, err := client.Post(server.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 || sink.entries[0].Level != LevelWarn {
+		t.Errorf("Expected one call logged at warn, got %d calls, %v", calls.Load(), sink.entries)
+	}
+}
+
+func TestTransport_PropagateHosts(t *testing.T) {
+	l := New(WithSinks(&lockedSink{}))
+	if err := l.ApplyConfig(Config{Redact: []string{"apiKey"}}); err != nil {
+		t.Fatal(err)
+	}
+	previous := Default()
+	SetDefault(l)
+	defer SetDefault(previous)
+
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	lc := NewLogContext(LogContextData{
+		SessionID: "s1",
+		UserID:    "u1",
+		TraceID:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		Metadata:  map[string]string{"apiKey": "k", "plan": "pro"},
+	})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+	send := func(transport *Transport) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	send(&Transport{})
+	if got.Get(RequestIDHeader) != "s1" || got.Get(TraceParentHeader) == "" ||
+		got.Get(UserIDHeader) != "" || got.Get(MetadataHeader) != "" {
+		t.Errorf("Expected only the request ID and trace by default, got %v", got)
+	}
+
+	send(&Transport{PropagateHosts: []string{"example.com", "127.0.0.1"}})
+	if got.Get(UserIDHeader) != "u1" || got.Get(MetadataHeader) != `{"apiKey":"[REDACTED]","plan":"pro"}` {
+		t.Errorf("Expected the LogContext, redacted, for listed hosts, got %v", got)
+	}
+	if lc.data.Metadata["apiKey"] != "k" {
+		t.Error("Expected the context's metadata to be left alone")
+	}
+}
+
+func TestTransport_PropagatesTo(t *testing.T) {
+	transport := &Transport{PropagateHosts: []string{"api.internal", ".svc.cluster.local"}}
+	for host, want := range map[string]bool{
+		"api.internal":              true,
+		"API.internal":              true,
+		"billing.svc.cluster.local": true,
+		"svc.cluster.local":         false,
+		"evil-svc.cluster.local":    false,
+		"api.internal.attacker.com": false,
+		"example.com":               false,
+	} {
+		if got := transport.propagatesTo(host); got != want {
+			t.Errorf("propagatesTo(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config holds the settings of a StandardLogger that can change while it
+// is in use, typically loaded from a JSON file by LoadConfig or WatchConfig:
+//
+//	{
+//	  "level": "info",
+//	  "categoryLevels": {"db": "debug"},
+//	  "sampling": {"http.healthcheck": 0.01},
+//	  "redact": ["password", "authorization"]
+//	}
+type Config struct {
+	// Level is the logger's minimum level. Empty leaves it unchanged.
+	Level LogLevel `json:"level,omitempty"`
+	// CategoryLevels are as set by WithCategoryLevel.
+	CategoryLevels map[string]LogLevel `json:"categoryLevels,omitempty"`
+	// Sampling keeps this fraction of the debug and info entries of a
+	// category and everything below it; the most specific category wins and
+	// "" matches all. Entries from contexts with a MinLevel are kept.
+	Sampling map[string]float64 `json:"sampling,omitempty"`
+	// Redact lists metadata keys and field names, matched case-insensitively,
+	// whose values are replaced with "[REDACTED]".
+	Redact []string `json:"redact,omitempty"`
+}
+
+// Validate reports the first invalid setting.
+func (c Config) Validate() error {
+	if c.Level != "" && !c.Level.valid() {
+		return fmt.Errorf("invalid level %q", c.Level)
+	}
+	for category, level := range c.CategoryLevels {
+		if !level.valid() {
+			return fmt.Errorf("invalid level %q for category %q", level, category)
+		}
+	}
+	for category, rate := range c.Sampling {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("sampling rate %v for category %q is outside [0, 1]", rate, category)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads and validates a JSON config file. Unknown keys are
+// rejected, so typos don't go unnoticed.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return parseConfig(data)
+}
+
+func parseConfig(data []byte) (Config, error) {
+	var c Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, err
+	}
+	return c, c.Validate()
+}
+
+// ConfigError reports a config file that could not be applied; the logger
+// keeps its previous configuration.
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("config %s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// tuning holds the settings ApplyConfig swaps atomically. It is never
+// modified after being stored.
+type tuning struct {
+	categoryLevels map[string]LogLevel
+	sampling       map[string]float64
+	redact         []string
+}
+
+var noTuning = &tuning{}
+
+func (l *StandardLogger) currentTuning() *tuning {
+	if t := l.tuning.Load(); t != nil {
+		return t
+	}
+	return noTuning
+}
+
+// ApplyConfig validates c and, if it is valid, applies it at once: category
+// levels, sampling and redaction are replaced as a whole. Settings from
+// WatchFlags stay merged on top of it.
+func (l *StandardLogger) ApplyConfig(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	l.configMu.Lock()
+	defer l.configMu.Unlock()
+	l.baseConfig = c
+	l.storeTuningLocked()
+	if c.Level != "" && l.flagConfig.Level == "" {
+		l.level.store(c.Level)
+	}
+	return nil
+}
+
+// applyFlagConfig applies the configuration WatchFlags evaluates on top of
+// the one ApplyConfig applied.
+func (l *StandardLogger) applyFlagConfig(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	l.configMu.Lock()
+	defer l.configMu.Unlock()
+	previous := l.flagConfig.Level
+	l.flagConfig = c
+	l.storeTuningLocked()
+	if c.Level != "" {
+		l.level.store(c.Level)
+	} else if previous != "" && l.baseConfig.Level != "" {
+		l.level.store(l.baseConfig.Level)
+	}
+	return nil
+}
+
+// storeTuningLocked merges the flags' configuration over the base one: the
+// flags' category levels and sampling rates win for their categories, and
+// the keys either redacts are redacted, so flags can never unmask a key.
+func (l *StandardLogger) storeTuningLocked() {
+	base, flags := l.baseConfig, l.flagConfig
+	categoryLevels := cloneMap(base.CategoryLevels, len(flags.CategoryLevels))
+	maps.Copy(categoryLevels, flags.CategoryLevels)
+	sampling := cloneMap(base.Sampling, len(flags.Sampling))
+	maps.Copy(sampling, flags.Sampling)
+	var redact []string
+	for _, key := range slices.Concat(base.Redact, flags.Redact) {
+		if key = strings.ToLower(key); !slices.Contains(redact, key) {
+			redact = append(redact, key)
+		}
+	}
+	l.tuning.Store(&tuning{
+		categoryLevels: categoryLevels,
+		sampling:       sampling,
+		redact:         redact,
+	})
+}
+
+// Config returns the logger's current configuration.
+func (l *StandardLogger) Config() Config {
+	t := l.currentTuning()
+	c := Config{Level: l.level.load()}
+	if len(t.redact) > 0 {
+		c.Redact = slices.Clone(t.redact)
+	}
+	if len(t.categoryLevels) > 0 {
+		c.CategoryLevels = cloneMap(t.categoryLevels, 0)
+	}
+	if len(t.sampling) > 0 {
+		c.Sampling = cloneMap(t.sampling, 0)
+	}
+	return c
+}
+
+// WatchConfig applies the config file at path, then polls it every interval
+// (2s when zero) and applies each change until ctx is done. A file that is
+// missing or invalid on a later poll is reported as a *ConfigError through
+// the logger's error handler, and the previous configuration stays in
+// effect. The initial load's error is returned.
+func (l *StandardLogger) WatchConfig(ctx context.Context, path string, interval time.Duration) error {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := l.applyConfigData(data); err != nil {
+		return &ConfigError{Path: path, Err: err}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			latest, err := os.ReadFile(path)
+			if err != nil {
+				reportError(l.errorHandler, &ConfigError{Path: path, Err: err})
+				continue
+			}
+			if bytes.Equal(latest, data) {
+				continue
+			}
+			data = latest
+			if err := l.applyConfigData(data); err != nil {
+				reportError(l.errorHandler, &ConfigError{Path: path, Err: err})
+			}
+		}
+	}()
+	return nil
+}
+
+func (l *StandardLogger) applyConfigData(data []byte) error {
+	c, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+	return l.ApplyConfig(c)
+}
+
+// categoryValue returns the value for the most specific prefix of category
+// in m.
+func categoryValue[V any](m map[string]V, category string) (value V, ok bool) {
+	best := -1
+	for prefix, v := range m {
+		if len(prefix) > best && CategoryHasPrefix(category, prefix) {
+			best, value, ok = len(prefix), v, true
+		}
+	}
+	return value, ok
+}
+
+// sampledAway applies the configured sampling rate to a debug or info entry.
+func (t *tuning) sampledAway(category string, level LogLevel) bool {
+	if len(t.sampling) == 0 || level.AtLeast(LevelWarn) {
+		return false
+	}
+	rate, ok := categoryValue(t.sampling, category)
+	return ok && rate < 1 && rand.Float64() >= rate
+}
+
+// redactOutput masks the configured metadata keys and fields.
+func (t *tuning) redactOutput(output *LogOutput) {
+	if len(t.redact) == 0 {
+		return
+	}
+	for _, key := range []string{"metadata", "fields"} {
+		switch values := output.Details[key].(type) {
+		case map[string]string:
+			var redacted map[string]string
+			for k := range values {
+				if slices.Contains(t.redact, strings.ToLower(k)) {
+					if redacted == nil {
+						redacted = cloneMap(values, 0)
+					}
+					redacted[k] = redactedValue
+				}
+			}
+			if redacted != nil {
+				output.Details[key] = redacted
+			}
+		case map[string]interface{}:
+			var redacted map[string]interface{}
+			for k := range values {
+				if slices.Contains(t.redact, strings.ToLower(k)) {
+					if redacted == nil {
+						redacted = cloneMap(values, 0)
+					}
+					redacted[k] = redactedValue
+				}
+			}
+			if redacted != nil {
+				output.Details[key] = redacted
+			}
+		}
+	}
+}
+
+const redactedValue = "[REDACTED]"
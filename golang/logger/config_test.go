@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.json")
+	write := func(config string) {
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"level": "warn", "categoryLevels": {"db": "debug"}, "redact": ["Password"]}`)
+
+	var configErrs atomic.Int32
+	sink := &lockedSink{}
+	l := New(WithSinks(sink), WithErrorHandler(func(err error) {
+		var configErr *ConfigError
+		if errors.As(err, &configErr) {
+			configErrs.Add(1)
+		}
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := l.WatchConfig(ctx, path, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	dbCtx := context.WithValue(ctx, logContextKey, NewLogContext(LogContextData{Category: "db"}))
+	if l.Enabled(ctx, LevelInfo) || !l.Enabled(dbCtx, LevelDebug) {
+		t.Error("Expected the file's levels to apply")
+	}
+	l.Info(dbCtx, "login", F("password", "hunter2"))
+	if fields := sink.entries[0].Details["fields"].(map[string]interface{}); fields["password"] != "[REDACTED]" {
+		t.Errorf("Expected the password redacted, got %v", fields)
+	}
+
+	write(`{"level": "loud"}`)
+	waitFor(t, func() bool { return configErrs.Load() > 0 })
+	if l.Level() != LevelWarn {
+		t.Errorf("Expected an invalid file to keep the previous config, got level %q", l.Level())
+	}
+
+	write(`{"level": "info", "sampling": {"": 0}}`)
+	waitFor(t, func() bool { return l.Level() == LevelInfo })
+	if config := l.Config(); config.CategoryLevels != nil || config.Redact != nil || config.Sampling[""] != 0 {
+		t.Errorf("Expected the new file to replace the config, got %+v", config)
+	}
+	l.Info(ctx, "sampled away")
+	l.Warn(ctx, "kept")
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if last := sink.entries[len(sink.entries)-1]; len(sink.entries) != 2 || last.Message != "kept" {
+		t.Errorf("Expected info entries sampled away, got %v", sink.entries)
+	}
+}
+
+func TestLoadConfig_RejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.json")
+	_ = os.WriteFile(path, []byte(`{"levle": "debug"}`), 0o644)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an unknown key to be rejected")
+	}
+	if err := (Config{Sampling: map[string]float64{"http": 2}}).Validate(); err == nil {
+		t.Error("Expected a sampling rate above 1 to be rejected")
+	}
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnOptions describes a long-lived connection, such as a WebSocket or a
+// streaming RPC, for ServeConn.
+type ConnOptions struct {
+	// ID identifies the connection. Defaults to a generated ID.
+	ID string
+	// Protocol is the negotiated subprotocol, e.g. "graphql-ws".
+	Protocol   string
+	RemoteAddr string
+	// Heartbeat is the interval at which the connection's traffic is
+	// logged while it is open; zero disables heartbeats.
+	Heartbeat time.Duration
+}
+
+// ConnStats counts a connection's traffic. Its methods are safe for
+// concurrent use, so reader and writer goroutines can share it.
+type ConnStats struct {
+	messagesIn, messagesOut atomic.Int64
+	bytesIn, bytesOut       atomic.Int64
+}
+
+// Received records a message of n bytes read from the connection.
+func (s *ConnStats) Received(n int) {
+	s.messagesIn.Add(1)
+	s.bytesIn.Add(int64(n))
+}
+
+// Sent records a message of n bytes written to the connection.
+func (s *ConnStats) Sent(n int) {
+	s.messagesOut.Add(1)
+	s.bytesOut.Add(int64(n))
+}
+
+func (s *ConnStats) fields(since time.Time) []interface{} {
+	return []interface{}{
+		F("messagesIn", s.messagesIn.Load()),
+		F("messagesOut", s.messagesOut.Load()),
+		F("bytesIn", s.bytesIn.Load()),
+		F("bytesOut", s.bytesOut.Load()),
+		DurationField("duration", time.Since(since)),
+	}
+}
+
+// ServeConn runs serve for the lifetime of a connection in a scope of its
+// own, since request-scoped logging does not fit connections that stay open
+// for hours. Entries are categorized "conn" and carry the connection ID,
+// protocol and remote address as metadata. ServeConn logs "Connection
+// opened", a "Connection heartbeat" with the traffic so far every
+// opts.Heartbeat, and "Connection closed" (or "Connection failed:" with
+// serve's error) with the totals when serve returns:
+//
+//	conn, _ := upgrader.Upgrade(w, r, nil)
+//	logger.ServeConn(r.Context(), logger.ConnOptions{
+//		Protocol:  conn.Subprotocol(),
+//		Heartbeat: time.Minute,
+//	}, func(ctx context.Context, stats *logger.ConnStats) error {
+//		for {
+//			_, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return err
+//			}
+//			stats.Received(len(msg))
+//		}
+//	})
+func ServeConn(ctx context.Context, opts ConnOptions, serve func(context.Context, *ConnStats) error) error {
+	if opts.ID == "" {
+		opts.ID = newID()
+	}
+	metadata := map[string]string{"connId": opts.ID}
+	if opts.Protocol != "" {
+		metadata["protocol"] = opts.Protocol
+	}
+	if opts.RemoteAddr != "" {
+		metadata["remoteAddr"] = opts.RemoteAddr
+	}
+	lc := GetLogContext(ctx).WithCategory("conn").WithMetadata(metadata)
+
+	_, err := WithLogContext(ctx, lc, func(ctx context.Context) (struct{}, error) {
+		start := time.Now()
+		stats := &ConnStats{}
+		Info(ctx, "Connection opened")
+
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			if opts.Heartbeat <= 0 {
+				return
+			}
+			ticker := time.NewTicker(opts.Heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					Info(ctx, append([]interface{}{"Connection heartbeat"}, stats.fields(start)...)...)
+				}
+			}
+		}()
+		stop := sync.OnceFunc(func() {
+			close(done)
+			<-stopped
+		})
+		defer stop()
+
+		err := serve(ctx, stats)
+		stop()
+		if err != nil {
+			Error(ctx, append(append([]interface{}{"Connection failed:"}, stats.fields(start)...), err)...)
+		} else {
+			Info(ctx, append([]interface{}{"Connection closed"}, stats.fields(start)...)...)
+		}
+		return struct{}{}, err
+	})
+	return err
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestServeConn(t *testing.T) {
+	sink := &lockedSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	lc := NewLogContext(LogContextData{SessionID: "upgrade-1"})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+	err := ServeConn(ctx, ConnOptions{ID: "c1", Protocol: "graphql-ws", Heartbeat: time.Millisecond},
+		func(ctx context.Context, stats *ConnStats) error {
+			stats.Received(10)
+			stats.Sent(4)
+			stats.Sent(6)
+			waitFor(t, func() bool {
+				sink.mu.Lock()
+				defer sink.mu.Unlock()
+				return len(sink.entries) >= 2
+			})
+			return errors.New("connection reset")
+		})
+	if err == nil {
+		t.Fatal("Expected serve's error")
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	first, heartbeat, last := sink.entries[0], sink.entries[1], sink.entries[len(sink.entries)-1]
+	if first.Message != "Connection opened" || heartbeat.Message != "Connection heartbeat" || last.Level != LevelError {
+		t.Fatalf("Unexpected connection entries: %v", sink.entries)
+	}
+	metadata := last.Details["metadata"].(map[string]string)
+	fields := last.Details["fields"].(map[string]interface{})
+	if last.SessionID != "upgrade-1" || metadata["connId"] != "c1" || metadata["protocol"] != "graphql-ws" ||
+		fields["messagesIn"] != int64(1) || fields["messagesOut"] != int64(2) || fields["bytesOut"] != int64(10) {
+		t.Errorf("Expected the totals in the connection's scope, got %+v", last)
+	}
+}
+
+func TestServeConn_PanicStopsHeartbeat(t *testing.T) {
+	sink := &lockedSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	count := func() int {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return len(sink.entries)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected serve's panic to propagate")
+			}
+		}()
+		_ = ServeConn(context.Background(), ConnOptions{ID: "c2", Heartbeat: time.Millisecond},
+			func(ctx context.Context, stats *ConnStats) error {
+				waitFor(t, func() bool { return count() >= 2 })
+				panic("handler bug")
+			})
+	}()
+
+	logged := count()
+	time.Sleep(20 * time.Millisecond)
+	if n := count(); n != logged {
+		t.Errorf("Expected no heartbeats after the panic, got %d more entries", n-logged)
+	}
+}
@@ -1,6 +1,12 @@
 package logger
 
-import "context"
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
 
 type contextKey string
 
@@ -11,12 +17,6 @@ type LogContext struct {
 }
 
 func NewLogContext(data LogContextData) *LogContext {
-	if data.Tags == nil {
-		data.Tags = make(map[string]bool)
-	}
-	if data.Metadata == nil {
-		data.Metadata = make(map[string]string)
-	}
 	return &LogContext{data: data}
 }
 
@@ -26,71 +26,320 @@ func (lc *LogContext) WithCategory(category string) *LogContext {
 	return &LogContext{data: newData}
 }
 
+// WithSubCategory appends a segment to the dot-separated category hierarchy:
+// "http.request" becomes "http.request.auth".
+func (lc *LogContext) WithSubCategory(sub string) *LogContext {
+	if lc.data.Category == "" {
+		return lc.WithCategory(sub)
+	}
+	return lc.WithCategory(lc.data.Category + "." + sub)
+}
+
+// CategoryHasPrefix reports whether category is prefix or nested below it,
+// matching whole dot-separated segments.
+func CategoryHasPrefix(category, prefix string) bool {
+	if !strings.HasPrefix(category, prefix) {
+		return false
+	}
+	return len(category) == len(prefix) || prefix == "" || category[len(prefix)] == '.'
+}
+
 func (lc *LogContext) WithSessionID(sessionID string) *LogContext {
 	newData := lc.copyData()
 	newData.SessionID = sessionID
 	return &LogContext{data: newData}
 }
 
+func (lc *LogContext) WithUserID(userID string) *LogContext {
+	newData := lc.copyData()
+	newData.UserID = userID
+	return &LogContext{data: newData}
+}
+
+func (lc *LogContext) WithTenantID(tenantID string) *LogContext {
+	newData := lc.copyData()
+	newData.TenantID = tenantID
+	return &LogContext{data: newData}
+}
+
+func (lc *LogContext) WithTraceID(traceID string) *LogContext {
+	newData := lc.copyData()
+	newData.TraceID = traceID
+	return &LogContext{data: newData}
+}
+
+// WithMinLevel makes entries logged in this context bypass the logger's level
+// filters in favor of level, so a single request can log at debug in
+// production.
+func (lc *LogContext) WithMinLevel(level LogLevel) *LogContext {
+	newData := lc.copyData()
+	newData.MinLevel = level
+	return &LogContext{data: newData}
+}
+
+// WithErrorClass sets the default error class ("retryable", "client",
+// "internal", ...) of error-level entries logged in this context.
+func (lc *LogContext) WithErrorClass(class string) *LogContext {
+	newData := lc.copyData()
+	newData.ErrorClass = class
+	return &LogContext{data: newData}
+}
+
+func (lc *LogContext) UserID() string {
+	return lc.data.UserID
+}
+
+func (lc *LogContext) TenantID() string {
+	return lc.data.TenantID
+}
+
+func (lc *LogContext) TraceID() string {
+	return lc.data.TraceID
+}
+
 func (lc *LogContext) WithTags(tags ...string) *LogContext {
 	newData := lc.copyData()
-	for _, tag := range tags {
-		newData.Tags[tag] = true
-	}
+	newData.Tags = lc.data.Tags.With(tags...)
 	return &LogContext{data: newData}
 }
 
 func (lc *LogContext) WithoutTags(tags ...string) *LogContext {
 	newData := lc.copyData()
-	for _, tag := range tags {
-		delete(newData.Tags, tag)
-	}
+	newData.Tags = lc.data.Tags.Without(tags...)
 	return &LogContext{data: newData}
 }
 
+func (lc *LogContext) Tags() TagSet {
+	return lc.data.Tags
+}
+
+func (lc *LogContext) HasTag(tag string) bool {
+	return lc.data.Tags.Has(tag)
+}
+
+// MetadataCollisionPolicy decides what WithMetadata does with a key that
+// already holds a different value.
+type MetadataCollisionPolicy int32
+
+const (
+	// MetadataOverwrite replaces the existing value.
+	MetadataOverwrite MetadataCollisionPolicy = iota
+	// MetadataKeepFirst keeps the existing value and ignores the new one.
+	MetadataKeepFirst
+	// MetadataCollect keeps every value; the key is emitted as an array,
+	// oldest first.
+	MetadataCollect
+)
+
+var collisionPolicy atomic.Int32
+
+// SetMetadataCollisionPolicy sets the policy applied by WithMetadata.
+// The default is MetadataOverwrite.
+func SetMetadataCollisionPolicy(policy MetadataCollisionPolicy) {
+	collisionPolicy.Store(int32(policy))
+}
+
 func (lc *LogContext) WithMetadata(metadata map[string]string) *LogContext {
 	newData := lc.copyData()
+	if lc.hasMetadata(metadata) {
+		return &LogContext{data: newData}
+	}
+	newData.Metadata = cloneMap(lc.data.Metadata, len(metadata))
+	policy := MetadataCollisionPolicy(collisionPolicy.Load())
+	historyCloned := false
 	for k, v := range metadata {
+		existing, exists := newData.Metadata[k]
+		if exists && existing != v {
+			switch policy {
+			case MetadataKeepFirst:
+				continue
+			case MetadataCollect:
+				if !historyCloned {
+					newData.metadataHistory = cloneMap(lc.data.metadataHistory, 1)
+					historyCloned = true
+				}
+				history := newData.metadataHistory[k]
+				newData.metadataHistory[k] = append(history[:len(history):len(history)], existing)
+			}
+		}
 		newData.Metadata[k] = v
 	}
 	return &LogContext{data: newData}
 }
 
+// WithMetadataNS merges metadata under a namespace, as "ns.key", so layers
+// setting the same key ("duration" from both HTTP and DB code) don't collide.
+func (lc *LogContext) WithMetadataNS(ns string, metadata map[string]string) *LogContext {
+	namespaced := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		namespaced[ns+"."+k] = v
+	}
+	return lc.WithMetadata(namespaced)
+}
+
+// hasMetadata reports whether every entry of metadata is already set, so
+// WithMetadata would change nothing.
+func (lc *LogContext) hasMetadata(metadata map[string]string) bool {
+	for k, v := range metadata {
+		if existing, ok := lc.data.Metadata[k]; !ok || existing != v {
+			return false
+		}
+	}
+	return true
+}
+
+// WithMetadataProvider registers a function whose result is emitted as the
+// metadata value for key each time an entry is logged, for values that change
+// during a long operation (queue depth, goroutine count). A provider value
+// overrides static metadata with the same key.
+func (lc *LogContext) WithMetadataProvider(key string, provider func() string) *LogContext {
+	newData := lc.copyData()
+	newData.providers = cloneMap(lc.data.providers, 1)
+	newData.providers[key] = provider
+	return &LogContext{data: newData}
+}
+
 func (lc *LogContext) WithoutMetadata(keys ...string) *LogContext {
 	newData := lc.copyData()
+	newData.Metadata = cloneMap(lc.data.Metadata, 0)
+	if lc.data.metadataHistory != nil {
+		newData.metadataHistory = cloneMap(lc.data.metadataHistory, 0)
+	}
+	if lc.data.providers != nil {
+		newData.providers = cloneMap(lc.data.providers, 0)
+	}
 	for _, key := range keys {
 		delete(newData.Metadata, key)
+		delete(newData.metadataHistory, key)
+		delete(newData.providers, key)
 	}
 	return &LogContext{data: newData}
 }
 
+// copyData returns a copy that shares the receiver's maps. The maps are
+// never modified after construction, so a With* method only clones the map it
+// changes (copy-on-write) and cheap derivations like WithCategory copy nothing.
 func (lc *LogContext) copyData() LogContextData {
-	tags := make(map[string]bool, len(lc.data.Tags))
-	for k, v := range lc.data.Tags {
-		tags[k] = v
-	}
-	metadata := make(map[string]string, len(lc.data.Metadata))
-	for k, v := range lc.data.Metadata {
-		metadata[k] = v
-	}
-	return LogContextData{
-		Tags:      tags,
-		Category:  lc.data.Category,
-		Metadata:  metadata,
-		SessionID: lc.data.SessionID,
+	return lc.data
+}
+
+func cloneMap[K comparable, V any](m map[K]V, extra int) map[K]V {
+	clone := make(map[K]V, len(m)+extra)
+	for k, v := range m {
+		clone[k] = v
 	}
+	return clone
 }
 
+// emptyLogContext is shared by every context without a LogContext. It is
+// safe to share because With* methods never modify their receiver.
+var emptyLogContext = NewLogContext(LogContextData{})
+
+// GetLogContext returns ctx's LogContext, falling back to one bound to the
+// calling goroutine by BindGoroutine, and otherwise an empty one.
 func GetLogContext(ctx context.Context) *LogContext {
 	if lc, ok := ctx.Value(logContextKey).(*LogContext); ok {
 		return lc
 	}
-	return NewLogContext(LogContextData{})
+	if lc, ok := goroutineLogContext(); ok {
+		return lc
+	}
+	return emptyLogContext
+}
+
+// Merge returns a LogContext with the union of both contexts' tags and
+// metadata. other's metadata is merged under the collision policy, and its
+// category and session ID win when set.
+func (lc *LogContext) Merge(other *LogContext) *LogContext {
+	merged := lc.WithMetadata(other.data.Metadata)
+	merged.data.Tags = lc.data.Tags.Union(other.data.Tags)
+	for key, provider := range other.data.providers {
+		merged = merged.WithMetadataProvider(key, provider)
+	}
+	if other.data.Category != "" {
+		merged.data.Category = other.data.Category
+	}
+	if other.data.SessionID != "" {
+		merged.data.SessionID = other.data.SessionID
+	}
+	if other.data.UserID != "" {
+		merged.data.UserID = other.data.UserID
+	}
+	if other.data.TenantID != "" {
+		merged.data.TenantID = other.data.TenantID
+	}
+	if other.data.TraceID != "" {
+		merged.data.TraceID = other.data.TraceID
+	}
+	if other.data.MinLevel != "" {
+		merged.data.MinLevel = other.data.MinLevel
+	}
+	if other.data.ErrorClass != "" {
+		merged.data.ErrorClass = other.data.ErrorClass
+	}
+	if other.data.logBodies {
+		merged.data.logBodies = true
+	}
+	if other.data.traceSampling != traceSamplingUnknown {
+		merged.data.traceSampling = other.data.traceSampling
+	}
+	if other.data.budget != nil {
+		merged.data.budget = other.data.budget
+	}
+	if other.data.summary != nil {
+		merged.data.summary = other.data.summary
+	}
+	if len(other.data.captures) > 0 {
+		merged.data.captures = other.data.captures
+	}
+	return merged
+}
+
+// ClearLogContext returns a context in which GetLogContext finds no
+// LogContext, detaching it from any outer scope.
+func ClearLogContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, logContextKey, emptyLogContext)
+}
+
+// MergeLogContext is like WithLogContext but keeps the enrichment of the
+// enclosing scope, merging logContext into it instead of replacing it.
+func MergeLogContext[T any](ctx context.Context, logContext *LogContext, callback func(context.Context) (T, error)) (T, error) {
+	return WithLogContext(ctx, GetLogContext(ctx).Merge(logContext), callback)
 }
 
 // WithLogContext executes a callback with an enriched context containing the log context.
+// The log context replaces any from the enclosing scope; use MergeLogContext to keep it.
+// Each call opens a new operation whose parent is the enclosing scope's operation.
 // Returns the result and error from the callback.
 func WithLogContext[T any](ctx context.Context, logContext *LogContext, callback func(context.Context) (T, error)) (T, error) {
-	enrichedCtx := context.WithValue(ctx, logContextKey, logContext)
-	return callback(enrichedCtx)
+	child := logContext.childOperation(ctx)
+	defer trackScope(child)()
+	inner := context.WithValue(ctx, logContextKey, child)
+	outer := GetLogContext(ctx)
+	defer traceScopeDiff(inner, outer, child)()
+	defer budgetScope(inner, outer, child)()
+	defer summaryScope(inner, outer, child)()
+	return callback(inner)
+}
+
+func (lc *LogContext) childOperation(ctx context.Context) *LogContext {
+	newData := lc.copyData()
+	outer, ok := ctx.Value(logContextKey).(*LogContext)
+	if ok && outer.data.OperationID != "" {
+		newData.ParentOperationID = outer.data.OperationID
+	} else {
+		newData.ParentOperationID = lc.data.OperationID
+	}
+	if ok && len(newData.captures) == 0 {
+		// Captures belong to the scope, not the enrichment it replaces.
+		newData.captures = outer.data.captures
+	}
+	newData.OperationID = newID()
+	return &LogContext{data: newData}
+}
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
 }
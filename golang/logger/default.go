@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Logger is the backend behind the package-level logging functions. Log
+// handles a Debug/Info/Warn/Error call; Emit handles an entry that was
+// already built, such as an access log record.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, args ...interface{})
+	Emit(ctx context.Context, output LogOutput)
+}
+
+// std is the default logger: no level filter, package-level sinks.
+var std = New()
+
+type loggerHolder struct {
+	logger Logger
+}
+
+var defaultLogger atomic.Pointer[loggerHolder]
+
+// Default returns the process-wide logger used by Debug, Info, Warn and Error.
+func Default() Logger {
+	if h := defaultLogger.Load(); h != nil {
+		return h.logger
+	}
+	return std
+}
+
+// SetDefault replaces the process-wide logger, e.g. with a mock in tests.
+// Passing nil restores the standard logger.
+func SetDefault(l Logger) {
+	if l == nil {
+		defaultLogger.Store(nil)
+		return
+	}
+	defaultLogger.Store(&loggerHolder{logger: l})
+}
+
+// defaultStandardLogger returns the default logger when it is a
+// StandardLogger, and the built-in one otherwise.
+func defaultStandardLogger() *StandardLogger {
+	if l, ok := Default().(*StandardLogger); ok {
+		return l
+	}
+	return std
+}
+
+// flushSinks flushes every sink of l that buffers entries.
+func flushSinks(l *StandardLogger) {
+	for _, sink := range l.activeSinks() {
+		if f, ok := sink.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				reportError(l.errorHandler, &SinkError{Sink: sink, Err: err})
+			}
+		}
+	}
+}
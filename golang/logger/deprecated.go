@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"runtime"
+)
+
+// Deprecated logs a warning the first time in the process that api is used,
+// so deprecated call sites surface without flooding the logs. The entry
+// carries details.deprecation with api, advice and the caller of the
+// function that called Deprecated, so usages can be found with one query.
+// Libraries embedding this package can use it for their own APIs:
+//
+//	func Connect(addr string) (*Client, error) {
+//		logger.Deprecated(ctx, "Connect(addr)", "use Dial(ctx, addr) instead")
+//		...
+//	}
+func Deprecated(ctx context.Context, api, advice string) {
+	if occurrence("deprecated:"+api) != 1 {
+		return
+	}
+	deprecation := map[string]interface{}{"api": api, "advice": advice}
+	if pc, file, line, ok := runtime.Caller(2); ok {
+		caller := map[string]interface{}{"file": file, "line": line}
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			caller["function"] = fn.Name()
+		}
+		deprecation["caller"] = caller
+	}
+	output := newEntry(ctx, LevelWarn, "Deprecated: "+api+": "+advice)
+	output.Details["deprecation"] = deprecation
+	Default().Emit(ctx, output)
+}
@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func deprecatedConnect(ctx context.Context) {
+	Deprecated(ctx, "Connect(addr)", "use Dial(ctx, addr) instead")
+}
+
+func TestDeprecated(t *testing.T) {
+	defer ResetOccurrences("deprecated:Connect(addr)")
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	for i := 0; i < 3; i++ {
+		deprecatedConnect(context.Background())
+	}
+	if len(sink.entries) != 1 {
+		t.Fatalf("Expected one warning per process, got %d", len(sink.entries))
+	}
+	output := sink.entries[0]
+	if output.Level != LevelWarn || output.Message != "Deprecated: Connect(addr): use Dial(ctx, addr) instead" {
+		t.Errorf("Unexpected entry %+v", output)
+	}
+	deprecation := output.Details["deprecation"].(map[string]interface{})
+	caller := deprecation["caller"].(map[string]interface{})
+	if deprecation["api"] != "Connect(addr)" || !strings.HasSuffix(caller["function"].(string), "TestDeprecated") {
+		t.Errorf("Expected the deprecated function's caller, got %v", deprecation)
+	}
+}
@@ -0,0 +1,270 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// diskQueue keeps entries as JSON lines in numbered segment files. The
+// oldest segment is read and deleted once consumed; the newest is appended
+// to. The read position is stored in a cursor file on commit, so after a
+// restart delivery resumes with the first entry not committed.
+type diskQueue struct {
+	dir         string
+	segmentSize int64
+
+	// segments are the segment numbers, oldest first.
+	segments []uint64
+	w        *os.File
+	wSize    int64
+
+	rFile *os.File
+	r     *bufio.Reader
+	rOff  int64
+	count int
+	// consumed are read segments deleted on the next commit.
+	consumed []uint64
+	cursor   *os.File
+}
+
+const segmentSuffix = ".seg"
+
+func openDiskQueue(dir string, segmentSize int64) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	q := &diskQueue{dir: dir, segmentSize: segmentSize}
+	if err := q.open(); err != nil {
+		q.close()
+		return nil, fmt.Errorf("open queue in %s: %w", dir, err)
+	}
+	return q, nil
+}
+
+func (q *diskQueue) open() error {
+	names, err := filepath.Glob(filepath.Join(q.dir, "*"+segmentSuffix))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		seq, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), segmentSuffix), 10, 64)
+		if err == nil {
+			q.segments = append(q.segments, seq)
+		}
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i] < q.segments[j] })
+
+	if q.cursor, err = os.OpenFile(filepath.Join(q.dir, "cursor"), os.O_CREATE|os.O_RDWR, 0o640); err != nil {
+		return err
+	}
+	var pos [16]byte
+	if n, _ := q.cursor.ReadAt(pos[:], 0); n == len(pos) {
+		seq := binary.BigEndian.Uint64(pos[:8])
+		for len(q.segments) > 1 && q.segments[0] < seq {
+			os.Remove(q.segmentPath(q.segments[0]))
+			q.segments = q.segments[1:]
+		}
+		if len(q.segments) > 0 && q.segments[0] == seq {
+			q.rOff = int64(binary.BigEndian.Uint64(pos[8:]))
+		}
+	}
+	if len(q.segments) == 0 {
+		q.segments = []uint64{1}
+	}
+
+	last := q.segments[len(q.segments)-1]
+	if q.w, err = os.OpenFile(q.segmentPath(last), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o640); err != nil {
+		return err
+	}
+	info, err := q.w.Stat()
+	if err != nil {
+		return err
+	}
+	q.wSize = info.Size()
+	// Terminate a line cut short by a crash; it fails to decode and is skipped.
+	var lastByte [1]byte
+	if q.wSize > 0 {
+		if _, err := q.w.ReadAt(lastByte[:], q.wSize-1); err == nil && lastByte[0] != '\n' {
+			n, _ := q.w.Write([]byte{'\n'})
+			q.wSize += int64(n)
+		}
+	}
+
+	for i, seq := range q.segments {
+		offset := int64(0)
+		if i == 0 {
+			offset = q.rOff
+		}
+		n, err := countLines(q.segmentPath(seq), offset)
+		if err != nil {
+			return err
+		}
+		q.count += n
+	}
+	return q.openReader()
+}
+
+func (q *diskQueue) segmentPath(seq uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+}
+
+func (q *diskQueue) openReader() error {
+	f, err := os.Open(q.segmentPath(q.segments[0]))
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(q.rOff, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	q.rFile, q.r = f, bufio.NewReader(f)
+	return nil
+}
+
+func countLines(path string, offset int64) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n := 0
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadSlice('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			n++
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return n, err
+		}
+	}
+}
+
+func (q *diskQueue) push(output LogOutput) error {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	if q.wSize >= q.segmentSize {
+		seq := q.segments[len(q.segments)-1] + 1
+		f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o640)
+		if err != nil {
+			return err
+		}
+		q.w.Close()
+		q.w, q.wSize = f, 0
+		q.segments = append(q.segments, seq)
+	}
+	n, err := q.w.Write(append(data, '\n'))
+	q.wSize += int64(n)
+	if err != nil {
+		return err
+	}
+	q.count++
+	return nil
+}
+
+func (q *diskQueue) pop() (LogOutput, bool) {
+	for q.count > 0 {
+		line, err := q.r.ReadBytes('\n')
+		if err != nil {
+			if len(q.segments) == 1 || q.nextSegment() != nil {
+				return LogOutput{}, false
+			}
+			continue
+		}
+		q.rOff += int64(len(line))
+		q.count--
+		var output LogOutput
+		if err := json.Unmarshal(line, &output); err != nil {
+			continue
+		}
+		restoreDetails(output.Details)
+		return output, true
+	}
+	return LogOutput{}, false
+}
+
+// nextSegment starts reading the segment after the fully read oldest one.
+func (q *diskQueue) nextSegment() error {
+	q.rFile.Close()
+	q.consumed = append(q.consumed, q.segments[0])
+	q.segments = q.segments[1:]
+	q.rOff = 0
+	return q.openReader()
+}
+
+func (q *diskQueue) commit() {
+	var pos [16]byte
+	binary.BigEndian.PutUint64(pos[:8], q.segments[0])
+	binary.BigEndian.PutUint64(pos[8:], uint64(q.rOff))
+	q.cursor.WriteAt(pos[:], 0)
+	for _, seq := range q.consumed {
+		os.Remove(q.segmentPath(seq))
+	}
+	q.consumed = nil
+}
+
+func (q *diskQueue) len() int {
+	return q.count
+}
+
+func (q *diskQueue) close() error {
+	var err error
+	for _, f := range []*os.File{q.w, q.rFile, q.cursor} {
+		if f != nil {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+// restoreDetails gives lists decoded from JSON back the types entries are
+// built with, which sinks expect: []string for tags and error chains,
+// including those of joined errors.
+func restoreDetails(details map[string]interface{}) {
+	restoreStrings(details, "tags")
+	restoreErrors(details)
+}
+
+// restoreErrors restores the error chain of an entry or of one of its
+// joined errors, and those of the errors joined below it.
+func restoreErrors(object map[string]interface{}) {
+	restoreStrings(object, "errorChain")
+	errs, _ := object["errors"].([]interface{})
+	for _, err := range errs {
+		if err, ok := err.(map[string]interface{}); ok {
+			restoreErrors(err)
+		}
+	}
+}
+
+func restoreStrings(m map[string]interface{}, key string) {
+	list, ok := m[key].([]interface{})
+	if !ok {
+		return
+	}
+	strs := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	m[key] = strs
+}
@@ -0,0 +1,343 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Encoder renders a LogOutput into the bytes written for one entry.
+type Encoder interface {
+	Encode(output LogOutput) ([]byte, error)
+}
+
+// binaryEncoder marks encoders whose entries are self-delimiting.
+type binaryEncoder interface {
+	binary()
+}
+
+// frame terminates an encoded entry with a newline, unless enc, or the
+// encoder it wraps, is binary.
+func frame(enc Encoder, encoded []byte) []byte {
+	for {
+		if _, ok := enc.(binaryEncoder); ok {
+			return encoded
+		}
+		wrapper, ok := enc.(interface{ Unwrap() Encoder })
+		if !ok {
+			return append(encoded, '\n')
+		}
+		enc = wrapper.Unwrap()
+	}
+}
+
+type JSONEncoder struct {
+	// FlattenMetadata promotes metadata keys from details.metadata to
+	// top-level fields, named MetadataPrefix+key (e.g. "meta.userId").
+	// Keys that would collide with a top-level field stay nested.
+	FlattenMetadata bool
+	MetadataPrefix  string
+	// SortKeys emits the keys of every object, including the top level, in
+	// lexical order so identical entries always encode to identical bytes.
+	SortKeys bool
+	// Levels renames levels and adds numeric severities.
+	Levels LevelFormat
+}
+
+func (e JSONEncoder) Encode(output LogOutput) ([]byte, error) {
+	var v interface{} = output
+	if e.FlattenMetadata {
+		if metadata, ok := metadataFields(output.Details["metadata"]); ok {
+			v = e.flatten(output, metadata)
+		}
+	}
+	if !e.Levels.isZero() {
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			fields = output.fields()
+		}
+		e.Levels.apply(fields, output.Level)
+		v = fields
+	}
+	if e.SortKeys {
+		return marshalSorted(v)
+	}
+	return json.Marshal(v)
+}
+
+// marshalSorted encodes v with every object's keys in lexical order. Struct
+// fields are emitted in declaration order by encoding/json, so v is first
+// converted to its generic form, whose map keys are sorted. Numbers are kept
+// as json.Number so integers beyond 2^53 survive the round trip.
+func marshalSorted(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+func (e JSONEncoder) flatten(output LogOutput, metadata map[string]interface{}) map[string]interface{} {
+	fields := output.fields()
+	remaining := make(map[string]interface{})
+	for k, v := range metadata {
+		name := e.MetadataPrefix + k
+		if _, exists := fields[name]; exists {
+			remaining[k] = v
+			continue
+		}
+		fields[name] = v
+	}
+
+	details := make(map[string]interface{}, len(output.Details))
+	for k, v := range output.Details {
+		details[k] = v
+	}
+	if len(remaining) > 0 {
+		details["metadata"] = remaining
+	} else {
+		delete(details, "metadata")
+	}
+	fields["details"] = details
+	return fields
+}
+
+// metadataFields accepts either shape the metadata detail can take.
+func metadataFields(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[string]string:
+		fields := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			fields[k] = val
+		}
+		return fields, true
+	}
+	return nil, false
+}
+
+// ConsoleEncoder renders human-readable, optionally colored entries for local
+// development, with context details on indented lines below the message.
+type ConsoleEncoder struct {
+	Color bool
+	// LevelNames replaces the displayed level names, e.g.
+	// {LevelWarn: "WARNING"}.
+	LevelNames map[LogLevel]string
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiGray   = "\x1b[90m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+var levelColors = map[LogLevel]string{
+	LevelDebug: ansiGray,
+	LevelInfo:  ansiBlue,
+	LevelWarn:  ansiYellow,
+	LevelError: ansiRed,
+}
+
+func (e ConsoleEncoder) Encode(output LogOutput) ([]byte, error) {
+	var b strings.Builder
+
+	timestamp, _ := output.Details["timestamp"].(string)
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		timestamp = t.Format("15:04:05")
+	}
+	b.WriteString(e.paint(ansiGray, timestamp))
+	b.WriteByte(' ')
+	level := strings.ToUpper(string(output.Level))
+	if name, ok := e.LevelNames[output.Level]; ok {
+		level = name
+	}
+	b.WriteString(e.paint(levelColors[output.Level], fmt.Sprintf("%-5s", level)))
+	if output.Event != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.paint(ansiCyan, output.Event))
+	}
+	if output.Message != nil {
+		b.WriteByte(' ')
+		b.WriteString(fmt.Sprint(output.Message))
+	}
+	if output.SessionID != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.paint(ansiCyan, "["+output.SessionID+"]"))
+	}
+	if output.Data != nil {
+		e.writeValue(&b, "data", output.Data)
+	}
+
+	if output.OperationID != "" {
+		operation := output.OperationID
+		if output.ParentOperationID != "" {
+			operation = output.ParentOperationID + " > " + operation
+		}
+		e.writeField(&b, "operation", operation)
+	}
+
+	for _, field := range [][2]string{
+		{"userId", output.UserID},
+		{"tenantId", output.TenantID},
+		{"traceId", output.TraceID},
+	} {
+		if field[1] != "" {
+			e.writeField(&b, field[0], field[1])
+		}
+	}
+
+	keys := make([]string, 0, len(output.Details))
+	for k := range output.Details {
+		if k != "timestamp" && k != "stack" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.writeValue(&b, k, output.Details[k])
+	}
+
+	if stack, ok := output.Details["stack"].(string); ok {
+		e.writeField(&b, "stack", "")
+		for _, line := range strings.Split(stack, "\n") {
+			b.WriteString("\n        ")
+			b.WriteString(e.paint(ansiRed, line))
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+func (e ConsoleEncoder) writeValue(b *strings.Builder, key string, value interface{}) {
+	switch v := value.(type) {
+	case []string:
+		e.writeField(b, key, strings.Join(v, ", "))
+	case map[string]string:
+		nested := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			nested[k] = val
+		}
+		e.writeValue(b, key, nested)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e.writeValue(b, key+"."+k, v[k])
+		}
+	default:
+		e.writeField(b, key, humanize(key, value))
+	}
+}
+
+func (e ConsoleEncoder) writeField(b *strings.Builder, key, value string) {
+	b.WriteString("\n    ")
+	b.WriteString(e.paint(ansiGray, key+":"))
+	if value != "" {
+		b.WriteByte(' ')
+		b.WriteString(value)
+	}
+}
+
+func (e ConsoleEncoder) paint(color, s string) string {
+	if !e.Color || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// humanize renders durations, including numeric millisecond fields such as
+// "durationMs", in their natural units.
+func humanize(key string, value interface{}) string {
+	switch v := value.(type) {
+	case time.Duration:
+		return v.String()
+	case float64:
+		if strings.HasSuffix(key, "Ms") || strings.HasSuffix(key, "_ms") {
+			return time.Duration(v * float64(time.Millisecond)).String()
+		}
+	case int64:
+		if strings.HasSuffix(key, "Ms") || strings.HasSuffix(key, "_ms") {
+			return (time.Duration(v) * time.Millisecond).String()
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+var (
+	encoderMu sync.RWMutex
+	encoder   = encoderFromEnv()
+)
+
+// SetEncoder replaces the encoder used by sinks without their own, such as
+// a WriterSink with a nil Encoder.
+func SetEncoder(e Encoder) {
+	encoderMu.Lock()
+	defer encoderMu.Unlock()
+	encoder = e
+}
+
+func currentEncoder() Encoder {
+	encoderMu.RLock()
+	defer encoderMu.RUnlock()
+	return encoder
+}
+
+// EncoderByName returns the encoder for a format name, as accepted by
+// LOG_FORMAT, so configuration can choose a format per sink: "json",
+// "pretty" (or "console", colored unless NO_COLOR is set), "logfmt", "ecs",
+// "datadog", "gcp", "cbor", "msgpack", "protobuf", "cef" or "leef".
+func EncoderByName(name string) (Encoder, error) {
+	switch strings.ToLower(name) {
+	case "json", "":
+		return JSONEncoder{}, nil
+	case "pretty", "console":
+		_, noColor := os.LookupEnv("NO_COLOR")
+		return ConsoleEncoder{Color: !noColor}, nil
+	case "logfmt":
+		return LogfmtEncoder{}, nil
+	case "ecs":
+		return ECSEncoder{}, nil
+	case "datadog":
+		return DatadogEncoder{}, nil
+	case "gcp":
+		return GCPEncoder{}, nil
+	case "cbor":
+		return CBOREncoder{}, nil
+	case "msgpack":
+		return MsgpackEncoder{}, nil
+	case "protobuf":
+		return ProtobufEncoder{}, nil
+	case "cef":
+		return CEFEncoder{}, nil
+	case "leef":
+		return LEEFEncoder{}, nil
+	}
+	return nil, fmt.Errorf("logger: unknown format %q", name)
+}
+
+// encoderFromEnv picks the encoder named by LOG_FORMAT, falling back to
+// JSON.
+func encoderFromEnv() Encoder {
+	if enc, err := EncoderByName(os.Getenv("LOG_FORMAT")); err == nil {
+		return enc
+	}
+	return JSONEncoder{}
+}
@@ -0,0 +1,394 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONEncoder_FlattenMetadata(t *testing.T) {
+	output := LogOutput{
+		Level:   LevelInfo,
+		Message: "hi",
+		Details: map[string]interface{}{
+			"metadata":  map[string]string{"userId": "42", "level": "gold"},
+			"timestamp": "2024-03-01T12:00:00Z",
+		},
+	}
+
+	encoded, _ := JSONEncoder{FlattenMetadata: true, MetadataPrefix: "meta."}.Encode(output)
+	expected := `{"details":{"timestamp":"2024-03-01T12:00:00Z"},"level":"info","message":"hi","meta.level":"gold","meta.userId":"42"}`
+	if string(encoded) != expected {
+		t.Errorf("Expected %s, got %s", expected, encoded)
+	}
+
+	encoded, _ = JSONEncoder{FlattenMetadata: true}.Encode(output)
+	expected = `{"details":{"metadata":{"level":"gold"},"timestamp":"2024-03-01T12:00:00Z"},"level":"info","message":"hi","userId":"42"}`
+	if string(encoded) != expected {
+		t.Errorf("Colliding keys should stay nested: expected %s, got %s", expected, encoded)
+	}
+	if len(output.Details["metadata"].(map[string]string)) != 2 {
+		t.Error("Encoding should not modify the entry")
+	}
+}
+
+func TestJSONEncoder_SortKeys(t *testing.T) {
+	output := LogOutput{
+		Level:     LevelInfo,
+		Message:   map[string]interface{}{"b": 1, "a": []interface{}{map[string]interface{}{"z": 1, "y": 2}}},
+		SessionID: "req-1",
+		Details: map[string]interface{}{
+			"metadata":  map[string]string{"zone": "b", "app": "a"},
+			"timestamp": "2024-03-01T12:00:00Z",
+		},
+	}
+
+	expected := `{"details":{"metadata":{"app":"a","zone":"b"},"timestamp":"2024-03-01T12:00:00Z"},"level":"info","message":{"a":[{"y":2,"z":1}],"b":1},"sessionId":"req-1"}`
+	for i := 0; i < 5; i++ {
+		encoded, err := JSONEncoder{SortKeys: true}.Encode(output)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(encoded) != expected {
+			t.Fatalf("Expected %s, got %s", expected, encoded)
+		}
+	}
+}
+
+func TestJSONEncoder_SortKeysLargeIntegers(t *testing.T) {
+	output := LogOutput{
+		Level:   LevelInfo,
+		Message: map[string]interface{}{"id": int64(9007199254740993), "max": uint64(18446744073709551615)},
+		Details: map[string]interface{}{},
+	}
+
+	encoded, err := JSONEncoder{SortKeys: true}.Encode(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `{"details":{},"level":"info","message":{"id":9007199254740993,"max":18446744073709551615}}`
+	if string(encoded) != expected {
+		t.Errorf("Expected %s, got %s", expected, encoded)
+	}
+}
+
+func TestConsoleEncoder(t *testing.T) {
+	output := LogOutput{
+		Level:     LevelWarn,
+		Message:   "Slow query",
+		SessionID: "req-1",
+		Details: map[string]interface{}{
+			"category":  "db",
+			"tags":      []string{"api", "db"},
+			"metadata":  map[string]string{"table": "users", "rows": "3"},
+			"access":    map[string]interface{}{"durationMs": float64(1500)},
+			"timestamp": "2024-03-01T12:30:45Z",
+		},
+	}
+
+	encoded, err := ConsoleEncoder{}.Encode(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"12:30:45 WARN  Slow query [req-1]",
+		"    access.durationMs: 1.5s",
+		"    category: db",
+		"    metadata.rows: 3",
+		"    metadata.table: users",
+		"    tags: api, db",
+	}, "\n")
+	if string(encoded) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, encoded)
+	}
+}
+
+func TestConsoleEncoder_Color(t *testing.T) {
+	encoded, _ := ConsoleEncoder{Color: true}.Encode(LogOutput{Level: LevelError, Message: "boom"})
+	if !strings.Contains(string(encoded), ansiRed+"ERROR"+ansiReset) {
+		t.Errorf("Expected red level label, got %q", encoded)
+	}
+}
+
+func TestSetEncoder(t *testing.T) {
+	SetEncoder(ConsoleEncoder{})
+	defer SetEncoder(JSONEncoder{})
+
+	lines := captureOutput(t, func() {
+		Info(context.Background(), "hello")
+	})
+	if !strings.Contains(lines[0], "INFO  hello") {
+		t.Errorf("Expected console output, got %q", lines[0])
+	}
+}
+
+func TestBinaryEncoders(t *testing.T) {
+	output := LogOutput{Level: LevelInfo, Message: "hi", Details: map[string]interface{}{"n": 1.5}}
+
+	msgpack := "\x83" +
+		"\xa7details\x81\xa1n\xcb\x3f\xf8\x00\x00\x00\x00\x00\x00" +
+		"\xa5level\xa4info" +
+		"\xa7message\xa2hi"
+	cbor := "\xa3" +
+		"\x67details\xa1\x61n\xfa\x3f\xc0\x00\x00" +
+		"\x65level\x64info" +
+		"\x67message\x62hi"
+
+	for _, tt := range []struct {
+		enc  Encoder
+		want string
+	}{
+		{MsgpackEncoder{}, msgpack},
+		{CBOREncoder{}, cbor},
+	} {
+		var buf strings.Builder
+		if err := (WriterSink{W: &buf, Encoder: tt.enc}).Write(output); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%T: expected %x, got %x", tt.enc, tt.want, buf.String())
+		}
+	}
+}
+
+func TestCBOREncoder_Integers(t *testing.T) {
+	got := appendCBOR(nil, []interface{}{float64(500), -1, uint64(24)})
+	want := []byte{0x83, 0x19, 0x01, 0xf4, 0x20, 0x18, 0x18}
+	if string(got) != string(want) {
+		t.Errorf("Expected %x, got %x", want, got)
+	}
+}
+
+func TestECSEncoder(t *testing.T) {
+	encoded, err := ECSEncoder{}.Encode(LogOutput{
+		Level:     LevelError,
+		Message:   "payment failed",
+		SessionID: "s1",
+		TraceID:   "t1",
+		Details: map[string]interface{}{
+			"timestamp":  "2024-01-01T00:00:00Z",
+			"category":   "payments",
+			"tags":       []string{"billing"},
+			"metadata":   map[string]string{"orderId": "42"},
+			"errorChain": []string{"charge: declined", "declined"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"@timestamp":"2024-01-01T00:00:00Z","ecs.version":"8.11.0","error.message":"charge: declined",` +
+		`"labels":{"orderId":"42","sessionId":"s1"},"log.level":"error","log.logger":"payments",` +
+		`"message":"payment failed","tags":["billing"],"trace.id":"t1"}`
+	if string(encoded) != want {
+		t.Errorf("Expected %s, got %s", want, encoded)
+	}
+}
+
+type fakeDatadogSpan struct{}
+
+func (fakeDatadogSpan) SpanID() uint64  { return 7 }
+func (fakeDatadogSpan) TraceID() uint64 { return 1234 }
+
+func TestDatadogEncoder(t *testing.T) {
+	t.Setenv("DD_ENV", "prod")
+	lc := NewLogContext(LogContextData{Category: "payments", UserID: "u1"}).
+		WithMetadata(map[string]string{"orderId": "42"}).
+		WithDatadogSpan(fakeDatadogSpan{})
+	output := newEntry(context.WithValue(context.Background(), logContextKey, lc), LevelWarn, "retrying")
+	output.Details["timestamp"] = "2024-01-01T00:00:00Z"
+
+	encoded, err := DatadogEncoder{Service: "orders", Version: "1.2.0"}.Encode(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"dd":{"env":"prod","service":"orders","span_id":"7","trace_id":"1234","version":"1.2.0"},` +
+		`"env":"prod","logger":{"name":"payments"},"message":"retrying","metadata":{"orderId":"42"},` +
+		`"service":"orders","status":"warn","timestamp":"2024-01-01T00:00:00Z","usr":{"id":"u1"},"version":"1.2.0"}`
+	if string(encoded) != want {
+		t.Errorf("Expected %s, got %s", want, encoded)
+	}
+	if output.TraceID != "" {
+		t.Errorf("Expected the Datadog trace ID kept out of TraceID, got %q", output.TraceID)
+	}
+}
+
+func TestGCPEncoder(t *testing.T) {
+	rec := &recordingSink{}
+	l := New(WithSinks(rec), WithHooks(SourceLocation))
+	ctx := context.WithValue(context.Background(), logContextKey,
+		NewLogContext(LogContextData{Category: "payments", TraceID: "abc123"}))
+	l.Error(ctx, "charge failed")
+
+	caller := rec.entries[0].Details["caller"].(map[string]interface{})
+	if !strings.HasSuffix(caller["file"].(string), "encoder_test.go") || !strings.HasSuffix(caller["function"].(string), "TestGCPEncoder") {
+		t.Fatalf("Expected caller in this test, got %v", caller)
+	}
+
+	encoded, err := GCPEncoder{ProjectID: "proj", Service: "orders"}.Encode(rec.entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["severity"] != "ERROR" || fields["message"] != "charge failed" {
+		t.Errorf("Unexpected severity/message: %v", fields)
+	}
+	if fields["logging.googleapis.com/trace"] != "projects/proj/traces/abc123" {
+		t.Errorf("Unexpected trace: %v", fields["logging.googleapis.com/trace"])
+	}
+	if _, ok := fields["logging.googleapis.com/sourceLocation"]; !ok {
+		t.Error("Expected sourceLocation")
+	}
+	if fields["@type"] != gcpReportedErrorEvent || fields["context"] == nil {
+		t.Errorf("Expected an Error Reporting event with reportLocation, got %v", fields)
+	}
+	if labels := fields["logging.googleapis.com/labels"].(map[string]interface{}); labels["category"] != "payments" {
+		t.Errorf("Unexpected labels: %v", labels)
+	}
+}
+
+func TestGCPEncoder_ReservedFields(t *testing.T) {
+	rec := &recordingSink{}
+	l := New(
+		WithSinks(rec),
+		WithClock(func() time.Time { return time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC) }),
+		WithTimestamps(TimestampOptions{DateTimeFields: true}),
+	)
+	l.Info(context.Background(), "tick")
+	output := rec.entries[0]
+	output.Details["severity"] = "spoofed"
+
+	encoded, err := GCPEncoder{}.Encode(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["time"] != "2024-03-01T01:00:00Z" || fields["severity"] != "INFO" || fields["localTime"] != "01:00:00" {
+		t.Errorf("Expected the encoder's own time and severity, got %v", fields)
+	}
+	if details := fields["details"].(map[string]interface{}); details["severity"] != "spoofed" {
+		t.Errorf("Expected colliding details nested, got %v", details)
+	}
+}
+
+func TestSanitizingEncoder(t *testing.T) {
+	message := "login failed\nforged entry \x1b[31mred\x1b[0m \xff\u202eevil"
+	tests := []struct {
+		policy SanitizePolicy
+		want   string
+	}{
+		{SanitizeEscape, `login failed\nforged entry \x1b[31mred\x1b[0m \xff\u202eevil`},
+		{SanitizeStrip, "login failedforged entry red evil"},
+		{SanitizeReplace, "login failed\ufffdforged entry \ufffd[31mred\ufffd[0m \ufffd\ufffdevil"},
+	}
+	for _, tt := range tests {
+		s := sanitizer{policy: tt.policy}
+		if got := s.string(message); got != tt.want {
+			t.Errorf("Policy %d: expected %q, got %q", tt.policy, tt.want, got)
+		}
+	}
+
+	type payload struct{ Name string }
+	encoded, err := SanitizingEncoder{}.Encode(LogOutput{
+		Level: LevelError,
+		Data:  payload{Name: "a\nb"},
+		Details: map[string]interface{}{
+			"metadata": map[string]string{"user\nId": "4\r2"},
+			"stack":    "boom\x1b\n\tmain.go:1",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatal(err)
+	}
+	details := got["details"].(map[string]interface{})
+	if got["data"].(map[string]interface{})["Name"] != `a\nb` ||
+		details["metadata"].(map[string]interface{})[`user\nId`] != `4\r2` ||
+		details["stack"] != "boom\\x1b\n\tmain.go:1" {
+		t.Errorf("Expected every string sanitized and stack lines kept, got %s", encoded)
+	}
+
+	if framed := frame(SanitizingEncoder{Encoder: MsgpackEncoder{}}, []byte{1}); len(framed) != 1 {
+		t.Errorf("Expected a wrapped binary encoder to stay unframed, got %v", framed)
+	}
+}
+
+func TestJSONEncoder_Levels(t *testing.T) {
+	output := LogOutput{Level: LevelWarn, Message: "disk low", Details: map[string]interface{}{}}
+	tests := []struct {
+		name   string
+		levels LevelFormat
+		want   string
+	}{
+		{"names", LevelFormat{Names: map[LogLevel]string{LevelWarn: "WARNING"}},
+			`{"details":{},"level":"WARNING","message":"disk low"}`},
+		{"syslog", LevelFormat{Severity: SyslogSeverity},
+			`{"details":{},"level":"warn","message":"disk low","severity":4}`},
+		{"otel only", LevelFormat{Severity: OTelSeverity, SeverityField: "severityNumber", OmitLevel: true},
+			`{"details":{},"message":"disk low","severityNumber":13}`},
+	}
+	for _, tt := range tests {
+		encoded, err := JSONEncoder{SortKeys: true, Levels: tt.levels}.Encode(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(encoded) != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, encoded)
+		}
+	}
+
+	console, _ := ConsoleEncoder{LevelNames: map[LogLevel]string{LevelWarn: "WARNING"}}.Encode(output)
+	if !strings.Contains(string(console), "WARNING disk low") {
+		t.Errorf("Expected the console level renamed, got %q", console)
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	encoded, err := LogfmtEncoder{}.Encode(LogOutput{
+		Level:     LevelInfo,
+		Message:   `user said "hi"`,
+		SessionID: "req-1",
+		Details: map[string]interface{}{
+			"timestamp": "2024-01-01T00:00:00Z",
+			"tags":      []string{"api", "v2"},
+			"metadata":  map[string]string{"path": "/orders", "note": ""},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `time=2024-01-01T00:00:00Z level=info msg="user said \"hi\"" sessionId=req-1 metadata.note="" metadata.path=/orders tags=api,v2`
+	if string(encoded) != want {
+		t.Errorf("Expected %s, got %s", want, encoded)
+	}
+}
+
+func TestPerSinkEncoders(t *testing.T) {
+	var console, file bytes.Buffer
+	pretty, _ := EncoderByName("pretty")
+	logfmt, _ := EncoderByName("logfmt")
+	if _, err := EncoderByName("yaml"); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+	l := New(WithSinks(
+		WriterSink{W: &console, Encoder: pretty},
+		WriterSink{W: &file, Encoder: logfmt},
+	))
+	l.Info(context.Background(), "started")
+
+	if !strings.Contains(console.String(), "INFO") || !strings.Contains(console.String(), " started") || !strings.Contains(file.String(), "level=info msg=started") {
+		t.Errorf("Expected each sink in its own format, got %q and %q", console.String(), file.String())
+	}
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// Error classes for SLO dashboards. Any other string may be used as well.
+const (
+	// ErrorClassRetryable marks transient failures that a retry may fix.
+	ErrorClassRetryable = "retryable"
+	// ErrorClassClient marks failures caused by the caller's input.
+	ErrorClassClient = "client"
+	// ErrorClassInternal marks failures of the service itself, and is the
+	// class of errors nothing else classifies.
+	ErrorClassInternal = "internal"
+)
+
+var errorClassifier atomic.Pointer[func(err error) string]
+
+// SetErrorClassifier classifies the errors logged at error level, e.g. by
+// matching sentinel errors or status codes. It returns "" for errors it does
+// not know, which then fall back to the context's class. Passing nil removes
+// the classifier.
+func SetErrorClassifier(classify func(err error) string) {
+	if classify == nil {
+		errorClassifier.Store(nil)
+		return
+	}
+	errorClassifier.Store(&classify)
+}
+
+// errorClass decides details.errorClass of an error-level entry: an error's
+// own ErrorClass() method, then the classifier, then the context's class,
+// and finally ErrorClassInternal.
+func errorClass(lc *LogContext, err error) string {
+	if err != nil {
+		var classified interface{ ErrorClass() string }
+		if errors.As(err, &classified) {
+			if class := classified.ErrorClass(); class != "" {
+				return class
+			}
+		}
+		if classify := errorClassifier.Load(); classify != nil {
+			if class := (*classify)(err); class != "" {
+				return class
+			}
+		}
+	}
+	if lc.data.ErrorClass != "" {
+		return lc.data.ErrorClass
+	}
+	return ErrorClassInternal
+}
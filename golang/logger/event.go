@@ -0,0 +1,31 @@
+package logger
+
+import "context"
+
+// Event emits a structured event record, such as "order.placed", for
+// analytics rather than reading: the event name, its fields in
+// details.fields, and the context's fields, without a message. Events are
+// never dropped by level filters; WithEventSinks sends them to their own
+// sinks.
+//
+//	logger.Event(ctx, "order.placed", logger.F("orderId", id), logger.F("total", total))
+func Event(ctx context.Context, name string, fields ...Field) {
+	Default().Emit(ctx, newEvent(ctx, name, fields))
+}
+
+func (l *StandardLogger) Event(ctx context.Context, name string, fields ...Field) {
+	l.Emit(ctx, newEvent(ctx, name, fields))
+}
+
+func newEvent(ctx context.Context, name string, fields []Field) LogOutput {
+	output := buildOutput(ctx, LevelInfo)
+	output.Event = name
+	if len(fields) > 0 {
+		values := make(map[string]interface{})
+		for _, field := range fields {
+			field.addTo(values)
+		}
+		output.Details["fields"] = values
+	}
+	return output
+}
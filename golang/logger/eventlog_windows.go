@@ -0,0 +1,91 @@
+//go:build windows
+
+package logger
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+)
+
+var eventlogTypes = map[LogLevel]uint16{
+	LevelDebug: eventlogInformationType,
+	LevelInfo:  eventlogInformationType,
+	LevelWarn:  eventlogWarningType,
+	LevelError: eventlogErrorType,
+}
+
+// EventLogSink reports entries to the Windows Event Log under source. The
+// entry is encoded with Encoder (JSON when nil) and stored as the event string.
+type EventLogSink struct {
+	Encoder Encoder
+	handle  uintptr
+	eventID uint32
+}
+
+// NewEventLogSink registers source with the Application log. The source
+// should be installed beforehand (e.g. New-EventLog) for messages to render
+// without a "description not found" prefix.
+func NewEventLogSink(source string, eventID uint32) (*EventLogSink, error) {
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	handle, _, callErr := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(name)))
+	if handle == 0 {
+		return nil, fmt.Errorf("register event source %q: %w", source, callErr)
+	}
+	return &EventLogSink{handle: handle, eventID: eventID}, nil
+}
+
+func (s *EventLogSink) Write(output LogOutput) error {
+	enc := s.Encoder
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	encoded, err := enc.Encode(output)
+	if err != nil {
+		return err
+	}
+	message, err := syscall.UTF16PtrFromString(string(encoded))
+	if err != nil {
+		return err
+	}
+	strs := []*uint16{message}
+	ok, _, callErr := procReportEventW.Call(
+		s.handle,
+		uintptr(eventlogTypes[output.Level]),
+		0,
+		uintptr(s.eventID),
+		0,
+		uintptr(len(strs)),
+		0,
+		uintptr(unsafe.Pointer(&strs[0])),
+		0,
+	)
+	if ok == 0 {
+		return fmt.Errorf("report event: %w", callErr)
+	}
+	return nil
+}
+
+func (s *EventLogSink) Close() error {
+	ok, _, callErr := procDeregisterEventSource.Call(s.handle)
+	if ok == 0 {
+		return fmt.Errorf("deregister event source: %w", callErr)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunCommand runs cmd in a new operation under ctx's LogContext, logging each
+// line of stdout at debug and of stderr at error. Entries keep the caller's
+// category, are tagged "exec" and with the program's name, and carry the
+// command line as metadata; the final entry adds the duration and, on
+// failure, the exit code. Writers already set on cmd still receive the
+// output.
+func RunCommand(ctx context.Context, cmd *exec.Cmd) error {
+	lc := GetLogContext(ctx).
+		WithTags("exec", filepath.Base(cmd.Path)).
+		WithMetadata(map[string]string{"command": strings.Join(cmd.Args, " ")})
+
+	_, err := WithLogContext(ctx, lc, func(ctx context.Context) (struct{}, error) {
+		stdout := Writer(ctx, LevelDebug, "")
+		stderr := Writer(ctx, LevelError, "")
+		cmd.Stdout = teeWriter(cmd.Stdout, stdout)
+		cmd.Stderr = teeWriter(cmd.Stderr, stderr)
+
+		start := time.Now()
+		err := cmd.Run()
+		stdout.Close()
+		stderr.Close()
+
+		result := map[string]string{"durationMs": strconv.FormatInt(time.Since(start).Milliseconds(), 10)}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result["exitCode"] = strconv.Itoa(exitErr.ExitCode())
+		}
+		ctx = context.WithValue(ctx, logContextKey, GetLogContext(ctx).WithMetadata(result))
+		if err != nil {
+			Error(ctx, "Command failed:", err)
+		} else {
+			Debug(ctx, "Command finished")
+		}
+		return struct{}{}, err
+	})
+	return err
+}
+
+func teeWriter(existing, w io.Writer) io.Writer {
+	if existing == nil {
+		return w
+	}
+	return io.MultiWriter(existing, w)
+}
@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type FailoverOptions struct {
+	// Retries is the number of consecutive failed writes to the primary
+	// tolerated before it is considered down. Each failed entry goes to the
+	// fallback; while the primary is down, writes skip it, and it is only
+	// probed once Backoff has passed. Defaults to 2.
+	Retries int
+	// Backoff is the delay before the first probe of a primary that is down,
+	// doubling after each failed probe. Defaults to 50ms.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between probes. Defaults to 30s.
+	MaxBackoff time.Duration
+	// ReplayBuffer is the number of fallback entries held for replay to the
+	// primary. The oldest are dropped from replay (not from the fallback)
+	// when full. Defaults to 10000.
+	ReplayBuffer int
+}
+
+// FailoverSink writes to a primary sink and falls back to a secondary (e.g. a
+// local file while the aggregator is down). Entries that went to the
+// fallback are replayed to the primary, in order, once it accepts writes
+// again. A nil fallback keeps entries only in the replay buffer. Writes never
+// wait for the primary to recover: a primary that is down is probed with
+// backoff as entries arrive.
+//
+// The primary must report failed writes. NetworkSink buffers and replays
+// entries itself instead, so its Write never fails and it never triggers a
+// failover.
+type FailoverSink struct {
+	primary  Sink
+	fallback Sink
+	opts     FailoverOptions
+	now      func() time.Time
+
+	mu        sync.Mutex
+	replay    *ring[LogOutput]
+	failures  int
+	down      bool
+	backoff   time.Duration
+	probeAt   time.Time
+	dropped   uint64
+	lastError error
+}
+
+func NewFailoverSink(primary, fallback Sink, opts FailoverOptions) *FailoverSink {
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	} else if opts.Retries == 0 {
+		opts.Retries = 2
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 50 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.ReplayBuffer <= 0 {
+		opts.ReplayBuffer = 10000
+	}
+	return &FailoverSink{
+		primary:  primary,
+		fallback: fallback,
+		opts:     opts,
+		now:      time.Now,
+		replay:   newRing[LogOutput](opts.ReplayBuffer),
+	}
+}
+
+// Write returns an error only when both the primary and the fallback fail.
+func (s *FailoverSink) Write(output LogOutput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replayLocked() && s.writePrimaryLocked(output) == nil {
+		return nil
+	}
+	if s.fallback != nil {
+		if err := s.fallback.Write(output); err != nil {
+			return fmt.Errorf("primary: %v; fallback: %w", s.lastError, err)
+		}
+	}
+	if s.replay.push(output) {
+		s.dropped++
+	}
+	return nil
+}
+
+// Replay attempts to deliver buffered fallback entries to the primary now,
+// rather than on the next write, even if its backoff has not passed.
+func (s *FailoverSink) Replay() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probeAt = time.Time{}
+	if !s.replayLocked() {
+		return s.lastError
+	}
+	return nil
+}
+
+// Flush replays buffered fallback entries like Replay, then flushes the
+// primary and fallback if they implement Flush.
+func (s *FailoverSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	s.probeAt = time.Time{}
+	if !s.replayLocked() {
+		errs = append(errs, s.lastError)
+	}
+	for _, sink := range []Sink{s.primary, s.fallback} {
+		if f, ok := sink.(interface{ Flush() error }); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reopen reopens the primary and fallback if they implement Reopener. A
+// primary that was down is probed again on the next write.
+func (s *FailoverSink) Reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, sink := range []Sink{s.primary, s.fallback} {
+		if r, ok := sink.(Reopener); ok {
+			errs = append(errs, r.Reopen())
+		}
+	}
+	s.probeAt = time.Time{}
+	return errors.Join(errs...)
+}
+
+// Pending reports how many fallback entries are waiting to be replayed.
+func (s *FailoverSink) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replay.len()
+}
+
+func (s *FailoverSink) SinkState() SinkState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := SinkState{
+		Name:     fmt.Sprintf("failover(%T)", s.primary),
+		Buffered: s.replay.len(),
+		Dropped:  s.dropped,
+	}
+	if s.lastError != nil {
+		state.LastError = s.lastError.Error()
+	}
+	return state
+}
+
+// Close closes the primary and fallback if they implement io.Closer.
+// Unreplayed entries remain only in the fallback.
+func (s *FailoverSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, sink := range []Sink{s.primary, s.fallback} {
+		if c, ok := sink.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// replayLocked drains the replay buffer into the primary, reporting whether
+// it is now empty.
+func (s *FailoverSink) replayLocked() bool {
+	for {
+		entry, ok := s.replay.peek()
+		if !ok {
+			return true
+		}
+		if s.writePrimaryLocked(entry) != nil {
+			return false
+		}
+		s.replay.pop()
+	}
+}
+
+// writePrimaryLocked makes a single attempt on the primary, unless it is down
+// and not due for a probe.
+func (s *FailoverSink) writePrimaryLocked(output LogOutput) error {
+	if s.down && s.now().Before(s.probeAt) {
+		return s.lastError
+	}
+	err := s.primary.Write(output)
+	if err == nil {
+		s.failures = 0
+		s.down = false
+		s.lastError = nil
+		return nil
+	}
+	s.lastError = err
+	if s.failures++; s.failures > s.opts.Retries {
+		if s.down {
+			s.backoff = min(s.backoff*2, s.opts.MaxBackoff)
+		} else {
+			s.backoff = s.opts.Backoff
+		}
+		s.down = true
+		s.probeAt = s.now().Add(s.backoff)
+	}
+	return err
+}
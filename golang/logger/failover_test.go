@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakySink struct {
+	recordingSink
+	fail     bool
+	attempts int
+}
+
+func (s *flakySink) Write(output LogOutput) error {
+	s.attempts++
+	if s.fail {
+		return errors.New("unavailable")
+	}
+	return s.recordingSink.Write(output)
+}
+
+// newTestFailoverSink returns a sink on a fake clock, advanced by the
+// returned function.
+func newTestFailoverSink(primary, fallback Sink, opts FailoverOptions) (*FailoverSink, func(time.Duration)) {
+	s := NewFailoverSink(primary, fallback, opts)
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func messages(entries []LogOutput) []interface{} {
+	var out []interface{}
+	for _, e := range entries {
+		out = append(out, e.Message)
+	}
+	return out
+}
+
+func TestFailoverSink_FallsBackThenProbesWithBackoff(t *testing.T) {
+	primary := &flakySink{fail: true}
+	fallback := &recordingSink{}
+	s, advance := newTestFailoverSink(primary, fallback, FailoverOptions{Retries: 2, Backoff: 10 * time.Millisecond})
+
+	if err := s.Write(LogOutput{Message: "one"}); err != nil {
+		t.Fatalf("Expected fallback to absorb the failure, got %v", err)
+	}
+	s.Write(LogOutput{Message: "two"})
+	s.Write(LogOutput{Message: "three"})
+	if primary.attempts != 3 || len(fallback.entries) != 3 {
+		t.Errorf("Expected one attempt per write until down, got %d attempts, %d fallback entries",
+			primary.attempts, len(fallback.entries))
+	}
+
+	s.Write(LogOutput{Message: "four"})
+	if primary.attempts != 3 {
+		t.Errorf("Expected no attempt during the backoff, got %d attempts", primary.attempts)
+	}
+	advance(10 * time.Millisecond)
+	s.Write(LogOutput{Message: "five"})
+	advance(10 * time.Millisecond)
+	s.Write(LogOutput{Message: "six"})
+	if primary.attempts != 4 {
+		t.Errorf("Expected one probe, then a doubled backoff, got %d attempts", primary.attempts)
+	}
+	advance(10 * time.Millisecond)
+	s.Write(LogOutput{Message: "seven"})
+	if primary.attempts != 5 {
+		t.Errorf("Expected a probe after the doubled backoff, got %d attempts", primary.attempts)
+	}
+
+	if s.Pending() != 7 || len(fallback.entries) != 7 {
+		t.Errorf("Expected 7 entries pending replay, got %d", s.Pending())
+	}
+	if state := s.SinkState(); state.LastError != "unavailable" {
+		t.Errorf("Expected last error in state, got %+v", state)
+	}
+}
+
+func TestFailoverSink_ReplaysInOrderOnRecovery(t *testing.T) {
+	primary := &flakySink{fail: true}
+	s, _ := newTestFailoverSink(primary, &recordingSink{}, FailoverOptions{})
+
+	s.Write(LogOutput{Message: "one"})
+	s.Write(LogOutput{Message: "two"})
+	primary.fail = false
+	s.Write(LogOutput{Message: "three"})
+
+	got := messages(primary.entries)
+	if len(got) != 3 || got[0] != "one" || got[1] != "two" || got[2] != "three" {
+		t.Errorf("Expected replay before the new entry, got %v", got)
+	}
+	if s.Pending() != 0 {
+		t.Errorf("Expected replay buffer drained, got %d", s.Pending())
+	}
+}
+
+func TestFailoverSink_BothFail(t *testing.T) {
+	s, _ := newTestFailoverSink(&flakySink{fail: true}, &failingSink{err: errors.New("disk full")}, FailoverOptions{Retries: -1})
+
+	if err := s.Write(LogOutput{Message: "lost"}); err == nil {
+		t.Error("Expected an error when both sinks fail")
+	}
+}
+
+type flushingSink struct {
+	recordingSink
+	flushes int
+}
+
+func (s *flushingSink) Flush() error {
+	s.flushes++
+	return nil
+}
+
+func TestFailoverSink_Flush(t *testing.T) {
+	primary := &flakySink{fail: true}
+	fallback := &flushingSink{}
+	s, _ := newTestFailoverSink(primary, fallback, FailoverOptions{Retries: -1, Backoff: time.Hour})
+
+	s.Write(LogOutput{Message: "one"})
+	s.Write(LogOutput{Message: "two"})
+	if err := s.Flush(); err == nil || fallback.flushes != 1 {
+		t.Errorf("Expected the replay error and the fallback flushed, got %v, %d flushes", err, fallback.flushes)
+	}
+
+	primary.fail = false
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(primary.entries); len(got) != 2 || s.Pending() != 0 {
+		t.Errorf("Expected Flush to replay within the backoff, got %v", got)
+	}
+}
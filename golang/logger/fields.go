@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// Field is a typed value passed among a log call's arguments. Fields are
+// collected into details.fields instead of being rendered into the message:
+//
+//	logger.Info(ctx, "Query finished", logger.DurationField("query", elapsed))
+type Field struct {
+	pairs []fieldPair
+}
+
+type fieldPair struct {
+	key   string
+	value interface{}
+}
+
+func (f Field) addTo(values map[string]interface{}) {
+	for _, pair := range f.pairs {
+		values[pair.key] = pair.value
+	}
+}
+
+// F is a field holding any value. Structs, maps and slices are converted to
+// their JSON form, with cycles and excessive nesting marked.
+func F(key string, value interface{}) Field {
+	if structured, ok := structuredValue(value); ok {
+		value = structured
+	}
+	return Field{pairs: []fieldPair{{key, value}}}
+}
+
+// DurationField emits <name>_ms, the duration in (fractional) milliseconds
+// for dashboards to graph, and <name> in Go's notation ("1.5s").
+func DurationField(name string, d time.Duration) Field {
+	return Field{pairs: []fieldPair{
+		{name + "_ms", float64(d) / float64(time.Millisecond)},
+		{name, d.String()},
+	}}
+}
+
+// BytesField emits <name>_bytes, the exact size, and <name> in binary units
+// ("1.5 MiB").
+func BytesField(name string, n int64) Field {
+	return Field{pairs: []fieldPair{
+		{name + "_bytes", n},
+		{name, formatBytes(n)},
+	}}
+}
+
+// TimeField emits <name>_unix_ms, milliseconds since the Unix epoch, and
+// <name> as an RFC 3339 UTC timestamp.
+func TimeField(name string, t time.Time) Field {
+	return Field{pairs: []fieldPair{
+		{name + "_unix_ms", t.UnixMilli()},
+		{name, t.UTC().Format(time.RFC3339Nano)},
+	}}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for (value >= unit || value <= -unit) && exp < 5 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[exp])
+}
+
+// splitFields removes Field arguments from args, returning them as the
+// details.fields object.
+func splitFields(args []interface{}) ([]interface{}, map[string]interface{}) {
+	var fields map[string]interface{}
+	rest := args
+	for i, arg := range args {
+		field, ok := arg.(Field)
+		if !ok {
+			if fields != nil {
+				rest = append(rest, arg)
+			}
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]interface{})
+			rest = append([]interface{}(nil), args[:i]...)
+		}
+		field.addTo(fields)
+	}
+	return rest, fields
+}
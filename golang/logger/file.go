@@ -0,0 +1,237 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reopener is implemented by sinks that can reopen their destination, e.g.
+// after logrotate has moved a file away.
+type Reopener interface {
+	Reopen() error
+}
+
+// Compressor wraps a log file in a streaming compressor. Gzip is built in;
+// other formats plug in through their package's writer, e.g. zstd:
+//
+//	func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+// Gzip compresses log files at level (gzip.DefaultCompression, ...).
+func Gzip(level int) Compressor {
+	return func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	}
+}
+
+// SyncMode is how often a FileSink forces its entries to disk with fsync.
+// Without it, the OS writes them back within seconds, and entries written
+// just before a machine crash or power loss can be lost; each fsync makes the
+// write wait for the disk instead, typically milliseconds.
+type SyncMode int
+
+const (
+	// SyncNone leaves write-back to the OS.
+	SyncNone SyncMode = iota
+	// SyncEntry syncs after every entry, so each Write returns only once its
+	// entry is durable. Suited to low-volume audit logs.
+	SyncEntry
+	// SyncBatch syncs every FileOptions.SyncEvery entries and on Flush and
+	// Close, bounding the entries a crash can lose at a fraction of the cost.
+	SyncBatch
+)
+
+// FileOptions configures a FileSink.
+type FileOptions struct {
+	// Encoder encodes entries; the package encoder when nil.
+	Encoder Encoder
+	// Compressor, when set, compresses the file as it is written. Each open
+	// appends a new compressed stream, which gzip and zstd readers decode as
+	// one file. Entries reach the file when the compressor's buffer fills, on
+	// Flush, and on Close or Reopen.
+	Compressor Compressor
+	// RotationCheck is how often writes check whether the file was rotated
+	// by an external tool, reopening the path when it was moved, deleted or
+	// truncated (logrotate's copytruncate). Defaults to a second; negative
+	// disables the check, leaving rotation to Reopen.
+	RotationCheck time.Duration
+	// Sync sets the durability of written entries. With a Compressor,
+	// entries are synced once the compressor has written them to the file.
+	Sync SyncMode
+	// SyncEvery is the batch size of SyncBatch. Defaults to 100.
+	SyncEvery int
+}
+
+// FileSink appends entries to a file.
+type FileSink struct {
+	path string
+	opts FileOptions
+
+	mu   sync.Mutex
+	file *os.File
+	// out is file, or the compressor writing to it.
+	out io.Writer
+	// checked is when rotation was last checked, and size the file's size
+	// then.
+	checked time.Time
+	size    int64
+	// unsynced counts the entries written since the last sync.
+	unsynced int
+}
+
+// NewFileSink appends entries to path, encoded with enc (the package encoder
+// when nil).
+func NewFileSink(path string, enc Encoder) (*FileSink, error) {
+	return OpenFileSink(path, FileOptions{Encoder: enc})
+}
+
+func OpenFileSink(path string, opts FileOptions) (*FileSink, error) {
+	s := &FileSink{path: path, opts: opts}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := openLogFile(s.path)
+	if err != nil {
+		return err
+	}
+	var out io.Writer = f
+	if s.opts.Compressor != nil {
+		if out, err = s.opts.Compressor(f); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	s.file, s.out = f, out
+	s.checked, s.size, s.unsynced = time.Now(), 0, 0
+	if info, err := f.Stat(); err == nil {
+		s.size = info.Size()
+	}
+	return nil
+}
+
+func (s *FileSink) Write(output LogOutput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkRotation(); err != nil {
+		return err
+	}
+	if err := (WriterSink{W: s.out, Encoder: s.opts.Encoder}).Write(output); err != nil {
+		return err
+	}
+	s.unsynced++
+	switch s.opts.Sync {
+	case SyncEntry:
+		return s.syncLocked()
+	case SyncBatch:
+		every := s.opts.SyncEvery
+		if every <= 0 {
+			every = 100
+		}
+		if s.unsynced >= every {
+			return s.syncLocked()
+		}
+	}
+	return nil
+}
+
+// syncLocked flushes the compressor, if any, and syncs the file.
+func (s *FileSink) syncLocked() error {
+	if f, ok := s.out.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	s.unsynced = 0
+	return s.file.Sync()
+}
+
+// Flush writes entries buffered by the compressor to the file, and syncs it
+// when Sync is set.
+func (s *FileSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.opts.Sync != SyncNone && s.unsynced > 0 {
+		return s.syncLocked()
+	}
+	if f, ok := s.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Reopen closes the file and opens the path again, creating it if it was
+// moved away.
+func (s *FileSink) Reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, out := s.file, s.out
+	if err := s.open(); err != nil {
+		return err
+	}
+	return s.closeFile(file, out)
+}
+
+// checkRotation reopens the path when the file was moved away, deleted or
+// truncated since the last check.
+func (s *FileSink) checkRotation() error {
+	interval := s.opts.RotationCheck
+	if interval == 0 {
+		interval = time.Second
+	}
+	if interval < 0 || time.Since(s.checked) < interval {
+		return nil
+	}
+	s.checked = time.Now()
+	current, err := s.file.Stat()
+	if err != nil {
+		return nil
+	}
+	truncated := current.Size() < s.size
+	s.size = current.Size()
+	if info, err := os.Stat(s.path); err == nil && os.SameFile(info, current) && !truncated {
+		return nil
+	}
+
+	file, out := s.file, s.out
+	if err := s.open(); err != nil {
+		return err
+	}
+	if truncated {
+		// The compressed stream lost its start with the truncated bytes;
+		// finishing it would only append a stray trailer.
+		return file.Close()
+	}
+	return s.closeFile(file, out)
+}
+
+// Close finishes the compressed stream, if any, and closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeFile(s.file, s.out)
+}
+
+// closeFile finishes the compressed stream, if any, and closes the file,
+// syncing it first when the sink syncs.
+func (s *FileSink) closeFile(file *os.File, out io.Writer) error {
+	var err error
+	if out != io.Writer(file) {
+		err = out.(io.Closer).Close()
+	}
+	if s.opts.Sync != SyncNone {
+		err = errors.Join(err, file.Sync())
+	}
+	return errors.Join(err, file.Close())
+}
+
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSink_Gzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	opts := FileOptions{Encoder: JSONEncoder{}, Compressor: Gzip(gzip.BestSpeed)}
+
+	sink, err := OpenFileSink(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(WithSinks(sink))
+	l.Info(context.Background(), "one")
+	if err := sink.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info(context.Background(), "two")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || decodeEntry(t, lines[0])["message"] != "one" || decodeEntry(t, lines[1])["message"] != "two" {
+		t.Errorf("Unexpected decompressed output %q", data)
+	}
+}
+
+func TestFileSink_DetectsRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	sink, err := OpenFileSink(path, FileOptions{Encoder: JSONEncoder{}, RotationCheck: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	l := New(WithSinks(sink))
+
+	l.Info(context.Background(), "before")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	l.Info(context.Background(), "after move")
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "before") || strings.Contains(string(rotated), "after move") {
+		t.Errorf("Expected only the first entry in the rotated file, got %q", rotated)
+	}
+	if !strings.Contains(string(current), "after move") {
+		t.Errorf("Expected the file to be recreated, got %q", current)
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	l.Info(context.Background(), "after truncate")
+	current, _ = os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(current)), "\n"); len(lines) != 1 || decodeEntry(t, lines[0])["message"] != "after truncate" {
+		t.Errorf("Expected writing to continue after copytruncate, got %q", current)
+	}
+}
+
+func TestFileSink_SyncBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log.gz")
+	sink, err := OpenFileSink(path, FileOptions{Encoder: JSONEncoder{}, Compressor: Gzip(gzip.BestSpeed), Sync: SyncBatch, SyncEvery: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	l := New(WithSinks(sink))
+
+	written := func() string {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			return ""
+		}
+		data, _ := io.ReadAll(r) // the stream is unfinished until Close
+		return string(data)
+	}
+	l.Info(context.Background(), "one")
+	if got := written(); got != "" {
+		t.Errorf("Expected the first entry to wait for its batch, got %q", got)
+	}
+	l.Info(context.Background(), "two")
+	if got := written(); !strings.Contains(got, `"one"`) || !strings.Contains(got, `"two"`) {
+		t.Errorf("Expected the batch on disk once full, got %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ConfigFlags adapts a feature-flag system that drives process-wide
+// settings, such as per-category levels and sampling rates. See WatchFlags.
+type ConfigFlags interface {
+	LoggingConfig(ctx context.Context) (Config, error)
+}
+
+// LevelFlags adapts a feature-flag system evaluated per context, e.g. to
+// turn on debug logging for one tenant:
+//
+//	func (f flags) ContextLevel(lc *logger.LogContext) (logger.LogLevel, bool) {
+//		on, _ := f.client.BoolVariation("debug-logging", ldcontext.New(lc.TenantID()), false)
+//		return logger.LevelDebug, on
+//	}
+//
+// It is called for every entry, so it must be cheap, as in-memory flag
+// evaluation is.
+type LevelFlags interface {
+	// ContextLevel returns the minimum level for entries logged in lc,
+	// or false to leave them to the logger's filters.
+	ContextLevel(lc *LogContext) (LogLevel, bool)
+}
+
+// WithLevelFlags lets flags set the minimum level per context. A level the
+// flags return acts like WithMinLevel, which still wins when set.
+func WithLevelFlags(flags LevelFlags) Option {
+	return func(l *StandardLogger) {
+		l.levelFlags = flags
+	}
+}
+
+// contextMinLevel returns the level replacing the logger's filters for lc:
+// its MinLevel, or the one its flags return.
+func (l *StandardLogger) contextMinLevel(lc *LogContext) LogLevel {
+	if lc.data.MinLevel != "" || l.levelFlags == nil {
+		return lc.data.MinLevel
+	}
+	if level, ok := l.levelFlags.ContextLevel(lc); ok && level.valid() {
+		return level
+	}
+	return ""
+}
+
+// WatchFlags applies the configuration flags evaluate to, then re-evaluates
+// them every interval (30s when zero) and applies changes until ctx is
+// done, like WatchConfig does for a file. Evaluation errors and invalid
+// configurations are reported as a *ConfigError and leave the previous
+// settings in effect. The initial evaluation's error is returned.
+//
+// The flags' configuration is merged on top of the one ApplyConfig or
+// WatchConfig applies rather than replacing it: their level, category
+// levels and sampling rates win, and their redacted keys are added.
+func (l *StandardLogger) WatchFlags(ctx context.Context, flags ConfigFlags, interval time.Duration) error {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	source := fmt.Sprintf("flags %T", flags)
+	config, err := flags.LoggingConfig(ctx)
+	if err == nil {
+		err = l.applyFlagConfig(config)
+	}
+	if err != nil {
+		return &ConfigError{Path: source, Err: err}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			latest, err := flags.LoggingConfig(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					reportError(l.errorHandler, &ConfigError{Path: source, Err: err})
+				}
+				continue
+			}
+			if reflect.DeepEqual(latest, config) {
+				continue
+			}
+			config = latest
+			if err := l.applyFlagConfig(config); err != nil {
+				reportError(l.errorHandler, &ConfigError{Path: source, Err: err})
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type tenantDebugFlags map[string]bool
+
+func (f tenantDebugFlags) ContextLevel(lc *LogContext) (LogLevel, bool) {
+	if f[lc.data.TenantID] {
+		return LevelDebug, true
+	}
+	return "", false
+}
+
+type stubConfigFlags struct {
+	mu     sync.Mutex
+	config Config
+}
+
+func (f *stubConfigFlags) LoggingConfig(context.Context) (Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.config, nil
+}
+
+func (f *stubConfigFlags) set(config Config) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = config
+}
+
+func TestWithLevelFlags(t *testing.T) {
+	l := New(WithLevel(LevelWarn), WithLevelFlags(tenantDebugFlags{"acme": true}))
+	acme := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{TenantID: "acme"}))
+	other := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{TenantID: "globex"}))
+	if !l.Enabled(acme, LevelDebug) {
+		t.Error("Expected the flag to enable debug for the flagged tenant")
+	}
+	if l.Enabled(other, LevelInfo) {
+		t.Error("Expected other tenants to keep the logger's level")
+	}
+	pinned := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{TenantID: "acme", MinLevel: LevelError}))
+	if l.Enabled(pinned, LevelWarn) {
+		t.Error("Expected the context's own min level to win over the flag")
+	}
+}
+
+func TestWatchFlags(t *testing.T) {
+	flags := &stubConfigFlags{config: Config{Level: LevelWarn, CategoryLevels: map[string]LogLevel{"db": LevelDebug}}}
+	l := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := l.WatchFlags(ctx, flags, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	dbCtx := context.WithValue(ctx, logContextKey, NewLogContext(LogContextData{Category: "db"}))
+	if l.Enabled(ctx, LevelInfo) || !l.Enabled(dbCtx, LevelDebug) {
+		t.Error("Expected the flags' levels to apply")
+	}
+
+	flags.set(Config{Level: LevelInfo, Sampling: map[string]float64{"db": 0}})
+	waitFor(t, func() bool { return l.Level() == LevelInfo })
+	if config := l.Config(); config.CategoryLevels != nil || config.Sampling["db"] != 0 {
+		t.Errorf("Expected the new flag values to replace the config, got %+v", config)
+	}
+
+	if err := l.WatchFlags(ctx, &stubConfigFlags{config: Config{Level: "loud"}}, 0); err == nil {
+		t.Error("Expected an invalid initial config to be returned")
+	}
+}
+
+func TestWatchFlags_MergesOverConfig(t *testing.T) {
+	l := New()
+	if err := l.ApplyConfig(Config{
+		Level:          LevelInfo,
+		CategoryLevels: map[string]LogLevel{"db": LevelWarn, "http": LevelWarn},
+		Redact:         []string{"Password"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	flags := &stubConfigFlags{config: Config{CategoryLevels: map[string]LogLevel{"db": LevelDebug}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := l.WatchFlags(ctx, flags, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	config := l.Config()
+	if config.CategoryLevels["db"] != LevelDebug || config.CategoryLevels["http"] != LevelWarn ||
+		len(config.Redact) != 1 || config.Level != LevelInfo {
+		t.Errorf("Expected the flags merged over the config, got %+v", config)
+	}
+
+	flags.set(Config{Level: LevelError, Sampling: map[string]float64{"http": 0.5}, Redact: []string{"token"}})
+	waitFor(t, func() bool { return l.Level() == LevelError })
+	config = l.Config()
+	if config.CategoryLevels["db"] != LevelWarn || config.Sampling["http"] != 0.5 ||
+		!slices.Equal(config.Redact, []string{"password", "token"}) {
+		t.Errorf("Expected the config's settings back under the new flags, got %+v", config)
+	}
+
+	// A config change keeps the flags' level and redacted keys.
+	if err := l.ApplyConfig(Config{Level: LevelDebug}); err != nil {
+		t.Fatal(err)
+	}
+	if config := l.Config(); config.Level != LevelError || !slices.Equal(config.Redact, []string{"token"}) {
+		t.Errorf("Expected the flags to stay on top, got %+v", config)
+	}
+	flags.set(Config{})
+	waitFor(t, func() bool { return l.Level() == LevelDebug })
+}
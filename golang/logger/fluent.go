@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FluentOptions struct {
+	// TagPrefix is prepended to the entry's category to form the Fluentd tag,
+	// e.g. "app" and category "payments" give "app.payments". Entries without
+	// a category use the prefix alone. Defaults to "app".
+	TagPrefix string
+	// RequireAck enables the forward protocol's at-least-once mode: each
+	// message carries a chunk ID and Write waits for the server to ack it.
+	RequireAck bool
+	// Timeout bounds dialing, writing and waiting for acks. Defaults to 5s.
+	Timeout time.Duration
+}
+
+// FluentSink sends entries to fluentd or fluent-bit using the Forward
+// protocol (msgpack over TCP, message mode).
+type FluentSink struct {
+	address string
+	opts    FluentOptions
+	dial    func(network, address string, timeout time.Duration) (net.Conn, error)
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func NewFluentSink(address string, opts FluentOptions) *FluentSink {
+	if opts.TagPrefix == "" {
+		opts.TagPrefix = "app"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &FluentSink{address: address, opts: opts, dial: net.DialTimeout}
+}
+
+func (s *FluentSink) Write(output LogOutput) error {
+	var chunk string
+	if s.opts.RequireAck {
+		var id [16]byte
+		_, _ = rand.Read(id[:])
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+	message := s.encode(output, chunk)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A dead connection is only noticed on write, so retry once on a fresh one.
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if err = s.sendLocked(message, chunk); err == nil {
+			return nil
+		}
+		s.closeLocked()
+	}
+	return fmt.Errorf("fluent forward to %s: %w", s.address, err)
+}
+
+func (s *FluentSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeLocked()
+}
+
+func (s *FluentSink) sendLocked(message []byte, chunk string) error {
+	if s.conn == nil {
+		conn, err := s.dial("tcp", s.address, s.opts.Timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	_ = s.conn.SetDeadline(time.Now().Add(s.opts.Timeout))
+	if _, err := s.conn.Write(message); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	response, err := readMsgpackStringMap(s.conn)
+	if err != nil {
+		return fmt.Errorf("read ack: %w", err)
+	}
+	if response["ack"] != chunk {
+		return fmt.Errorf("ack mismatch: expected %q, got %q", chunk, response["ack"])
+	}
+	return nil
+}
+
+func (s *FluentSink) closeLocked() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// encode builds a message-mode event: [tag, time, record, option].
+func (s *FluentSink) encode(output LogOutput, chunk string) []byte {
+	timestamp := time.Now()
+	if ts, ok := output.Details["timestamp"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			timestamp = parsed
+		}
+	}
+
+	size := 3
+	if chunk != "" {
+		size = 4
+	}
+	b := appendMsgpackArrayHeader(nil, size)
+	b = appendMsgpackString(b, s.tag(output))
+	b = appendMsgpackEventTime(b, timestamp)
+	b = appendMsgpack(b, output.fields())
+	if chunk != "" {
+		b = appendMsgpack(b, map[string]string{"chunk": chunk})
+	}
+	return b
+}
+
+func (s *FluentSink) tag(output LogOutput) string {
+	category, _ := output.Details["category"].(string)
+	if category == "" {
+		return s.opts.TagPrefix
+	}
+	return s.opts.TagPrefix + "." + strings.Map(func(r rune) rune {
+		if r == ' ' || r == '/' {
+			return '_'
+		}
+		return r
+	}, category)
+}
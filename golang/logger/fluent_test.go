@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFluentSink_AckMode(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	sink := NewFluentSink("fluentd:24224", FluentOptions{RequireAck: true})
+	sink.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return client, nil
+	}
+	defer sink.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		n, _ := server.Read(buf)
+		message := buf[:n]
+		received <- message
+
+		// The option map {"chunk": "<24 base64 chars>"} ends the message.
+		chunk := message[len(message)-24:]
+		ack := appendMsgpackMapHeader(nil, 1)
+		ack = appendMsgpackString(ack, "ack")
+		ack = appendMsgpackString(ack, string(chunk))
+		_, _ = server.Write(ack)
+	}()
+
+	err := sink.Write(LogOutput{
+		Level:   LevelInfo,
+		Message: "charged",
+		Details: map[string]interface{}{
+			"category":  "payments",
+			"timestamp": "2024-03-01T12:00:00Z",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	message := <-received
+	header := append([]byte{0x94}, appendMsgpackString(nil, "app.payments")...)
+	header = append(header, 0xd7, 0x00, 0x65, 0xe1, 0xc3, 0x40, 0, 0, 0, 0)
+	if !bytes.HasPrefix(message, header) {
+		t.Errorf("Expected tag and event time header, got % x", message[:len(header)])
+	}
+	if !bytes.Contains(message, appendMsgpackString(nil, "charged")) {
+		t.Error("Expected message in record")
+	}
+}
+
+func TestFluentSink_AckMismatch(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	sink := NewFluentSink("fluentd:24224", FluentOptions{RequireAck: true, Timeout: 100 * time.Millisecond})
+	sink.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return client, nil
+	}
+
+	go func() {
+		buf := make([]byte, 4096)
+		_, _ = server.Read(buf)
+		ack := appendMsgpackMapHeader(nil, 1)
+		ack = appendMsgpackString(ack, "ack")
+		ack = appendMsgpackString(ack, "wrong")
+		_, _ = server.Write(ack)
+	}()
+
+	if err := sink.Write(LogOutput{Level: LevelInfo, Message: "lost"}); err == nil {
+		t.Error("Expected error for mismatched ack")
+	}
+}
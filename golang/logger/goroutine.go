@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	goroutineContexts sync.Map // goroutine ID -> *LogContext
+	boundGoroutines   atomic.Int64
+)
+
+// BindGoroutine makes ctx's LogContext the ambient one for the calling
+// goroutine until unbind is called, so code reached without a ctx (callbacks
+// from third-party libraries) still logs with it: GetLogContext, and the
+// logging functions, fall back to it when their ctx carries no LogContext.
+// The session, operation and category are also set as pprof labels on the
+// goroutine, so CPU profiles can be split by request.
+//
+// This is best-effort: the binding is not inherited by goroutines the
+// callback starts, and resolving it costs a stack read per lookup while any
+// goroutine is bound. Pass ctx explicitly wherever possible.
+//
+//	defer logger.BindGoroutine(ctx)()
+func BindGoroutine(ctx context.Context) (unbind func()) {
+	lc, ok := ctx.Value(logContextKey).(*LogContext)
+	if !ok {
+		return func() {}
+	}
+	id := goroutineID()
+	previous, hadPrevious := goroutineContexts.Swap(id, lc)
+	if !hadPrevious {
+		boundGoroutines.Add(1)
+	}
+
+	var labels []string
+	for _, label := range [][2]string{
+		{"sessionId", lc.data.SessionID},
+		{"operationId", lc.data.OperationID},
+		{"category", lc.data.Category},
+	} {
+		if label[1] != "" {
+			labels = append(labels, label[0], label[1])
+		}
+	}
+	if len(labels) > 0 {
+		pprof.SetGoroutineLabels(pprof.WithLabels(ctx, pprof.Labels(labels...)))
+	}
+
+	return func() {
+		if hadPrevious {
+			goroutineContexts.Store(id, previous)
+		} else {
+			goroutineContexts.Delete(id)
+			boundGoroutines.Add(-1)
+		}
+		if len(labels) > 0 {
+			pprof.SetGoroutineLabels(ctx)
+		}
+	}
+}
+
+// goroutineLogContext returns the LogContext bound to the calling goroutine.
+func goroutineLogContext() (*LogContext, bool) {
+	if boundGoroutines.Load() == 0 {
+		return nil, false
+	}
+	lc, ok := goroutineContexts.Load(goroutineID())
+	if !ok {
+		return nil, false
+	}
+	return lc.(*LogContext), true
+}
+
+// goroutineID parses the calling goroutine's ID from its stack header,
+// "goroutine 123 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
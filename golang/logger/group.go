@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+type groupKey struct{}
+
+// LogGroup collects the entries logged with its context and writes them
+// together when closed.
+type LogGroup struct {
+	parent *LogGroup
+
+	mu      sync.Mutex
+	entries []groupEntry
+	closed  bool
+}
+
+type groupEntry struct {
+	logger *StandardLogger
+	output LogOutput
+}
+
+// Group returns a context whose entries are held back until the group is
+// closed, then written to each sink back to back, so lines from concurrent
+// requests cannot interleave with a logically related sequence. Level
+// filters and hooks still apply when each entry is logged. A group opened
+// inside another hands its entries to the outer group on Close.
+//
+//	ctx, group := logger.Group(ctx)
+//	defer group.Close()
+func Group(ctx context.Context) (context.Context, *LogGroup) {
+	g := &LogGroup{parent: groupFrom(ctx)}
+	return context.WithValue(ctx, groupKey{}, g), g
+}
+
+func groupFrom(ctx context.Context) *LogGroup {
+	g, _ := ctx.Value(groupKey{}).(*LogGroup)
+	return g
+}
+
+// Close writes the collected entries. Entries logged with the group's context
+// after Close are written immediately.
+func (g *LogGroup) Close() {
+	g.mu.Lock()
+	entries := g.entries
+	g.entries = nil
+	g.closed = true
+	g.mu.Unlock()
+
+	if g.parent != nil {
+		for _, e := range entries {
+			g.parent.add(e.logger, e.output)
+		}
+		return
+	}
+	for len(entries) > 0 {
+		l := entries[0].logger
+		n := 1
+		for n < len(entries) && entries[n].logger == l {
+			n++
+		}
+		l.writeMu.Lock()
+		for _, e := range entries[:n] {
+			l.write(e.output)
+		}
+		l.writeMu.Unlock()
+		entries = entries[n:]
+	}
+}
+
+func (g *LogGroup) add(l *StandardLogger, output LogOutput) {
+	g.mu.Lock()
+	if !g.closed {
+		g.entries = append(g.entries, groupEntry{logger: l, output: output})
+		g.mu.Unlock()
+		return
+	}
+	g.mu.Unlock()
+
+	if g.parent != nil {
+		g.parent.add(l, output)
+		return
+	}
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	l.write(output)
+}
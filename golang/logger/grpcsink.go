@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type GRPCOptions struct {
+	// Client performs the call. Defaults to an HTTP/2 client: TLS for https
+	// targets and prior-knowledge cleartext (h2c) for http targets, which
+	// relies on http.Protocols and is why the module requires Go 1.24.
+	Client *http.Client
+	// Header is sent when each stream opens, e.g. for authorization.
+	Header http.Header
+	// WriteTimeout bounds each write. A collector that stops reading for
+	// longer has its stream abandoned, failing the write, and a new one is
+	// opened on the next write. Defaults to 5s.
+	WriteTimeout time.Duration
+}
+
+// GRPCSink streams entries to a contextlogger.v1.LogCollector/Stream
+// client-streaming RPC (see proto/logentry.proto). The stream opens on the
+// first write and is reopened after it fails; Close ends it and reports the
+// server's status.
+type GRPCSink struct {
+	url     string
+	client  *http.Client
+	header  http.Header
+	timeout time.Duration
+
+	mu        sync.Mutex
+	stream    *grpcStream
+	lastError error
+}
+
+type grpcStream struct {
+	body *io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+var (
+	errStreamEnded  = errors.New("grpc: stream ended by server")
+	errWriteTimeout = errors.New("grpc: write timed out")
+)
+
+// NewGRPCSink targets a collector base URL such as "https://collector:4317".
+func NewGRPCSink(target string, opts GRPCOptions) *GRPCSink {
+	target = strings.TrimSuffix(target, "/")
+	if opts.Client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if strings.HasPrefix(target, "http://") {
+			transport.Protocols = new(http.Protocols)
+			transport.Protocols.SetUnencryptedHTTP2(true)
+		}
+		opts.Client = &http.Client{Transport: transport}
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 5 * time.Second
+	}
+	return &GRPCSink{
+		url:     target + "/contextlogger.v1.LogCollector/Stream",
+		client:  opts.Client,
+		header:  opts.Header,
+		timeout: opts.WriteTimeout,
+	}
+}
+
+func (s *GRPCSink) Write(output LogOutput) error {
+	msg, err := marshalLogEntry(output)
+	if err != nil {
+		return err
+	}
+	frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(msg)))
+	frame = append(frame, msg...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == nil {
+		s.stream = s.open()
+	}
+	// The pipe has no deadline, so a stalled write is ended by closing it,
+	// which also aborts the call.
+	body := s.stream.body
+	timer := time.AfterFunc(s.timeout, func() { body.CloseWithError(errWriteTimeout) })
+	_, err = body.Write(frame)
+	if !timer.Stop() {
+		err = errWriteTimeout
+	} else if err != nil {
+		<-s.stream.done
+	}
+	if err != nil {
+		s.lastError = err
+		s.stream = nil
+		return err
+	}
+	return nil
+}
+
+func (s *GRPCSink) SinkState() SinkState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := SinkState{Name: s.url}
+	if s.lastError != nil {
+		state.LastError = s.lastError.Error()
+	}
+	return state
+}
+
+// Close ends the current stream and waits for the server's response.
+func (s *GRPCSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == nil {
+		return nil
+	}
+	s.stream.body.Close()
+	<-s.stream.done
+	err := s.stream.err
+	s.stream = nil
+	return err
+}
+
+func (s *GRPCSink) open() *grpcStream {
+	pr, pw := io.Pipe()
+	st := &grpcStream{body: pw, done: make(chan struct{})}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, pr)
+	if err != nil {
+		st.err = err
+		pr.CloseWithError(err)
+		close(st.done)
+		return st
+	}
+	for k, v := range s.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	go func() {
+		defer close(st.done)
+		st.err = s.call(req)
+		if st.err == nil {
+			pr.CloseWithError(errStreamEnded)
+		} else {
+			pr.CloseWithError(st.err)
+		}
+	}()
+	return st
+}
+
+// call runs the RPC until the server responds, returning its status.
+func (s *GRPCSink) call(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("grpc: http status %s", resp.Status)
+	}
+
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status = resp.Header.Get("Grpc-Status")
+		message = resp.Header.Get("Grpc-Message")
+	}
+	if status != "0" {
+		return fmt.Errorf("grpc: status %s: %s", status, message)
+	}
+	return nil
+}
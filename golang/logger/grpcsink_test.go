@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readProtoFields decodes the length-delimited fields of a message, which is
+// every field LogEntry uses except truncated.
+func readProtoFields(t *testing.T, msg []byte) map[int][]string {
+	t.Helper()
+	fields := make(map[int][]string)
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		msg = msg[n:]
+		if key&7 != 2 {
+			t.Fatalf("Unexpected wire type %d", key&7)
+		}
+		size, n := binary.Uvarint(msg)
+		msg = msg[n:]
+		fields[int(key>>3)] = append(fields[int(key>>3)], string(msg[:size]))
+		msg = msg[size:]
+	}
+	return fields
+}
+
+func TestProtobufEncoder(t *testing.T) {
+	encoded, err := ProtobufEncoder{}.Encode(LogOutput{
+		Level:     LevelWarn,
+		Message:   "low disk",
+		SessionID: "s1",
+		Details: map[string]interface{}{
+			"category": "storage",
+			"tags":     []string{"a", "b"},
+			"metadata": map[string]string{"volume": "/data"},
+			"access":   map[string]interface{}{"status": 200},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, n := binary.Uvarint(encoded)
+	if int(size) != len(encoded)-n {
+		t.Fatalf("Expected length prefix %d, got %d", len(encoded)-n, size)
+	}
+
+	fields := readProtoFields(t, encoded[n:])
+	if fields[pbLevel][0] != "warn" || fields[pbMessage][0] != "low disk" || fields[pbSessionID][0] != "s1" {
+		t.Errorf("Unexpected top-level fields: %q", fields)
+	}
+	if fields[pbCategory][0] != "storage" || len(fields[pbTags]) != 2 {
+		t.Errorf("Unexpected category/tags: %q", fields)
+	}
+	entry := readProtoFields(t, []byte(fields[pbMetadata][0]))
+	if entry[1][0] != "volume" || entry[2][0] != "/data" {
+		t.Errorf("Unexpected metadata entry: %q", entry)
+	}
+	if fields[pbDetailsJSON][0] != `{"access":{"status":200}}` {
+		t.Errorf("Expected remaining details as JSON, got %q", fields[pbDetailsJSON])
+	}
+}
+
+func TestGRPCSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/contextlogger.v1.LogCollector/Stream" || r.ProtoMajor != 2 {
+			t.Errorf("Unexpected request %s over %s", r.URL.Path, r.Proto)
+		}
+		for {
+			var head [5]byte
+			if _, err := io.ReadFull(r.Body, head[:]); err != nil {
+				break
+			}
+			msg := make([]byte, binary.BigEndian.Uint32(head[1:]))
+			io.ReadFull(r.Body, msg)
+			mu.Lock()
+			messages = append(messages, readProtoFields(t, msg)[pbMessage][0])
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte{0, 0, 0, 0, 0})
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	sink := NewGRPCSink(srv.URL, GRPCOptions{})
+	for _, msg := range []string{"one", "two"} {
+		if err := sink.Write(LogOutput{Level: LevelInfo, Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Expected OK status, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 2 || messages[0] != "one" || messages[1] != "two" {
+		t.Errorf("Expected both entries streamed in order, got %q", messages)
+	}
+}
+
+func TestGRPCSink_WriteTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // never reads the stream
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+	defer close(release)
+
+	sink := NewGRPCSink(srv.URL, GRPCOptions{WriteTimeout: 50 * time.Millisecond})
+	var err error
+	for i := 0; i < 10000 && err == nil; i++ {
+		err = sink.Write(LogOutput{Level: LevelInfo, Message: strings.Repeat("x", 1024)})
+	}
+	if !errors.Is(err, errWriteTimeout) {
+		t.Fatalf("Expected the write to time out, got %v", err)
+	}
+	if state := sink.SinkState(); state.LastError != errWriteTimeout.Error() {
+		t.Errorf("Expected the timeout in the sink's state, got %+v", state)
+	}
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// SinkError reports a failed write to a sink, including encoding failures.
+type SinkError struct {
+	Sink Sink
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return fmt.Sprintf("write to %T: %v", e.Sink, e.Err)
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	internalErrors atomic.Uint64
+	errorHandler   atomic.Pointer[func(error)]
+)
+
+// SetErrorHandler sets the last-resort handler for failures inside the
+// logging pipeline, such as sink writes that fail (as *SinkError). Use it to
+// alert when log shipping is broken. Passing nil restores the default, which
+// prints to stderr. Loggers created with WithErrorHandler use their own.
+func SetErrorHandler(handler func(err error)) {
+	if handler == nil {
+		errorHandler.Store(nil)
+		return
+	}
+	errorHandler.Store(&handler)
+}
+
+// InternalErrors reports how many pipeline failures have occurred in the
+// process, across all loggers.
+func InternalErrors() uint64 {
+	return internalErrors.Load()
+}
+
+// reportError counts err and passes it to handler, or the package handler
+// when handler is nil.
+func reportError(handler func(error), err error) {
+	internalErrors.Add(1)
+	if handler == nil {
+		if h := errorHandler.Load(); h != nil {
+			handler = *h
+		}
+	}
+	if handler == nil {
+		fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+		return
+	}
+	handler(err)
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SinkState describes a sink for introspection.
+type SinkState struct {
+	Name      string `json:"name"`
+	Buffered  int    `json:"buffered,omitempty"`
+	Dropped   uint64 `json:"dropped,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// SinkStater is implemented by sinks that can report buffer occupancy, drops
+// or errors. Other sinks are reported by type name only.
+type SinkStater interface {
+	SinkState() SinkState
+}
+
+// LoggerState is a point-in-time view of a StandardLogger.
+type LoggerState struct {
+	Level            LogLevel            `json:"level,omitempty"`
+	CategoryLevels   map[string]LogLevel `json:"categoryLevels,omitempty"`
+	Sampling         map[string]float64  `json:"sampling,omitempty"`
+	Redact           []string            `json:"redact,omitempty"`
+	Suppressions     []Suppression       `json:"suppressions,omitempty"`
+	Sinks            []SinkState         `json:"sinks"`
+	Emitted          uint64              `json:"emitted"`
+	Filtered         uint64              `json:"filtered"`
+	Dropped          uint64              `json:"dropped"`
+	WriteErrors      uint64              `json:"writeErrors"`
+	LastWriteError   string              `json:"lastWriteError,omitempty"`
+	LastWriteErrorAt *time.Time          `json:"lastWriteErrorAt,omitempty"`
+	// Healthy is false while the most recent entry failed to reach a sink.
+	Healthy bool `json:"healthy"`
+}
+
+type loggerStats struct {
+	emitted     atomic.Uint64
+	filtered    atomic.Uint64
+	writeErrors atomic.Uint64
+	failing     atomic.Bool
+
+	mu          sync.Mutex
+	lastError   error
+	lastErrorAt time.Time
+}
+
+func (s *loggerStats) recordError(err error, now time.Time) {
+	s.writeErrors.Add(1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err
+	s.lastErrorAt = now
+}
+
+// setFailing records whether the latest entry failed to reach any sink.
+func (s *loggerStats) setFailing(failing bool) {
+	if s.failing.Load() != failing {
+		s.failing.Store(failing)
+	}
+}
+
+// State reports the logger's configuration and delivery statistics.
+func (l *StandardLogger) State() LoggerState {
+	state := LoggerState{
+		Level:        l.level.load(),
+		Emitted:      l.stats.emitted.Load(),
+		Filtered:     l.stats.filtered.Load(),
+		WriteErrors:  l.stats.writeErrors.Load(),
+		Suppressions: l.Suppressions(),
+	}
+	config := l.Config()
+	state.CategoryLevels, state.Sampling, state.Redact = config.CategoryLevels, config.Sampling, config.Redact
+
+	for _, sink := range l.activeSinks() {
+		sinkState := SinkState{Name: fmt.Sprintf("%T", sink)}
+		if stater, ok := sink.(SinkStater); ok {
+			sinkState = stater.SinkState()
+		}
+		state.Dropped += sinkState.Dropped
+		state.Sinks = append(state.Sinks, sinkState)
+	}
+
+	l.stats.mu.Lock()
+	defer l.stats.mu.Unlock()
+	if l.stats.lastError != nil {
+		at := l.stats.lastErrorAt
+		state.LastWriteError = l.stats.lastError.Error()
+		state.LastWriteErrorAt = &at
+	}
+	state.Healthy = !l.stats.failing.Load()
+	return state
+}
+
+// StateHandler serves l.State() as JSON, with status 503 while the logger is
+// unhealthy, for use as a /healthz-style admin endpoint.
+func StateHandler(l *StandardLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := l.State()
+		w.Header().Set("Content-Type", "application/json")
+		if !state.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(state)
+	})
+}
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingSink struct {
+	err error
+}
+
+func (s *failingSink) Write(LogOutput) error {
+	return s.err
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	var loggerErrs, globalErrs int
+	SetErrorHandler(func(error) { globalErrs++ })
+	defer SetErrorHandler(nil)
+
+	l := New(WithSinks(&failingSink{err: errors.New("broken")}), WithErrorHandler(func(error) { loggerErrs++ }))
+	l.Error(context.Background(), "lost")
+
+	if loggerErrs != 1 || globalErrs != 0 {
+		t.Errorf("Expected the logger's handler only, got logger=%d global=%d", loggerErrs, globalErrs)
+	}
+
+	stderr := captureStderr(t, func() {
+		SetErrorHandler(nil)
+		New(WithSinks(&failingSink{err: errors.New("broken")})).Error(context.Background(), "lost")
+	})
+	if stderr != "logger: write to *logger.failingSink: broken\n" {
+		t.Errorf("Expected default stderr report, got %q", stderr)
+	}
+}
+
+func TestStandardLogger_State(t *testing.T) {
+	failing := &failingSink{err: errors.New("disk full")}
+	network := NewNetworkSink("tcp", "127.0.0.1:1", NetworkSinkOptions{})
+	network.dial = func(string, string, time.Duration) (net.Conn, error) {
+		return nil, errors.New("refused")
+	}
+	l := New(WithSinks(failing, network), WithLevel(LevelInfo), WithCategoryLevel("db", LevelWarn))
+
+	var handled []error
+	SetErrorHandler(func(err error) { handled = append(handled, err) })
+	defer SetErrorHandler(nil)
+	before := InternalErrors()
+
+	l.Debug(context.Background(), "filtered")
+	l.Info(context.Background(), "emitted")
+
+	var sinkErr *SinkError
+	if len(handled) != 1 || !errors.As(handled[0], &sinkErr) || sinkErr.Sink != failing {
+		t.Errorf("Expected one SinkError for the failing sink, got %v", handled)
+	}
+	if InternalErrors()-before != 1 {
+		t.Errorf("Expected internal error counter to increase by 1, got %d", InternalErrors()-before)
+	}
+
+	state := l.State()
+	if state.Level != LevelInfo || state.CategoryLevels["db"] != LevelWarn {
+		t.Errorf("Unexpected levels %+v", state)
+	}
+	if state.Emitted != 1 || state.Filtered != 1 || state.WriteErrors != 1 {
+		t.Errorf("Unexpected counters %+v", state)
+	}
+	if state.Healthy || state.LastWriteError != "disk full" || state.LastWriteErrorAt == nil {
+		t.Errorf("Expected unhealthy state with last error, got %+v", state)
+	}
+	if len(state.Sinks) != 2 || state.Sinks[0].Name != "*logger.failingSink" {
+		t.Fatalf("Unexpected sinks %+v", state.Sinks)
+	}
+	if state.Sinks[1].Name != "tcp://127.0.0.1:1" || state.Sinks[1].Buffered != 1 || state.Sinks[1].LastError != "refused" {
+		t.Errorf("Unexpected network sink state %+v", state.Sinks[1])
+	}
+
+	rec := httptest.NewRecorder()
+	StateHandler(l).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while failing, got %d", rec.Code)
+	}
+
+	failing.err = nil
+	l.Info(context.Background(), "recovered")
+	rec = httptest.NewRecorder()
+	StateHandler(l).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var served LoggerState
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK || !served.Healthy || served.Emitted != 2 {
+		t.Errorf("Expected healthy state after recovery, got %d %+v", rec.Code, served)
+	}
+}
+
+func TestSuppressionHandler(t *testing.T) {
+	sink := &recordingSink{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(WithSinks(sink), WithLevel(LevelInfo), WithClock(func() time.Time { return now }))
+	handler := SuppressionHandler(l)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return rec
+	}
+	if rec := create(`{"duration": "10m"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a suppression without category or tags to be rejected, got %d", rec.Code)
+	}
+	rec := create(`{"category": "http.healthcheck", "duration": "10m", "reason": "maintenance"}`)
+	var silenced Suppression
+	if err := json.NewDecoder(rec.Body).Decode(&silenced); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 with the suppression, got %d: %v", rec.Code, err)
+	}
+	create(`{"tags": ["batch"], "downgradeTo": "debug", "duration": "1h"}`)
+
+	log := func(category string, tags ...string) {
+		lc := NewLogContext(LogContextData{Category: category}).WithTags(tags...)
+		_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+			l.Error(ctx, category)
+			return struct{}{}, nil
+		})
+	}
+	log("http.healthcheck.db")
+	log("jobs", "batch")
+	l.Event(context.WithValue(context.Background(), logContextKey, emptyLogContext.WithTags("batch")), "job.done")
+	log("http.orders")
+	if len(sink.entries) != 2 || sink.entries[0].Event != "job.done" || sink.entries[1].Message != "http.orders" {
+		t.Errorf("Expected the suppressed and downgraded entries dropped, got %v", sink.entries)
+	}
+	if state := l.State(); len(state.Suppressions) != 2 {
+		t.Errorf("Expected the state to list 2 suppressions, got %v", state.Suppressions)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/?id="+silenced.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rec.Code)
+	}
+	now = now.Add(time.Hour)
+	if active := l.Suppressions(); len(active) != 0 {
+		t.Errorf("Expected no active suppressions, got %v", active)
+	}
+	log("http.healthcheck.db")
+	if len(sink.entries) != 3 {
+		t.Errorf("Expected entries to flow again, got %v", sink.entries)
+	}
+}
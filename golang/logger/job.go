@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// Job outcomes, recorded as the "outcome" metadata of a job's final entry.
+const (
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobPanicked  = "panicked"
+)
+
+// PanicError is the error Job returns when its function panics.
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Format includes the panicking goroutine's stack with %+v, so it is logged
+// as the entry's stack.
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s\n%s", e.Error(), e.Stack)
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Job runs fn as one run of a scheduled or background job, so every job logs
+// the same way. The run gets its own session, named after a generated run
+// ID, and entries are categorized "job.<name>" with the job and run ID as
+// metadata. Job logs "Job started" and, when fn returns, "Job finished" or
+// "Job failed:" with the duration and outcome. A panic in fn is recovered
+// and returned as a *PanicError.
+//
+//	err := logger.Job(ctx, "nightly-reconciliation", reconcile)
+func Job(ctx context.Context, name string, fn func(context.Context) error) error {
+	runID := newID()
+	lc := GetLogContext(ctx).
+		WithSessionID(runID).
+		WithCategory("job." + name).
+		WithMetadata(map[string]string{"job": name, "runId": runID})
+
+	_, err := WithLogContext(ctx, lc, func(ctx context.Context) (struct{}, error) {
+		Info(ctx, "Job started")
+		start := time.Now()
+		err := runJob(ctx, fn)
+
+		outcome := JobSucceeded
+		if _, ok := err.(*PanicError); ok {
+			outcome = JobPanicked
+		} else if err != nil {
+			outcome = JobFailed
+		}
+		ctx = context.WithValue(ctx, logContextKey, GetLogContext(ctx).WithMetadata(map[string]string{
+			"durationMs": strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+			"outcome":    outcome,
+		}))
+		if err != nil {
+			Error(ctx, "Job failed:", err)
+		} else {
+			Info(ctx, "Job finished")
+		}
+		return struct{}{}, err
+	})
+	return err
+}
+
+func runJob(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: string(debug.Stack())}
+		}
+	}()
+	return fn(ctx)
+}
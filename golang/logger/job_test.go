@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJob(t *testing.T) {
+	previous := Default()
+	rec := &lockedSink{}
+	SetDefault(New(WithSinks(rec)))
+	defer SetDefault(previous)
+
+	if err := Job(context.Background(), "reconcile", func(ctx context.Context) error {
+		Info(ctx, "working")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.entries) != 3 {
+		t.Fatalf("Expected start, work and finish entries, got %v", rec.entries)
+	}
+	start, finish := rec.entries[0], rec.entries[2]
+	metadata := finish.Details["metadata"].(map[string]string)
+	if start.Message != "Job started" || finish.Message != "Job finished" || metadata["outcome"] != JobSucceeded ||
+		metadata["job"] != "reconcile" || metadata["runId"] != finish.SessionID || finish.Details["category"] != "job.reconcile" {
+		t.Errorf("Unexpected job entries: %v", rec.entries)
+	}
+
+	rec.entries = nil
+	err := Job(context.Background(), "reconcile", func(ctx context.Context) error {
+		var m map[string]int
+		m["boom"]++
+		return nil
+	})
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("Expected the panic returned as a *PanicError, got %v", err)
+	}
+	var runtimeErr interface{ RuntimeError() }
+	if !errors.As(err, &runtimeErr) {
+		t.Error("Expected the panic value to be unwrapped")
+	}
+	last := rec.entries[len(rec.entries)-1]
+	if last.Level != LevelError || last.Details["metadata"].(map[string]string)["outcome"] != JobPanicked ||
+		!strings.Contains(last.Details["stack"].(string), "job_test.go") {
+		t.Errorf("Expected the panic logged with its stack, got %+v", last)
+	}
+}
@@ -0,0 +1,134 @@
+//go:build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// JournaldSink writes entries to systemd-journald using its native protocol,
+// so context fields become queryable journal fields (SESSION_ID, CATEGORY,
+// METADATA_USERID, ...).
+type JournaldSink struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+// NewJournaldSink connects to the local journal. identifier becomes
+// SYSLOG_IDENTIFIER.
+func NewJournaldSink(identifier string) (*JournaldSink, error) {
+	return dialJournald(journaldSocket, identifier)
+}
+
+func dialJournald(path, identifier string) (*JournaldSink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("connect to journald: %w", err)
+	}
+	return &JournaldSink{conn: conn, identifier: identifier}, nil
+}
+
+func (s *JournaldSink) Write(output LogOutput) error {
+	_, err := s.conn.Write(s.encode(output))
+	return err
+}
+
+func (s *JournaldSink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *JournaldSink) encode(output LogOutput) []byte {
+	var buf bytes.Buffer
+	if output.Message != nil {
+		writeJournalField(&buf, "MESSAGE", fmt.Sprint(output.Message))
+	}
+	if output.Data != nil {
+		if data, err := json.Marshal(output.Data); err == nil {
+			writeJournalField(&buf, "DATA", string(data))
+		}
+	}
+	if output.Event != "" {
+		writeJournalField(&buf, "EVENT", output.Event)
+	}
+	writeJournalField(&buf, "PRIORITY", fmt.Sprint(SyslogSeverity.Number(output.Level)))
+	if s.identifier != "" {
+		writeJournalField(&buf, "SYSLOG_IDENTIFIER", s.identifier)
+	}
+	if output.SessionID != "" {
+		writeJournalField(&buf, "SESSION_ID", output.SessionID)
+	}
+	if output.OperationID != "" {
+		writeJournalField(&buf, "OPERATION_ID", output.OperationID)
+	}
+	if output.ParentOperationID != "" {
+		writeJournalField(&buf, "PARENT_OPERATION_ID", output.ParentOperationID)
+	}
+	if output.UserID != "" {
+		writeJournalField(&buf, "USER_ID", output.UserID)
+	}
+	if output.TenantID != "" {
+		writeJournalField(&buf, "TENANT_ID", output.TenantID)
+	}
+	if output.TraceID != "" {
+		writeJournalField(&buf, "TRACE_ID", output.TraceID)
+	}
+
+	for _, k := range sortedKeys(output.Details) {
+		if nested, ok := metadataFields(output.Details[k]); ok {
+			for _, nk := range sortedKeys(nested) {
+				writeJournalField(&buf, journalFieldName(k+"_"+nk), journalValue(nested[nk]))
+			}
+			continue
+		}
+		writeJournalField(&buf, journalFieldName(k), journalValue(output.Details[k]))
+	}
+	return buf.Bytes()
+}
+
+func journalValue(v interface{}) string {
+	if list, ok := v.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// writeJournalField uses the simple KEY=value form, or the length-prefixed
+// binary form when the value spans lines.
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName maps a key to journald's field alphabet: uppercase
+// letters, digits and underscores, not starting with an underscore or digit.
+func journalFieldName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(b.String(), "_0123456789")
+	if name == "" {
+		return "FIELD"
+	}
+	return name
+}
@@ -0,0 +1,60 @@
+//go:build linux
+
+package logger
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournaldSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram not available: %v", err)
+	}
+	defer listener.Close()
+
+	sink, err := dialJournald(path, "orders")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	err = sink.Write(LogOutput{
+		Level:     LevelWarn,
+		Message:   "Payment retry",
+		SessionID: "req-1",
+		Details: map[string]interface{}{
+			"category": "payments",
+			"metadata": map[string]string{"userId": "42"},
+			"stack":    "line1\nline2",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	n, err := listener.Read(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	datagram := buf[:n]
+
+	for _, field := range []string{
+		"MESSAGE=Payment retry\n",
+		"PRIORITY=4\n",
+		"SYSLOG_IDENTIFIER=orders\n",
+		"SESSION_ID=req-1\n",
+		"CATEGORY=payments\n",
+		"METADATA_USERID=42\n",
+		"STACK\n\x0b\x00\x00\x00\x00\x00\x00\x00line1\nline2\n",
+	} {
+		if !bytes.Contains(datagram, []byte(field)) {
+			t.Errorf("Expected field %q in %q", field, datagram)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package logger
+
+// SeverityScale is a numbering of levels for ingestion systems that filter
+// on numeric severity.
+type SeverityScale int
+
+const (
+	NoSeverity SeverityScale = iota
+	// SyslogSeverity numbers levels as RFC 5424 does, lower being more
+	// severe: error 3, warn 4, info 6, debug 7.
+	SyslogSeverity
+	// OTelSeverity uses OpenTelemetry SeverityNumbers: debug 5, info 9,
+	// warn 13, error 17.
+	OTelSeverity
+)
+
+var severityNumbers = map[SeverityScale]map[LogLevel]int{
+	SyslogSeverity: {LevelDebug: 7, LevelInfo: 6, LevelWarn: 4, LevelError: 3},
+	OTelSeverity:   {LevelDebug: 5, LevelInfo: 9, LevelWarn: 13, LevelError: 17},
+}
+
+// Number returns level's severity on the scale. Unknown levels are numbered
+// as info.
+func (s SeverityScale) Number(level LogLevel) int {
+	numbers := severityNumbers[s]
+	if n, ok := numbers[level]; ok {
+		return n
+	}
+	return numbers[LevelInfo]
+}
+
+// LevelFormat customizes how an encoder emits the level.
+type LevelFormat struct {
+	// Names replaces level names, e.g. {LevelWarn: "WARNING"}. Levels not
+	// listed keep their own.
+	Names map[LogLevel]string
+	// Severity adds a numeric severity on this scale.
+	Severity SeverityScale
+	// SeverityField names the severity field. Defaults to "severity".
+	SeverityField string
+	// OmitLevel emits the numeric severity instead of the level name.
+	OmitLevel bool
+}
+
+func (f LevelFormat) isZero() bool {
+	return len(f.Names) == 0 && f.Severity == NoSeverity
+}
+
+// Name returns the display name of level.
+func (f LevelFormat) Name(level LogLevel) string {
+	if name, ok := f.Names[level]; ok {
+		return name
+	}
+	return string(level)
+}
+
+// apply replaces the level in an entry's fields.
+func (f LevelFormat) apply(fields map[string]interface{}, level LogLevel) {
+	fields["level"] = f.Name(level)
+	if f.Severity != NoSeverity {
+		field := f.SeverityField
+		if field == "" {
+			field = "severity"
+		}
+		fields[field] = f.Severity.Number(level)
+		if f.OmitLevel {
+			delete(fields, "level")
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// Limits caps the size of an entry. Zero values mean unlimited. Entries that
+// hit any limit carry `"truncated": true` in their details.
+type Limits struct {
+	// MaxMessageLength caps the message and stack trace, in bytes.
+	MaxMessageLength int
+	// MaxMetadataValueLength caps each metadata value, in bytes.
+	MaxMetadataValueLength int
+	// MaxFields caps the number of metadata entries. The lexically first
+	// keys are kept.
+	MaxFields int
+	// MaxDepth caps the nesting of structured payloads; deeper values are
+	// replaced with "[max depth exceeded]".
+	MaxDepth int
+}
+
+const truncationMarker = "...[truncated %d bytes]"
+
+var (
+	limitsMu sync.RWMutex
+	limits   Limits
+)
+
+func SetLimits(l Limits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	limits = l
+}
+
+func currentLimits() Limits {
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+	return limits
+}
+
+func (l Limits) apply(output *LogOutput) {
+	if l == (Limits{}) {
+		return
+	}
+	truncated := false
+
+	switch message := output.Message.(type) {
+	case string:
+		if s, cut := truncateString(message, l.MaxMessageLength); cut {
+			output.Message = s
+			truncated = true
+		}
+	case map[string]interface{}, []interface{}:
+		if limited, cut := limitDepth(message, l.MaxDepth); cut {
+			output.Message = limited
+			truncated = true
+		}
+	}
+	if limited, cut := limitDepth(output.Data, l.MaxDepth); cut {
+		output.Data = limited
+		truncated = true
+	}
+	if stack, ok := output.Details["stack"].(string); ok {
+		if s, cut := truncateString(stack, l.MaxMessageLength); cut {
+			output.Details["stack"] = s
+			truncated = true
+		}
+	}
+
+	// metadataFields copies the metadata, so only when it may be trimmed.
+	if l.MaxFields > 0 || l.MaxMetadataValueLength > 0 {
+		if metadata, ok := metadataFields(output.Details["metadata"]); ok {
+			if l.limitMetadata(metadata) {
+				output.Details["metadata"] = metadata
+				truncated = true
+			}
+		}
+	}
+
+	if truncated {
+		output.Details["truncated"] = true
+	}
+}
+
+// limitMetadata trims metadata in place, reporting whether anything changed.
+func (l Limits) limitMetadata(metadata map[string]interface{}) bool {
+	changed := false
+	if l.MaxFields > 0 && len(metadata) > l.MaxFields {
+		for _, k := range sortedKeys(metadata)[l.MaxFields:] {
+			delete(metadata, k)
+		}
+		changed = true
+	}
+	for k, v := range metadata {
+		switch v := v.(type) {
+		case string:
+			if s, cut := truncateString(v, l.MaxMetadataValueLength); cut {
+				metadata[k] = s
+				changed = true
+			}
+		case []string:
+			values := make([]string, len(v))
+			for i, value := range v {
+				s, cut := truncateString(value, l.MaxMetadataValueLength)
+				values[i] = s
+				changed = changed || cut
+			}
+			metadata[k] = values
+		}
+	}
+	return changed
+}
+
+// truncateString cuts s to at most max bytes on a rune boundary and appends
+// a marker noting how much was removed.
+func truncateString(s string, max int) (string, bool) {
+	if max <= 0 || len(s) <= max {
+		return s, false
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf(truncationMarker, len(s)-cut), true
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"context"
+	"net/url"
+)
+
+// Link is a deep link, such as a trace, dashboard or runbook URL, added to
+// entries by the Links hook.
+type Link struct {
+	// Name is the link's key under details.links, e.g. "trace".
+	Name string
+	// URL is a template whose {placeholders} are replaced with the entry's
+	// fields, query-escaped: sessionId, operationId, parentOperationId,
+	// userId, tenantId, traceId and level, then metadata keys, then string
+	// details such as category and timestamp. A link with a placeholder the
+	// entry has no value for is left out.
+	URL string
+	// MinLevel is the lowest level the link is added at. Defaults to
+	// LevelError.
+	MinLevel LogLevel
+}
+
+// Links returns a Hook adding details.links to entries, so on-call engineers
+// can go straight from a log line to its trace or runbook:
+//
+//	logger.WithHooks(logger.Links(
+//		logger.Link{Name: "trace", URL: "https://grafana.example.com/explore?traceId={traceId}"},
+//		logger.Link{Name: "runbook", URL: "https://runbooks.example.com/{category}"},
+//	))
+func Links(links ...Link) Hook {
+	for i := range links {
+		if links[i].MinLevel == "" {
+			links[i].MinLevel = LevelError
+		}
+	}
+	return func(_ context.Context, output *LogOutput) {
+		var expanded map[string]string
+		for _, link := range links {
+			if !output.Level.AtLeast(link.MinLevel) {
+				continue
+			}
+			if u, ok := expandLink(link.URL, *output); ok {
+				if expanded == nil {
+					expanded = make(map[string]string)
+				}
+				expanded[link.Name] = u
+			}
+		}
+		if expanded != nil {
+			output.Details["links"] = expanded
+		}
+	}
+}
+
+func expandLink(template string, output LogOutput) (string, bool) {
+	return expandTemplate(template, func(name string) (string, bool) {
+		value := linkValue(name, output)
+		return url.QueryEscape(value), value != ""
+	})
+}
+
+func linkValue(name string, output LogOutput) string {
+	switch name {
+	case "sessionId":
+		return output.SessionID
+	case "operationId":
+		return output.OperationID
+	case "parentOperationId":
+		return output.ParentOperationID
+	case "userId":
+		return output.UserID
+	case "tenantId":
+		return output.TenantID
+	case "traceId":
+		return output.TraceID
+	case "level":
+		return string(output.Level)
+	}
+	if metadata, ok := metadataFields(output.Details["metadata"]); ok {
+		if v, ok := metadata[name].(string); ok {
+			return v
+		}
+	}
+	v, _ := output.Details[name].(string)
+	return v
+}
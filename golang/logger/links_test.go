@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithHooks(Links(
+		Link{Name: "trace", URL: "https://traces.example.com/trace/{traceId}?tenant={tenantId}"},
+		Link{Name: "runbook", URL: "https://runbooks.example.com/{category}/{orderId}", MinLevel: LevelWarn},
+	)))
+	lc := NewLogContext(LogContextData{
+		Category: "payments",
+		TenantID: "acme & co",
+		TraceID:  "4bf92f35",
+		Metadata: map[string]string{"orderId": "o-1"},
+	})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+
+	l.Info(ctx, "charged")
+	l.Warn(context.Background(), "no context")
+	l.Error(ctx, "charge failed")
+
+	if _, ok := sink.entries[0].Details["links"]; ok {
+		t.Error("Expected no links below the links' levels")
+	}
+	if _, ok := sink.entries[1].Details["links"]; ok {
+		t.Error("Expected links with missing placeholders left out")
+	}
+	links := sink.entries[2].Details["links"].(map[string]string)
+	if links["trace"] != "https://traces.example.com/trace/4bf92f35?tenant=acme+%26+co" ||
+		links["runbook"] != "https://runbooks.example.com/payments/o-1" {
+		t.Errorf("Unexpected links: %v", links)
+	}
+}
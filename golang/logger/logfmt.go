@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LogfmtEncoder renders entries as logfmt key=value lines, as Loki and
+// Heroku-style tooling expect: time, level, msg and the context fields
+// first, then details flattened with dotted keys ("metadata.userId") in
+// lexical order. Tags are comma-joined; structured data is JSON.
+type LogfmtEncoder struct{}
+
+func (LogfmtEncoder) Encode(output LogOutput) ([]byte, error) {
+	var b strings.Builder
+	if timestamp, ok := output.Details["timestamp"]; ok {
+		writeLogfmt(&b, "time", timestamp)
+	}
+	writeLogfmt(&b, "level", string(output.Level))
+	if output.Event != "" {
+		writeLogfmt(&b, "event", output.Event)
+	}
+	if output.Message != nil {
+		writeLogfmt(&b, "msg", output.Message)
+	}
+	if output.Data != nil {
+		writeLogfmt(&b, "data", output.Data)
+	}
+	for _, field := range [][2]string{
+		{"sessionId", output.SessionID},
+		{"operationId", output.OperationID},
+		{"parentOperationId", output.ParentOperationID},
+		{"userId", output.UserID},
+		{"tenantId", output.TenantID},
+		{"traceId", output.TraceID},
+	} {
+		if field[1] != "" {
+			writeLogfmt(&b, field[0], field[1])
+		}
+	}
+	for _, k := range sortedKeys(output.Details) {
+		if k != "timestamp" {
+			writeLogfmtValue(&b, k, output.Details[k])
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// writeLogfmtValue flattens maps into dotted keys.
+func writeLogfmtValue(b *strings.Builder, key string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			writeLogfmtValue(b, key+"."+k, v[k])
+		}
+	case map[string]string:
+		for _, k := range sortedKeys(v) {
+			writeLogfmt(b, key+"."+k, v[k])
+		}
+	case []string:
+		writeLogfmt(b, key, strings.Join(v, ","))
+	default:
+		writeLogfmt(b, key, value)
+	}
+}
+
+func writeLogfmt(b *strings.Builder, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case nil:
+		return
+	case map[string]interface{}, []interface{}:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(raw)
+		}
+	default:
+		s = fmt.Sprint(v)
+	}
+	if needsLogfmtQuotes(s) {
+		s = strconv.Quote(s)
+	}
+	b.WriteString(s)
+}
+
+// logfmtKey replaces the characters logfmt keys cannot hold.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func needsLogfmtQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == '\\' {
+			return true
+		}
+	}
+	return false
+}
@@ -2,81 +2,156 @@ package logger
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"sort"
 	"time"
 )
 
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+var stdout io.Writer = os.Stdout
+
 func Debug(ctx context.Context, args ...interface{}) {
-	log(ctx, LevelDebug, args...)
+	Default().Log(ctx, LevelDebug, args...)
 }
 
 func Info(ctx context.Context, args ...interface{}) {
-	log(ctx, LevelInfo, args...)
+	Default().Log(ctx, LevelInfo, args...)
 }
 
 func Warn(ctx context.Context, args ...interface{}) {
-	log(ctx, LevelWarn, args...)
+	Default().Log(ctx, LevelWarn, args...)
 }
 
 func Error(ctx context.Context, args ...interface{}) {
-	log(ctx, LevelError, args...)
+	Default().Log(ctx, LevelError, args...)
 }
 
-func log(ctx context.Context, level LogLevel, args ...interface{}) {
+// newEntry builds the entry for a log call: context fields plus the message
+// and error details extracted from args.
+func newEntry(ctx context.Context, level LogLevel, args ...interface{}) LogOutput {
+	output := buildOutput(ctx, level)
+
+	args, fields := splitFields(args)
+	if fields != nil {
+		output.Details["fields"] = fields
+	}
+	for _, arg := range args {
+		if m, ok := arg.(Message); ok {
+			m.addTo(output.Details)
+			break
+		}
+	}
+
+	isStructured := false
+	if len(args) == 1 {
+		if structured, ok, cut := toStructured(args[0]); ok {
+			if MessageFormat(messageFormat.Load()) == MessageLegacy {
+				output.Message = structured
+			} else {
+				output.Data = structured
+			}
+			if cut {
+				output.Details["truncated"] = true
+			}
+			isStructured = true
+		}
+	}
+
+	if len(args) > 0 && !isStructured {
+		message, stack := extractMessageAndStack(args...)
+		if message != "" {
+			output.Message = message
+		}
+		if stack != "" {
+			output.Details["stack"] = stack
+		}
+		if chain := errorChain(trailingError(args...)); chain != nil {
+			output.Details["errorChain"] = chain
+		}
+		if errs := joinedErrors(trailingError(args...)); errs != nil {
+			output.Details["errors"] = errs
+		}
+	}
+	if level == LevelError {
+		output.Details["errorClass"] = errorClass(GetLogContext(ctx), trailingError(args...))
+	}
+
+	return output
+}
+
+func buildOutput(ctx context.Context, level LogLevel) LogOutput {
 	logContext := GetLogContext(ctx)
 
 	output := LogOutput{
-		Level:   level,
-		Details: make(map[string]interface{}),
+		SchemaVersion: currentSchemaVersion(),
+		Level:         level,
+		Details:       make(map[string]interface{}),
 	}
 
 	if logContext.data.SessionID != "" {
 		output.SessionID = logContext.data.SessionID
 	}
 
-	if len(logContext.data.Tags) > 0 {
-		tags := make([]string, 0, len(logContext.data.Tags))
-		for tag := range logContext.data.Tags {
-			tags = append(tags, tag)
-		}
-		sort.Strings(tags)
-		output.Details["tags"] = tags
+	output.OperationID = logContext.data.OperationID
+	output.ParentOperationID = logContext.data.ParentOperationID
+	output.UserID = logContext.data.UserID
+	output.TenantID = logContext.data.TenantID
+	output.TraceID = logContext.data.TraceID
+
+	if logContext.data.Tags.Len() > 0 {
+		output.Details["tags"] = logContext.data.Tags.Slice()
 	}
 
 	if logContext.data.Category != "" {
 		output.Details["category"] = logContext.data.Category
 	}
 
-	if len(logContext.data.Metadata) > 0 {
-		metadata := make(map[string]string, len(logContext.data.Metadata))
-		for k, v := range logContext.data.Metadata {
-			metadata[k] = v
-		}
-		output.Details["metadata"] = metadata
+	if len(logContext.data.Metadata) > 0 || len(logContext.data.providers) > 0 {
+		output.Details["metadata"] = logContext.metadataOutput()
 	}
 
-	if len(args) > 0 {
-		message, stack := extractMessageAndStack(args...)
-		if message != "" {
-			output.Message = message
+	return output
+}
+
+// metadataOutput copies the metadata for an entry. Keys with collected
+// values become arrays, which needs the wider map type.
+func (lc *LogContext) metadataOutput() interface{} {
+	if len(lc.data.metadataHistory) == 0 {
+		metadata := make(map[string]string, len(lc.data.Metadata)+len(lc.data.providers))
+		for k, v := range lc.data.Metadata {
+			metadata[k] = v
 		}
-		if stack != "" {
-			output.Details["stack"] = stack
+		for k, provider := range lc.data.providers {
+			metadata[k] = callProvider(provider)
 		}
+		return metadata
 	}
 
-	output.Details["timestamp"] = time.Now().UTC().Format(time.RFC3339)
-
-	jsonBytes, err := json.Marshal(output)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to marshal log: %v\n", err)
-		return
+	metadata := make(map[string]interface{}, len(lc.data.Metadata))
+	for k, v := range lc.data.Metadata {
+		if history, ok := lc.data.metadataHistory[k]; ok {
+			metadata[k] = append(append([]string(nil), history...), v)
+		} else {
+			metadata[k] = v
+		}
+	}
+	for k, provider := range lc.data.providers {
+		metadata[k] = callProvider(provider)
 	}
+	return metadata
+}
 
-	fmt.Fprintln(os.Stdout, string(jsonBytes))
+func callProvider(provider func() string) (value string) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("<provider panic: %v>", r)
+		}
+	}()
+	return provider()
 }
 
 func extractMessageAndStack(args ...interface{}) (message string, stack string) {
@@ -87,29 +162,29 @@ func extractMessageAndStack(args ...interface{}) (message string, stack string)
 	// Single argument case
 	if len(args) == 1 {
 		if err, ok := args[0].(error); ok {
-			message = err.Error()
+			message = errorText(err)
 			stack = fmt.Sprintf("%+v", err)
 		} else {
-			message = fmt.Sprint(args[0])
+			message = renderArg(args[0])
 		}
 		return message, stack
 	}
 
 	// Multiple arguments case
-	firstArg := fmt.Sprint(args[0])
+	firstArg := renderArg(args[0])
 	lastArg := args[len(args)-1]
 
 	// Check if last argument is an error
 	if err, ok := lastArg.(error); ok {
 		if len(args) == 2 {
-			message = fmt.Sprintf("%s %s", firstArg, err.Error())
+			message = fmt.Sprintf("%s %s", firstArg, errorText(err))
 		} else {
-			middle := fmt.Sprint(args[1 : len(args)-1]...)
-			message = fmt.Sprintf("%s%s %s", firstArg, middle, err.Error())
+			middle := renderArgs(args[1 : len(args)-1]...)
+			message = fmt.Sprintf("%s%s %s", firstArg, middle, errorText(err))
 		}
 		stack = fmt.Sprintf("%+v", err)
 	} else {
-		message = fmt.Sprint(args...)
+		message = renderArgs(args...)
 	}
 
 	return message, stack
@@ -1,10 +1,64 @@
 package logger
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+func captureOutput(t *testing.T, fn func()) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	original := stdout
+	stdout = &buf
+	defer func() { stdout = original }()
+
+	fn()
+
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	original := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = original }()
+
+	fn()
+
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+func decodeEntry(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("Invalid JSON log line %q: %v", line, err)
+	}
+	return entry
+}
+
 func TestLogContext_WithCategory(t *testing.T) {
 	lc := NewLogContext(LogContextData{})
 	lc2 := lc.WithCategory("test-category")
@@ -29,19 +83,76 @@ func TestLogContext_WithSessionID(t *testing.T) {
 	}
 }
 
+func TestLogContext_CopyOnWrite(t *testing.T) {
+	base := NewLogContext(LogContextData{}).
+		WithTags("a").
+		WithMetadata(map[string]string{"k": "v"})
+	sibling1 := base.WithCategory("one").WithTags("b").WithMetadata(map[string]string{"k": "changed"})
+	sibling2 := base.WithSessionID("two").WithoutTags("a").WithoutMetadata("k")
+
+	if base.data.Tags.Len() != 1 || base.data.Metadata["k"] != "v" {
+		t.Error("Derived contexts must not modify their parent")
+	}
+	if sibling1.data.Tags.Len() != 2 || sibling1.data.Metadata["k"] != "changed" {
+		t.Error("Unexpected sibling1 state")
+	}
+	if sibling2.data.Tags.Len() != 0 || len(sibling2.data.Metadata) != 0 {
+		t.Error("Unexpected sibling2 state")
+	}
+}
+
 func TestLogContext_WithTags(t *testing.T) {
 	lc := NewLogContext(LogContextData{})
 	lc2 := lc.WithTags("tag1", "tag2")
 	lc3 := lc2.WithTags("tag3")
 
-	if len(lc.data.Tags) != 0 {
+	if lc.data.Tags.Len() != 0 {
 		t.Error("Original context should not be modified")
 	}
-	if len(lc2.data.Tags) != 2 {
-		t.Errorf("Expected 2 tags, got %d", len(lc2.data.Tags))
+	if lc2.data.Tags.Len() != 2 {
+		t.Errorf("Expected 2 tags, got %d", lc2.data.Tags.Len())
+	}
+	if lc3.data.Tags.Len() != 3 {
+		t.Errorf("Expected 3 tags, got %d", lc3.data.Tags.Len())
+	}
+}
+
+func TestNewLogContext_SeedsTags(t *testing.T) {
+	lc := NewLogContext(LogContextData{Tags: NewTagSet("b", "a")}).WithTags("c", "a")
+
+	if strings.Join(lc.data.Tags.Slice(), ",") != "a,b,c" {
+		t.Errorf("Expected sorted unique tags, got %v", lc.data.Tags)
+	}
+}
+
+func TestLogContext_WithMetadataNS(t *testing.T) {
+	lc := NewLogContext(LogContextData{}).
+		WithMetadataNS("http", map[string]string{"duration": "120"}).
+		WithMetadataNS("db", map[string]string{"duration": "80"})
+
+	if lc.data.Metadata["http.duration"] != "120" || lc.data.Metadata["db.duration"] != "80" {
+		t.Errorf("Expected namespaced keys, got %v", lc.data.Metadata)
+	}
+}
+
+func TestTagSet(t *testing.T) {
+	a := NewTagSet("web", "api", "web")
+	b := NewTagSet("db", "api")
+
+	if got := a.Union(b).String(); got != "[api db web]" {
+		t.Errorf("Unexpected union %s", got)
+	}
+	if got := a.Intersect(b).String(); got != "[api]" {
+		t.Errorf("Unexpected intersection %s", got)
+	}
+	if !a.Has("web") || a.Has("db") || a.Len() != 2 {
+		t.Errorf("Unexpected set %s", a)
+	}
+	if !a.Union(b).ContainsAll(b) || a.ContainsAll(b) {
+		t.Error("Unexpected ContainsAll result")
 	}
-	if len(lc3.data.Tags) != 3 {
-		t.Errorf("Expected 3 tags, got %d", len(lc3.data.Tags))
+	if a.With("api").tags[0] != "api" || a.Without("api").String() != "[web]" {
+		t.Errorf("Unexpected With/Without results")
 	}
 }
 
@@ -50,13 +161,13 @@ func TestLogContext_WithoutTags(t *testing.T) {
 	lc2 := lc.WithTags("tag1", "tag2", "tag3")
 	lc3 := lc2.WithoutTags("tag2")
 
-	if len(lc3.data.Tags) != 2 {
-		t.Errorf("Expected 2 tags, got %d", len(lc3.data.Tags))
+	if lc3.data.Tags.Len() != 2 {
+		t.Errorf("Expected 2 tags, got %d", lc3.data.Tags.Len())
 	}
-	if lc3.data.Tags["tag2"] {
+	if lc3.HasTag("tag2") {
 		t.Error("tag2 should have been removed")
 	}
-	if !lc3.data.Tags["tag1"] || !lc3.data.Tags["tag3"] {
+	if !lc3.HasTag("tag1") || !lc3.HasTag("tag3") {
 		t.Error("tag1 and tag3 should still be present")
 	}
 }
@@ -97,6 +208,92 @@ func TestLogContext_WithoutMetadata(t *testing.T) {
 	}
 }
 
+func TestLogContext_MetadataCollisionPolicy(t *testing.T) {
+	defer SetMetadataCollisionPolicy(MetadataOverwrite)
+	parent := NewLogContext(LogContextData{}).WithMetadata(map[string]string{"duration": "5ms", "user": "1"})
+	overlay := map[string]string{"duration": "12ms", "user": "1"}
+
+	SetMetadataCollisionPolicy(MetadataOverwrite)
+	if got := parent.WithMetadata(overlay).data.Metadata["duration"]; got != "12ms" {
+		t.Errorf("Overwrite: expected '12ms', got '%s'", got)
+	}
+
+	SetMetadataCollisionPolicy(MetadataKeepFirst)
+	if got := parent.WithMetadata(overlay).data.Metadata["duration"]; got != "5ms" {
+		t.Errorf("KeepFirst: expected '5ms', got '%s'", got)
+	}
+
+	SetMetadataCollisionPolicy(MetadataCollect)
+	collected := parent.WithMetadata(overlay).WithMetadata(map[string]string{"duration": "20ms"})
+	metadata := collected.metadataOutput().(map[string]interface{})
+	durations, ok := metadata["duration"].([]string)
+	if !ok || strings.Join(durations, ",") != "5ms,12ms,20ms" {
+		t.Errorf("Collect: expected all durations oldest first, got %v", metadata["duration"])
+	}
+	if metadata["user"] != "1" {
+		t.Errorf("Collect: equal values should not be collected, got %v", metadata["user"])
+	}
+	if _, ok := collected.WithoutMetadata("duration").metadataOutput().(map[string]string); !ok {
+		t.Error("Removing a key should drop its collected values")
+	}
+	if len(parent.metadataOutput().(map[string]string)) != 2 {
+		t.Error("Original context should not be modified")
+	}
+}
+
+func TestLogContext_IdentityFields(t *testing.T) {
+	lc := NewLogContext(LogContextData{}).
+		WithUserID("u-1").
+		WithTenantID("acme").
+		WithTraceID("4bf92f3577b34da6a3ce929d0e0e4736")
+
+	if lc.UserID() != "u-1" || lc.TenantID() != "acme" || lc.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Unexpected identity fields: %+v", lc.data)
+	}
+
+	lines := captureOutput(t, func() {
+		_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+			Info(ctx, "who")
+			return struct{}{}, nil
+		})
+	})
+	entry := decodeEntry(t, lines[0])
+	if entry["userId"] != "u-1" || entry["tenantId"] != "acme" || entry["traceId"] != lc.TraceID() {
+		t.Errorf("Expected top-level identity fields, got %s", lines[0])
+	}
+}
+
+func TestLogContext_WithMetadataProvider(t *testing.T) {
+	depth := 1
+	lc := NewLogContext(LogContextData{}).
+		WithMetadata(map[string]string{"queueDepth": "stale", "job": "sync"}).
+		WithMetadataProvider("queueDepth", func() string { return fmt.Sprint(depth) }).
+		WithMetadataProvider("broken", func() string { panic("boom") })
+
+	lines := captureOutput(t, func() {
+		_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+			Info(ctx, "first")
+			depth = 7
+			Info(ctx, "second")
+			return struct{}{}, nil
+		})
+	})
+
+	for i, expected := range []string{"1", "7"} {
+		metadata := decodeEntry(t, lines[i])["details"].(map[string]interface{})["metadata"].(map[string]interface{})
+		if metadata["queueDepth"] != expected || metadata["job"] != "sync" {
+			t.Errorf("Expected queueDepth %s, got %v", expected, metadata)
+		}
+		if metadata["broken"] != "<provider panic: boom>" {
+			t.Errorf("Expected panicking provider to be reported, got %v", metadata["broken"])
+		}
+	}
+
+	if _, ok := lc.WithoutMetadata("queueDepth").data.providers["queueDepth"]; ok {
+		t.Error("WithoutMetadata should remove providers")
+	}
+}
+
 func TestGetLogContext(t *testing.T) {
 	ctx := context.Background()
 	lc := GetLogContext(ctx)
@@ -104,11 +301,26 @@ func TestGetLogContext(t *testing.T) {
 	if lc == nil {
 		t.Fatal("Expected non-nil LogContext")
 	}
-	if len(lc.data.Tags) != 0 || len(lc.data.Metadata) != 0 {
+	if lc.data.Tags.Len() != 0 || len(lc.data.Metadata) != 0 {
 		t.Error("Empty context should have no tags or metadata")
 	}
 }
 
+func TestGetLogContext_NoAllocations(t *testing.T) {
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = GetLogContext(ctx)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations without a LogContext, got %v", allocs)
+	}
+
+	derived := GetLogContext(ctx).WithTags("a")
+	if GetLogContext(ctx).data.Tags.Len() != 0 || derived.data.Tags.Len() != 1 {
+		t.Error("Deriving from the shared empty context must not modify it")
+	}
+}
+
 func TestWithLogContext(t *testing.T) {
 	ctx := context.Background()
 	logCtx := NewLogContext(LogContextData{
@@ -176,6 +388,197 @@ func TestWithLogContext_CallbackInt(t *testing.T) {
 	}
 }
 
+func TestWithLogContext_OperationChain(t *testing.T) {
+	ctx := context.Background()
+	root := NewLogContext(LogContextData{SessionID: "req-1"})
+
+	_, _ = WithLogContext(ctx, root, func(ctx context.Context) (struct{}, error) {
+		outer := GetLogContext(ctx)
+		if outer.data.OperationID == "" {
+			t.Fatal("Expected an operation ID for the outer scope")
+		}
+		if outer.data.ParentOperationID != "" {
+			t.Errorf("Root scope should have no parent, got '%s'", outer.data.ParentOperationID)
+		}
+
+		// Freshly built contexts still nest under the enclosing scope.
+		for _, next := range []*LogContext{outer.WithTags("db"), NewLogContext(LogContextData{})} {
+			_, _ = WithLogContext(ctx, next, func(ctx context.Context) (struct{}, error) {
+				inner := GetLogContext(ctx)
+				if inner.data.ParentOperationID != outer.data.OperationID {
+					t.Errorf("Expected parent '%s', got '%s'", outer.data.OperationID, inner.data.ParentOperationID)
+				}
+				if inner.data.OperationID == outer.data.OperationID {
+					t.Error("Nested scope should get its own operation ID")
+				}
+				return struct{}{}, nil
+			})
+		}
+		return struct{}{}, nil
+	})
+}
+
+func TestLogScopeDiffs(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+	LogScopeDiffs(true)
+	defer LogScopeDiffs(false)
+
+	outer := NewLogContext(LogContextData{Category: "http", Metadata: map[string]string{"path": "/"}}).WithTags("api")
+	inner := NewLogContext(LogContextData{Category: "db"}).WithTags("sql")
+	_, _ = WithLogContext(context.Background(), outer, func(ctx context.Context) (struct{}, error) {
+		return WithLogContext(ctx, inner, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, nil
+		})
+	})
+
+	if len(sink.entries) != 4 || sink.entries[1].Message != "entering scope" || sink.entries[2].Message != "leaving scope" {
+		t.Fatalf("Expected entering and leaving entries for both scopes, got %v", sink.entries)
+	}
+	expected := map[string]interface{}{
+		"addedTags":       []string{"sql"},
+		"removedTags":     []string{"api"},
+		"removedMetadata": []string{"path"},
+		"fields":          map[string]string{"category": "db"},
+	}
+	if diff := sink.entries[1].Details["contextDiff"]; !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %v, got %v", expected, diff)
+	}
+	if sink.entries[1].Level != LevelDebug || sink.entries[1].Details["category"] != "db" {
+		t.Errorf("Expected a debug entry from inside the scope, got %+v", sink.entries[1])
+	}
+	undone := sink.entries[2].Details["contextDiff"].(map[string]interface{})
+	if !reflect.DeepEqual(undone["addedTags"], []string{"api"}) {
+		t.Errorf("Expected the leaving entry to restore the outer tags, got %v", undone)
+	}
+}
+
+func TestMergeLogContext(t *testing.T) {
+	ctx := context.Background()
+	outer := NewLogContext(LogContextData{SessionID: "req-1", Category: "http"}).
+		WithTags("api").
+		WithMetadata(map[string]string{"userId": "42", "path": "/a"})
+	inner := NewLogContext(LogContextData{Category: "db"}).
+		WithTags("sql").
+		WithMetadata(map[string]string{"path": "/b", "table": "users"})
+
+	_, _ = WithLogContext(ctx, outer, func(ctx context.Context) (struct{}, error) {
+		_, _ = MergeLogContext(ctx, inner, func(ctx context.Context) (struct{}, error) {
+			lc := GetLogContext(ctx)
+			if !lc.HasTag("api") || !lc.HasTag("sql") {
+				t.Errorf("Expected union of tags, got %v", lc.data.Tags)
+			}
+			if lc.data.Metadata["userId"] != "42" || lc.data.Metadata["path"] != "/b" || lc.data.Metadata["table"] != "users" {
+				t.Errorf("Expected merged metadata, got %v", lc.data.Metadata)
+			}
+			if lc.data.SessionID != "req-1" || lc.data.Category != "db" {
+				t.Errorf("Unexpected session '%s' or category '%s'", lc.data.SessionID, lc.data.Category)
+			}
+			return struct{}{}, nil
+		})
+
+		_, _ = WithLogContext(ctx, inner, func(ctx context.Context) (struct{}, error) {
+			if GetLogContext(ctx).HasTag("api") {
+				t.Error("WithLogContext should replace the enclosing context")
+			}
+			return struct{}{}, nil
+		})
+
+		cleared := GetLogContext(ClearLogContext(ctx))
+		if cleared.data.SessionID != "" || cleared.data.Tags.Len() != 0 {
+			t.Error("ClearLogContext should detach the enclosing context")
+		}
+		return struct{}{}, nil
+	})
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	lines := captureOutput(t, func() {
+		result, err := Retry(context.Background(), 3, func(ctx context.Context) (string, error) {
+			calls++
+			Info(ctx, "trying")
+			if calls < 2 {
+				return "", errors.New("flaky")
+			}
+			return "done", nil
+		})
+		if err != nil || result != "done" {
+			t.Errorf("Expected success, got %q, %v", result, err)
+		}
+	})
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries without a summary, got %d", len(lines))
+	}
+	for i, line := range lines {
+		metadata := decodeEntry(t, line)["details"].(map[string]interface{})["metadata"].(map[string]interface{})
+		if metadata["attempt"] != fmt.Sprint(i+1) || metadata["maxAttempts"] != "3" {
+			t.Errorf("Unexpected attempt metadata %v", metadata)
+		}
+	}
+}
+
+func TestRetry_Exhausted(t *testing.T) {
+	lines := captureOutput(t, func() {
+		_, err := Retry(context.Background(), 2, func(ctx context.Context) (int, error) {
+			return 0, errors.New("down")
+		})
+		if err == nil || err.Error() != "down" {
+			t.Errorf("Expected last error, got %v", err)
+		}
+	})
+
+	entry := decodeEntry(t, lines[0])
+	if entry["level"] != "error" || entry["message"] != "Failed after 2 attempts: down" {
+		t.Errorf("Unexpected summary %s", lines[0])
+	}
+}
+
+func TestRetry_NoAttempts(t *testing.T) {
+	calls := 0
+	lines := captureOutput(t, func() {
+		_, err := Retry(context.Background(), 0, func(ctx context.Context) (int, error) {
+			calls++
+			return 0, errors.New("down")
+		})
+		if err == nil || err.Error() != "down" {
+			t.Errorf("Expected fn's error, got %v", err)
+		}
+	})
+
+	if calls != 1 || len(lines) != 1 {
+		t.Fatalf("Expected one attempt and a summary, got %d attempts, %d entries", calls, len(lines))
+	}
+	if entry := decodeEntry(t, lines[0]); entry["message"] != "Failed after 1 attempts: down" {
+		t.Errorf("Unexpected summary %s", lines[0])
+	}
+}
+
+func TestRetry_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	lines := captureOutput(t, func() {
+		_, err := Retry(ctx, 3, func(ctx context.Context) (int, error) {
+			calls++
+			return 0, ctx.Err()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected fn's error, got %v", err)
+		}
+	})
+
+	if calls != 1 || len(lines) != 1 {
+		t.Fatalf("Expected one attempt and a summary, got %d attempts, %d entries", calls, len(lines))
+	}
+	if entry := decodeEntry(t, lines[0]); entry["message"] != "Failed after 1 attempts: context canceled" {
+		t.Errorf("Unexpected summary %s", lines[0])
+	}
+}
+
 func TestLogger_ConvenienceFunctions(t *testing.T) {
 	ctx := context.Background()
 	logCtx := NewLogContext(LogContextData{
@@ -194,61 +597,1477 @@ func TestLogger_ConvenienceFunctions(t *testing.T) {
 	})
 }
 
-func BenchmarkLogger_NoContext(b *testing.B) {
-	ctx := context.Background()
+type recordingSink struct {
+	entries []LogOutput
+}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		Info(ctx, "Benchmark message")
-	}
+func (s *recordingSink) Write(output LogOutput) error {
+	s.entries = append(s.entries, output)
+	return nil
 }
 
-func BenchmarkLogger_WithContext(b *testing.B) {
-	ctx := context.Background()
-	logCtx := NewLogContext(LogContextData{
-		SessionID: "req-123",
-		Category:  "benchmark",
-	}).WithTags("api", "test", "benchmark").WithMetadata(map[string]string{
-		"userId":   "456",
-		"endpoint": "/api/test",
-		"method":   "GET",
+// lockedSink is a recordingSink for entries written concurrently.
+type lockedSink struct {
+	mu sync.Mutex
+	recordingSink
+}
+
+func (s *lockedSink) Write(output LogOutput) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recordingSink.Write(output)
+}
+
+type mockLogger struct {
+	calls   []LogLevel
+	emitted []LogOutput
+}
+
+func (m *mockLogger) Log(_ context.Context, level LogLevel, _ ...interface{}) {
+	m.calls = append(m.calls, level)
+}
+
+func (m *mockLogger) Emit(_ context.Context, output LogOutput) {
+	m.emitted = append(m.emitted, output)
+}
+
+func TestSetDefault(t *testing.T) {
+	mock := &mockLogger{}
+	SetDefault(mock)
+
+	lines := captureOutput(t, func() {
+		Info(context.Background(), "captured")
+		Error(context.Background(), "captured")
+		LogAccess(context.Background(), AccessRecord{Method: "GET", Path: "/", Status: 200})
 	})
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_, _ = WithLogContext(ctx, logCtx, func(ctx context.Context) (struct{}, error) {
-			Info(ctx, "Benchmark message")
+	if lines[0] != "" {
+		t.Errorf("Mock should receive all entries, got output %q", lines[0])
+	}
+	if len(mock.calls) != 2 || mock.calls[0] != LevelInfo || mock.calls[1] != LevelError {
+		t.Errorf("Unexpected calls %v", mock.calls)
+	}
+	if len(mock.emitted) != 1 {
+		t.Errorf("Expected access entry to be emitted, got %d", len(mock.emitted))
+	}
+	if Default() != mock {
+		t.Error("Default should return the replacement")
+	}
+
+	SetDefault(nil)
+	if Default() != Logger(std) {
+		t.Error("SetDefault(nil) should restore the standard logger")
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	var buf bytes.Buffer
+	extra := &recordingSink{}
+	l := New(
+		WithWriter(&buf),
+		WithEncoder(ConsoleEncoder{}),
+		WithSinks(extra),
+		WithLevel(LevelWarn),
+		WithHooks(func(ctx context.Context, output *LogOutput) {
+			output.Details["hooked"] = true
+		}),
+	)
+
+	l.Info(context.Background(), "filtered")
+	l.Warn(context.Background(), "kept")
+
+	if !strings.Contains(buf.String(), "WARN  kept") || strings.Contains(buf.String(), "filtered") {
+		t.Errorf("Unexpected writer output %q", buf.String())
+	}
+	if len(extra.entries) != 1 || extra.entries[0].Details["hooked"] != true {
+		t.Errorf("Expected hooked entry in extra sink, got %v", extra.entries)
+	}
+}
+
+func TestNew_WithClock(t *testing.T) {
+	var buf bytes.Buffer
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("AEDT", 11*3600))
+	l := New(WithWriter(&buf), WithClock(func() time.Time { return fixed }))
+
+	l.Info(context.Background(), "tick")
+
+	expected := `{"schemaVersion":2,"level":"info","message":"tick","details":{"timestamp":"2024-03-01T01:00:00Z"}}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestNew_WithTimestamps(t *testing.T) {
+	sink := &recordingSink{}
+	fixed := time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	zone := time.FixedZone("AEDT", 11*3600)
+	l := New(
+		WithSinks(sink),
+		WithClock(func() time.Time { return fixed }),
+		WithTimestamps(TimestampOptions{Location: zone, DateTimeFields: true}),
+	)
+
+	l.Info(context.Background(), "tick")
+
+	details := sink.entries[0].Details
+	if details["timestamp"] != "2024-03-01T12:00:00+11:00" || details["localDate"] != "2024-03-01" || details["localTime"] != "12:00:00" {
+		t.Errorf("Unexpected timestamp details %v", details)
+	}
+}
+
+func TestNew_WithCategoryLevel(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(
+		WithSinks(sink),
+		WithLevel(LevelInfo),
+		WithCategoryLevel("http", LevelWarn),
+		WithCategoryLevel("http.request.auth", LevelDebug),
+	)
+
+	base := NewLogContext(LogContextData{}).WithCategory("http")
+	for _, lc := range []*LogContext{
+		base,
+		base.WithSubCategory("request"),
+		base.WithSubCategory("request").WithSubCategory("auth"),
+		NewLogContext(LogContextData{}).WithCategory("https"),
+	} {
+		_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+			l.Debug(ctx, lc.data.Category)
+			l.Info(ctx, lc.data.Category)
 			return struct{}{}, nil
 		})
 	}
+
+	var got []string
+	for _, e := range sink.entries {
+		got = append(got, string(e.Level)+":"+e.Message.(string))
+	}
+	expected := "debug:http.request.auth,info:http.request.auth,info:https"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, ","))
+	}
 }
 
-func BenchmarkLogContext_Copy(b *testing.B) {
-	lc := NewLogContext(LogContextData{}).
-		WithTags("tag1", "tag2", "tag3").
-		WithMetadata(map[string]string{
-			"key1": "value1",
-			"key2": "value2",
-			"key3": "value3",
+func TestParseLevel(t *testing.T) {
+	for input, want := range map[string]LogLevel{"debug": LevelDebug, " INFO ": LevelInfo, "Warning": LevelWarn, "ERROR": LevelError} {
+		if got, err := ParseLevel(input); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+
+	var config struct{ Level, Unset LogLevel }
+	if err := json.Unmarshal([]byte(`{"level": "WARN", "unset": ""}`), &config); err != nil || config.Level != LevelWarn || config.Unset != "" {
+		t.Errorf("Expected levels to unmarshal through ParseLevel, got %+v, %v", config, err)
+	}
+	if raw, _ := json.Marshal(config); string(raw) != `{"Level":"warn","Unset":""}` {
+		t.Errorf("Expected levels to marshal as their names, got %s", raw)
+	}
+}
+
+func TestWithMinLevel(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithLevel(LevelWarn), WithCategoryLevel("db", LevelError))
+
+	verbose := NewLogContext(LogContextData{Category: "db"}).WithMinLevel(LevelDebug)
+	quiet := NewLogContext(LogContextData{}).Merge(NewLogContext(LogContextData{MinLevel: LevelError}))
+	for _, lc := range []*LogContext{verbose, quiet} {
+		_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+			l.Debug(ctx, "debug")
+			l.Warn(ctx, "warn")
+			return struct{}{}, nil
 		})
+	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_ = lc.WithCategory("test")
+	if len(sink.entries) != 2 || sink.entries[0].Message != "debug" || sink.entries[1].Message != "warn" {
+		t.Errorf("Expected the context level to replace the logger's filters, got %v", sink.entries)
 	}
 }
 
-func ExampleInfo() {
-	ctx := context.Background()
-	logCtx := NewLogContext(LogContextData{}).WithSessionID("req-123").
-		WithCategory("api").
-		WithTags("user-service").
-		WithMetadata(map[string]string{
-			"userId": "456",
+func TestWithUnsampledPolicy(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithUnsampledPolicy(UnsampledPolicy{}))
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0"
+	sampled := NewLogContext(LogContextData{}).WithTraceParent(traceparent + "1")
+	unsampled := NewLogContext(LogContextData{}).WithTraceParent(traceparent + "0")
+	if unsampled.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the trace ID from traceparent, got %q", unsampled.TraceID())
+	}
+	if s, known := NewLogContext(LogContextData{}).WithTraceParent("00-bad").TraceSampled(); known || s {
+		t.Error("Expected an invalid traceparent to be ignored")
+	}
+	for _, lc := range []*LogContext{sampled, unsampled, unsampled.WithMinLevel(LevelDebug)} {
+		_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+			l.Info(ctx, "info")
+			l.Warn(ctx, "warn")
+			return struct{}{}, nil
 		})
+	}
 
-	_, _ = WithLogContext(ctx, logCtx, func(ctx context.Context) (struct{}, error) {
-		Info(ctx, "Processing user request")
-		return struct{}{}, nil
+	var got []string
+	for _, entry := range sink.entries {
+		got = append(got, fmt.Sprint(entry.Message))
+	}
+	if strings.Join(got, ",") != "info,warn,warn,info,warn" {
+		t.Errorf("Expected info from the unsampled trace to be suppressed, got %v", got)
+	}
+
+	kept := 0
+	half := New(WithSinks(&recordingSink{}), WithUnsampledPolicy(UnsampledPolicy{Rate: 0.5}))
+	for i := 0; i < 1000; i++ {
+		ctx := context.WithValue(context.Background(), logContextKey,
+			NewLogContext(LogContextData{TraceID: fmt.Sprint(i)}).WithTraceSampled(false))
+		first := half.Enabled(ctx, LevelDebug)
+		if half.Enabled(ctx, LevelDebug) != first {
+			t.Fatal("Expected the same decision for every entry of a trace")
+		}
+		if first {
+			kept++
+		}
+	}
+	if kept < 400 || kept > 600 {
+		t.Errorf("Expected about half the traces kept, got %d of 1000", kept)
+	}
+}
+
+func TestSetSinks(t *testing.T) {
+	sink := &recordingSink{}
+	SetSinks(sink)
+	defer SetSinks(stdoutSink{})
+
+	lines := captureOutput(t, func() {
+		Warn(context.Background(), "to sink")
 	})
+
+	if lines[0] != "" {
+		t.Errorf("Default sink should be replaced, got %q", lines[0])
+	}
+	if len(sink.entries) != 1 || sink.entries[0].Message != "to sink" {
+		t.Errorf("Expected entry in sink, got %v", sink.entries)
+	}
+}
+
+func TestLogger_StructuredMessage(t *testing.T) {
+	type item struct {
+		SKU string `json:"sku"`
+		Qty int    `json:"qty"`
+	}
+	payload := struct {
+		OrderID string `json:"orderId"`
+		Items   []item `json:"items"`
+	}{"o-1", []item{{"A", 2}}}
+
+	lines := captureOutput(t, func() {
+		Info(context.Background(), payload)
+		Info(context.Background(), &payload)
+		Info(context.Background(), time.Duration(0))
+	})
+
+	for _, line := range lines[:2] {
+		entry := decodeEntry(t, line)
+		data, ok := entry["data"].(map[string]interface{})
+		if !ok || data["orderId"] != "o-1" || len(data["items"].([]interface{})) != 1 {
+			t.Errorf("Expected structured data, got %s", line)
+		}
+		if _, ok := entry["message"]; ok {
+			t.Errorf("Expected no message for a structured payload, got %s", line)
+		}
+	}
+	if decodeEntry(t, lines[2])["message"] != "0s" {
+		t.Errorf("Stringers should stay strings, got %s", lines[2])
+	}
+}
+
+func TestSetSchemaVersion(t *testing.T) {
+	if err := SetSchemaVersion(SchemaV1); err != nil {
+		t.Fatal(err)
+	}
+	defer SetSchemaVersion(LatestSchemaVersion)
+
+	lines := captureOutput(t, func() {
+		Info(context.Background(), []int{1, 2})
+	})
+	entry := decodeEntry(t, lines[0])
+	if entry["schemaVersion"] != float64(SchemaV1) || entry["message"] == nil {
+		t.Errorf("Expected the version 1 layout, got %s", lines[0])
+	}
+	if err := SetSchemaVersion(7); err == nil {
+		t.Error("Expected an error for an unknown schema version")
+	}
+}
+
+func TestSetMessageFormat_Legacy(t *testing.T) {
+	SetMessageFormat(MessageLegacy)
+	defer SetMessageFormat(MessageAndData)
+
+	lines := captureOutput(t, func() {
+		Info(context.Background(), map[string]int{"count": 3})
+	})
+
+	entry := decodeEntry(t, lines[0])
+	if message, ok := entry["message"].(map[string]interface{}); !ok || message["count"] != float64(3) {
+		t.Errorf("Expected structured message in legacy mode, got %s", lines[0])
+	}
+	if _, ok := entry["data"]; ok {
+		t.Errorf("Expected no data field in legacy mode, got %s", lines[0])
+	}
+}
+
+type rawPayload struct{ id int }
+
+func (p rawPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]int{"id": p.id})
+}
+
+func TestExtractMessageAndStack_Rendering(t *testing.T) {
+	message, _ := extractMessageAndStack("payload:", rawPayload{7})
+	if message != `payload:{"id":7}` {
+		t.Errorf("Expected marshaler rendered as JSON, got %q", message)
+	}
+
+	message, _ = extractMessageAndStack(1, 2, "three", 4)
+	if message != "1 2three4" {
+		t.Errorf("Expected fmt.Sprint spacing, got %q", message)
+	}
+
+	RegisterRenderer(func(v interface{}) (string, bool) {
+		if p, ok := v.(rawPayload); ok {
+			return fmt.Sprintf("payload#%d", p.id), true
+		}
+		return "", false
+	})
+	defer func() { renderers = nil }()
+
+	message, _ = extractMessageAndStack("got", rawPayload{7})
+	if message != "gotpayload#7" {
+		t.Errorf("Expected custom renderer, got %q", message)
+	}
+}
+
+type valueStringer struct{ name string }
+
+func (s valueStringer) String() string { return s.name }
+
+type valueError struct{ code int }
+
+func (e valueError) Error() string { return fmt.Sprintf("code %d", e.code) }
+
+func TestExtractMessageAndStack_NilPointers(t *testing.T) {
+	var stringer *valueStringer
+	var err *valueError
+	lines := captureOutput(t, func() {
+		Info(context.Background(), "value:", stringer)
+		Error(context.Background(), "failed:", err)
+		Error(context.Background(), err)
+	})
+
+	for i, want := range []string{"value:<nil>", "failed: <nil>", "<nil>"} {
+		if got := decodeEntry(t, lines[i])["message"]; got != want {
+			t.Errorf("Entry %d: expected %q, got %v", i, want, got)
+		}
+	}
+}
+
+func TestLogger_ErrorChain(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("query users: %w", fmt.Errorf("dial db: %w", root))
+
+	lines := captureOutput(t, func() {
+		Error(context.Background(), "Request failed", err)
+		Error(context.Background(), root)
+	})
+
+	chain := decodeEntry(t, lines[0])["details"].(map[string]interface{})["errorChain"]
+	expected := `["query users: dial db: connection refused","dial db: connection refused","connection refused"]`
+	if mustJSON(t, chain) != expected {
+		t.Errorf("Expected %s, got %s", expected, mustJSON(t, chain))
+	}
+	if _, ok := decodeEntry(t, lines[1])["details"].(map[string]interface{})["errorChain"]; ok {
+		t.Error("Unwrapped errors should not have a chain")
+	}
+}
+
+func TestLogger_JoinedErrors(t *testing.T) {
+	joined := errors.Join(
+		fmt.Errorf("validate name: %w", errors.New("empty")),
+		&net.DNSError{Err: "no such host", Name: "db"},
+		errors.Join(errors.New("a"), errors.New("b")),
+	)
+
+	lines := captureOutput(t, func() {
+		Error(context.Background(), "Batch failed", fmt.Errorf("save: %w", joined))
+	})
+
+	errs := decodeEntry(t, lines[0])["details"].(map[string]interface{})["errors"]
+	expected := `[` +
+		`{"errorChain":["validate name: empty","empty"],"message":"validate name: empty","type":"*fmt.wrapError"},` +
+		`{"message":"lookup db: no such host","type":"*net.DNSError"},` +
+		`{"errors":[{"message":"a","type":"*errors.errorString"},{"message":"b","type":"*errors.errorString"}],"message":"a\nb","type":"*errors.joinError"}` +
+		`]`
+	if mustJSON(t, errs) != expected {
+		t.Errorf("Expected %s, got %s", expected, mustJSON(t, errs))
+	}
+}
+
+func TestLimits_MaxDepth(t *testing.T) {
+	SetLimits(Limits{MaxDepth: 2})
+	defer SetLimits(Limits{})
+
+	lines := captureOutput(t, func() {
+		Info(context.Background(), map[string]interface{}{
+			"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}},
+		})
+	})
+
+	entry := decodeEntry(t, lines[0])
+	expected := map[string]interface{}{"a": map[string]interface{}{"b": maxDepthMarker}}
+	if got, _ := json.Marshal(entry["data"]); string(got) != mustJSON(t, expected) {
+		t.Errorf("Expected %s, got %s", mustJSON(t, expected), got)
+	}
+	if entry["details"].(map[string]interface{})["truncated"] != true {
+		t.Error("Expected truncated flag")
+	}
+}
+
+type treeNode struct {
+	Name   string      `json:"name"`
+	Parent *treeNode   `json:"parent,omitempty"`
+	Kids   []*treeNode `json:"kids,omitempty"`
+}
+
+func TestStructuredValue_CyclesAndDepth(t *testing.T) {
+	root := &treeNode{Name: "root"}
+	root.Kids = []*treeNode{{Name: "kid", Parent: root}}
+	loop := map[string]interface{}{"name": "loop"}
+	loop["self"] = loop
+	deep := map[string]interface{}{}
+	for i := 0; i < 100; i++ {
+		deep = map[string]interface{}{"next": deep}
+	}
+
+	sink := &recordingSink{}
+	l := New(WithSinks(sink))
+	for _, v := range []interface{}{root, loop, deep, struct{ C chan int }{}} {
+		l.Info(context.Background(), v)
+	}
+	l.Info(context.Background(), "with field", F("tree", root))
+
+	if len(sink.entries) != 5 {
+		t.Fatalf("Expected every entry emitted, got %d", len(sink.entries))
+	}
+	kid := sink.entries[0].Data.(map[string]interface{})["kids"].([]interface{})[0].(map[string]interface{})
+	if kid["parent"] != cycleMarker || sink.entries[0].Details["truncated"] != true {
+		t.Errorf("Expected the back reference marked as a cycle, got %v", sink.entries[0])
+	}
+	if sink.entries[1].Data.(map[string]interface{})["self"] != cycleMarker {
+		t.Errorf("Expected the self reference marked, got %v", sink.entries[1].Data)
+	}
+	levels := 0
+	for v := sink.entries[2].Data; v != maxDepthMarker; levels++ {
+		next, ok := v.(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected nesting past %d levels marked, got %v", defaultMaxStructuredDepth, v)
+		}
+		v = next["next"]
+	}
+	if levels != defaultMaxStructuredDepth {
+		t.Errorf("Expected %d levels kept, got %d", defaultMaxStructuredDepth, levels)
+	}
+	if sink.entries[3].Data.(map[string]interface{})["C"] != "[unsupported chan int]" {
+		t.Errorf("Expected the channel marked unsupported, got %v", sink.entries[3].Data)
+	}
+	for _, entry := range sink.entries {
+		if _, err := (JSONEncoder{}).Encode(entry); err != nil {
+			t.Errorf("Expected the entry to encode, got %v", err)
+		}
+	}
+
+	type embedded struct {
+		ID int `json:"id"`
+	}
+	type record struct {
+		embedded
+		Name    string    `json:"name,omitempty"`
+		Skip    string    `json:"-"`
+		When    time.Time `json:"when"`
+		Raw     []byte
+		private int
+	}
+	value := record{embedded: embedded{ID: 7}, Skip: "x", When: time.Unix(0, 0).UTC(), Raw: []byte("hi")}
+	w := &structWalker{maxDepth: defaultMaxStructuredDepth, path: map[visit]bool{}}
+	want, _ := structuredValue(value)
+	if got := w.walk(reflect.ValueOf(value), 1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the walker to match encoding/json: want %v, got %v", want, got)
+	}
+}
+
+func TestStructuredValue_LargeIntegers(t *testing.T) {
+	type ids struct {
+		ID   int64   `json:"id"`
+		Max  uint64  `json:"max"`
+		Rate float64 `json:"rate"`
+	}
+	value := ids{ID: 9007199254740993, Max: 18446744073709551615, Rate: 0.5}
+	expected := map[string]interface{}{"id": int64(9007199254740993), "max": uint64(18446744073709551615), "rate": 0.5}
+
+	got, _ := structuredValue(value)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	w := &structWalker{maxDepth: defaultMaxStructuredDepth, path: map[visit]bool{}}
+	if walked := w.walk(reflect.ValueOf(value), 1); !reflect.DeepEqual(walked, expected) {
+		t.Errorf("Expected the walker to keep integers exact, got %v", walked)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return string(b)
+}
+
+func TestLimits(t *testing.T) {
+	SetLimits(Limits{MaxMessageLength: 10, MaxMetadataValueLength: 4, MaxFields: 2})
+	defer SetLimits(Limits{})
+
+	logCtx := NewLogContext(LogContextData{}).WithMetadata(map[string]string{
+		"a": "short",
+		"b": "ok",
+		"c": "dropped",
+	})
+	lines := captureOutput(t, func() {
+		_, _ = WithLogContext(context.Background(), logCtx, func(ctx context.Context) (struct{}, error) {
+			Info(ctx, "héllo wörld, this is long")
+			Info(ctx, "fits")
+			return struct{}{}, nil
+		})
+	})
+
+	entry := decodeEntry(t, lines[0])
+	details := entry["details"].(map[string]interface{})
+	if entry["message"] != "héllo wö...[truncated 17 bytes]" {
+		t.Errorf("Unexpected message %q", entry["message"])
+	}
+	metadata := details["metadata"].(map[string]interface{})
+	if len(metadata) != 2 || metadata["a"] != "shor...[truncated 1 bytes]" || metadata["b"] != "ok" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if details["truncated"] != true {
+		t.Error("Expected truncated flag")
+	}
+
+	entry = decodeEntry(t, lines[1])
+	if entry["message"] != "fits" {
+		t.Errorf("Short message should be untouched, got %q", entry["message"])
+	}
+	if len(logCtx.data.Metadata) != 3 {
+		t.Error("Limits should not modify the LogContext")
+	}
+}
+
+func TestTenantSink(t *testing.T) {
+	dir := t.TempDir()
+	fallback := &recordingSink{}
+	sink := NewTenantSink(TenantFiles(dir, JSONEncoder{}), fallback)
+	l := New(WithSinks(sink))
+
+	for _, tenant := range []string{"acme", "globex", "acme", "../evil", "acme/eu", "acme_eu", ".", "_.", ""} {
+		lc := NewLogContext(LogContextData{}).WithTenantID(tenant)
+		_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+			l.Info(ctx, "for "+tenant)
+			return struct{}{}, nil
+		})
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	files := map[string]int{
+		"acme.log": 2, "globex.log": 1, "..%2Fevil.log": 1,
+		"acme%2Feu.log": 1, "acme_eu.log": 1, "..log": 1, "_..log": 1,
+	}
+	for file, expected := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Expected tenant file %s: %v", file, err)
+		}
+		if n := strings.Count(string(data), "\n"); n != expected {
+			t.Errorf("Expected %d entries in %s, got %d", expected, file, n)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != len(files) {
+		t.Errorf("Expected one file per tenant, got %d", len(entries))
+	}
+	if len(fallback.entries) != 1 {
+		t.Errorf("Expected untenanted entry in fallback, got %d", len(fallback.entries))
+	}
+}
+
+func TestLogAccess_JSON(t *testing.T) {
+	ctx := context.Background()
+	logCtx := NewLogContext(LogContextData{SessionID: "req-1"})
+
+	lines := captureOutput(t, func() {
+		_, _ = WithLogContext(ctx, logCtx, func(ctx context.Context) (struct{}, error) {
+			LogAccess(ctx, AccessRecord{
+				Method:    "GET",
+				Route:     "/users/{id}",
+				Path:      "/users/42",
+				Status:    200,
+				Bytes:     512,
+				Duration:  15 * time.Millisecond,
+				UserAgent: "curl/8.0",
+			})
+			return struct{}{}, nil
+		})
+	})
+
+	entry := decodeEntry(t, lines[0])
+	if entry["sessionId"] != "req-1" {
+		t.Errorf("Expected sessionId 'req-1', got %v", entry["sessionId"])
+	}
+	access := entry["details"].(map[string]interface{})["access"].(map[string]interface{})
+	if access["route"] != "/users/{id}" || access["status"] != float64(200) || access["durationMs"] != float64(15) {
+		t.Errorf("Unexpected access fields: %v", access)
+	}
+}
+
+func TestLogAccess_Common(t *testing.T) {
+	SetAccessLogFormat(AccessLogCombined)
+	defer SetAccessLogFormat(AccessLogJSON)
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	lines := captureOutput(t, func() {
+		LogAccess(context.Background(), AccessRecord{
+			Time:       at,
+			RemoteAddr: "10.0.0.1",
+			Method:     "POST",
+			Path:       "/orders",
+			Protocol:   "HTTP/1.1",
+			Status:     201,
+			Bytes:      12,
+			UserAgent:  "curl/8.0",
+		})
+	})
+
+	expected := `10.0.0.1 - - [01/Mar/2024:12:00:00 +0000] "POST /orders HTTP/1.1" 201 12 "-" "curl/8.0"`
+	if lines[0] != expected {
+		t.Errorf("Expected %q, got %q", expected, lines[0])
+	}
+}
+
+func TestLogAccess_Output(t *testing.T) {
+	var buf bytes.Buffer
+	SetAccessLogOutput(&buf)
+	defer SetAccessLogOutput(nil)
+
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithHooks(func(_ context.Context, output *LogOutput) {
+		if access, ok := output.Details["access"].(map[string]interface{}); ok {
+			access["user"] = "anon"
+		}
+	}))
+	if err := l.ApplyConfig(Config{Redact: []string{"token"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	previous := Default()
+	SetDefault(l)
+	defer SetDefault(previous)
+
+	ctx := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{
+		Metadata: map[string]string{"token": "secret"},
+	}))
+	record := AccessRecord{Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), User: "alice", Method: "GET", Path: "/", Status: 200}
+	LogAccess(ctx, record)
+	SetAccessLogFormat(AccessLogCommon)
+	LogAccess(ctx, record)
+	SetAccessLogFormat(AccessLogJSON)
+
+	if len(sink.entries) != 0 {
+		t.Errorf("Expected access entries only in the access output, got %v", sink.entries)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 access lines, got %q", buf.String())
+	}
+	details := decodeEntry(t, lines[0])["details"].(map[string]interface{})
+	if details["timestamp"] != "2024-03-01T12:00:00Z" {
+		t.Errorf("Expected the record's time, got %v", details["timestamp"])
+	}
+	if token := details["metadata"].(map[string]interface{})["token"]; token != redactedValue {
+		t.Errorf("Expected the token redacted, got %v", token)
+	}
+	if user := details["access"].(map[string]interface{})["user"]; user != "anon" {
+		t.Errorf("Expected the hook applied, got %v", user)
+	}
+	expected := `- - anon [01/Mar/2024:12:00:00 +0000] "GET / HTTP/1.1" 200 -`
+	if lines[1] != expected {
+		t.Errorf("Expected %q, got %q", expected, lines[1])
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		Info(r.Context(), "handling")
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	lines := captureOutput(t, func() {
+		Middleware(mux).ServeHTTP(httptest.NewRecorder(), req)
+	})
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d", len(lines))
+	}
+	app, access := decodeEntry(t, lines[0]), decodeEntry(t, lines[1])
+	if app["sessionId"] != "abc" || access["sessionId"] != "abc" {
+		t.Error("Request ID should propagate to all entries")
+	}
+	fields := access["details"].(map[string]interface{})["access"].(map[string]interface{})
+	if fields["status"] != float64(http.StatusTeapot) || fields["bytes"] != float64(5) {
+		t.Errorf("Unexpected access fields: %v", fields)
+	}
+	if fields["route"] != "GET /users/{id}" {
+		t.Errorf("Expected route pattern, got %v", fields["route"])
+	}
+}
+
+func TestMiddleware_FlushAndHijack(t *testing.T) {
+	sink := &lockedSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	body := BodyLogger(BodyLogOptions{Enabled: func(*http.Request) bool { return true }})
+	rec := httptest.NewRecorder()
+	Middleware(body(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+	}))).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if !rec.Flushed {
+		t.Error("Expected Flush to reach the underlying writer")
+	}
+
+	srv := httptest.NewServer(Middleware(body(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Expected Hijack to reach the server, got %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	}))))
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected the hijacked response, got %s", resp.Status)
+	}
+
+	waitFor(t, func() bool {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return len(sink.entries) >= 6
+	})
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	access := sink.entries[len(sink.entries)-1].Details["access"].(map[string]interface{})
+	if access["status"] != http.StatusSwitchingProtocols {
+		t.Errorf("Expected the upgrade logged as 101, got %v", access["status"])
+	}
+}
+
+func TestMiddleware_RouteResolver(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	// A router that, like gorilla/mux, matches before its middleware runs
+	// and exposes the route through the request.
+	type routeCtxKey struct{}
+	router := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeCtxKey{}, "/orders/{id}")))
+		})
+	}
+	SetRouteResolver(func(r *http.Request) string {
+		route, _ := r.Context().Value(routeCtxKey{}).(string)
+		return route
+	})
+	defer SetRouteResolver(nil)
+
+	router(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Info(r.Context(), "handling")
+	}))).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+	for _, entry := range sink.entries {
+		metadata := entry.Details["metadata"].(map[string]string)
+		if metadata["route"] != "/orders/{id}" || metadata["path"] != "/orders/42" {
+			t.Errorf("Expected the route next to the raw path, got %v", metadata)
+		}
+	}
+
+	// Routers that only know the route inside their handlers report it.
+	sink.entries = nil
+	Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetRoute(r.Context(), "/items/:id")
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/9", nil))
+	access := sink.entries[0]
+	if access.Details["metadata"].(map[string]string)["route"] != "/items/:id" ||
+		access.Details["access"].(map[string]interface{})["route"] != "/items/:id" {
+		t.Errorf("Expected the reported route on the access entry, got %v", access.Details)
+	}
+}
+
+func TestMiddleware_CanonicalLine(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		Canonical(r.Context()).Set("plan", "pro")
+		Canonical(r.Context()).Add("db_queries", 2)
+		Canonical(r.Context()).Add("db_queries", 1)
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Middleware(mux)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/7", nil))
+	if len(sink.entries) != 2 || sink.entries[1].Message != "canonical-log-line" {
+		t.Fatalf("Expected the access entry and the canonical line, got %v", sink.entries)
+	}
+	line := sink.entries[1]
+	fields := line.Details["fields"].(map[string]interface{})
+	if fields["plan"] != "pro" || fields["db_queries"] != int64(3) || fields["route"] != "GET /orders/{id}" ||
+		fields["status"] != http.StatusOK || line.SessionID != sink.entries[0].SessionID {
+		t.Errorf("Unexpected canonical line: %+v", line)
+	}
+
+	sink.entries = nil
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if len(sink.entries) != 1 {
+		t.Errorf("Expected no canonical line for a request that set no fields, got %v", sink.entries)
+	}
+}
+
+func TestMiddleware_LogLevelHeader(t *testing.T) {
+	previous := Default()
+	SetDefault(New(WithLevel(LevelWarn)))
+	defer SetDefault(previous)
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Debug(r.Context(), "details")
+	}))
+	serve := func() []string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(LogLevelHeader, "debug")
+		req.Header.Set("X-Support-Token", "secret")
+		return captureOutput(t, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+
+	if lines := serve(); lines[0] != "" {
+		t.Errorf("Expected the header to be ignored without a policy, got %v", lines)
+	}
+
+	SetLogLevelHeaderPolicy(func(r *http.Request) bool { return r.Header.Get("X-Support-Token") == "secret" })
+	defer SetLogLevelHeaderPolicy(nil)
+	if lines := serve(); len(lines) != 2 || decodeEntry(t, lines[0])["message"] != "details" {
+		t.Errorf("Expected debug and access entries, got %v", lines)
+	}
+}
+
+func TestBodyLogger(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+
+	handler := Middleware(BodyLogger(BodyLogOptions{
+		MaxBytes: 16,
+		Enabled:  func(r *http.Request) bool { return r.Header.Get("X-Debug-Body") != "" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("received " + string(body)))
+	})))
+	serve := func(enabled bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"id": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		if enabled {
+			req.Header.Set("X-Debug-Body", "1")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if serve(false); len(sink.entries) != 1 {
+		t.Fatalf("Expected only the access entry, got %v", sink.entries)
+	}
+	sink.entries = nil
+	if rec := serve(true); rec.Body.String() != `received {"id": 1}` {
+		t.Errorf("Expected the handler to read the whole body, got %q", rec.Body.String())
+	}
+	if len(sink.entries) != 3 {
+		t.Fatalf("Expected request, response and access entries, got %v", sink.entries)
+	}
+	request := sink.entries[0].Details["http"].(map[string]interface{})
+	if request["body"] != "{\n  \"id\": 1\n}" || request["headers"].(map[string]string)["Authorization"] != "[REDACTED]" {
+		t.Errorf("Expected indented JSON and a redacted header, got %v", request)
+	}
+	response := sink.entries[1].Details["http"].(map[string]interface{})
+	if response["body"] != "received {\"id\": …" || response["truncated"] != true || response["bodyBytes"] != int64(18) {
+		t.Errorf("Expected a truncated response body, got %v", response)
+	}
+
+	if got := renderBody("image/png", []byte{0x89, 'P', 'N', 'G'}, false, 2048); got != "<binary image/png, 2.0 KiB>" {
+		t.Errorf("Expected binary bodies summarized, got %q", got)
+	}
+}
+
+func discardOutput(b *testing.B) {
+	original := stdout
+	stdout = io.Discard
+	b.Cleanup(func() { stdout = original })
+}
+
+func BenchmarkLogger_NoContext(b *testing.B) {
+	discardOutput(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Info(ctx, "Benchmark message")
+	}
+}
+
+func BenchmarkLogger_WithContext(b *testing.B) {
+	discardOutput(b)
+	ctx := context.Background()
+	logCtx := NewLogContext(LogContextData{
+		SessionID: "req-123",
+		Category:  "benchmark",
+	}).WithTags("api", "test", "benchmark").WithMetadata(map[string]string{
+		"userId":   "456",
+		"endpoint": "/api/test",
+		"method":   "GET",
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = WithLogContext(ctx, logCtx, func(ctx context.Context) (struct{}, error) {
+			Info(ctx, "Benchmark message")
+			return struct{}{}, nil
+		})
+	}
+}
+
+func BenchmarkLogContext_Copy(b *testing.B) {
+	lc := NewLogContext(LogContextData{}).
+		WithTags("tag1", "tag2", "tag3").
+		WithMetadata(map[string]string{
+			"key1": "value1",
+			"key2": "value2",
+			"key3": "value3",
+		})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = lc.WithCategory("test")
+	}
+}
+
+func BenchmarkLogContext_WithTags(b *testing.B) {
+	lc := NewLogContext(LogContextData{}).
+		WithTags("tag1", "tag2", "tag3").
+		WithMetadata(map[string]string{
+			"key1": "value1",
+			"key2": "value2",
+			"key3": "value3",
+		})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = lc.WithCategory("test").WithSessionID("req").WithTags("tag4")
+	}
+}
+
+var benchLogContext *LogContext
+
+func BenchmarkLogContext_Chain(b *testing.B) {
+	lc := NewLogContext(LogContextData{SessionID: "req"})
+
+	b.ReportAllocs()
+	for b.Loop() {
+		benchLogContext = lc.WithTags("api", "checkout").
+			WithMetadata(map[string]string{"region": "eu", "route": "/orders"}).
+			WithTags("db").
+			WithMetadata(map[string]string{"table": "orders"})
+	}
+}
+
+func ExampleInfo() {
+	ctx := context.Background()
+	logCtx := NewLogContext(LogContextData{}).WithSessionID("req-123").
+		WithCategory("api").
+		WithTags("user-service").
+		WithMetadata(map[string]string{
+			"userId": "456",
+		})
+
+	_, _ = WithLogContext(ctx, logCtx, func(ctx context.Context) (struct{}, error) {
+		Info(ctx, "Processing user request")
+		return struct{}{}, nil
+	})
+}
+
+func TestWriter(t *testing.T) {
+	lc := NewLogContext(LogContextData{SessionID: "s1"})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+
+	lines := captureOutput(t, func() {
+		w := Writer(ctx, LevelWarn, "exec.git")
+		fmt.Fprint(w, "fatal: not a ")
+		fmt.Fprint(w, "repository\r\n\nhint: run git init")
+		w.Close()
+	})
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", lines)
+	}
+	first, second := decodeEntry(t, lines[0]), decodeEntry(t, lines[1])
+	if first["message"] != "fatal: not a repository" || second["message"] != "hint: run git init" {
+		t.Errorf("Unexpected messages: %v / %v", first["message"], second["message"])
+	}
+	details := first["details"].(map[string]interface{})
+	if first["level"] != "warn" || first["sessionId"] != "s1" || details["category"] != "exec.git" {
+		t.Errorf("Expected level, context and category on each entry, got %s", lines[0])
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	previous := Default()
+	rec := &lockedSink{}
+	SetDefault(New(WithSinks(rec)))
+	defer SetDefault(previous)
+
+	lc := NewLogContext(LogContextData{SessionID: "s1", Category: "deploy"})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+	err := RunCommand(ctx, exec.Command("sh", "-c", "echo out; echo oops >&2; exit 3"))
+
+	if err == nil {
+		t.Fatal("Expected the exit error")
+	}
+	var got []string
+	for _, e := range rec.entries {
+		got = append(got, string(e.Level)+":"+e.Message.(string))
+		if e.SessionID != "s1" || e.Details["category"] != "deploy" || !reflect.DeepEqual(e.Details["tags"], []string{"exec", "sh"}) {
+			t.Errorf("Expected the caller's context and category with exec tags, got %+v", e)
+		}
+	}
+	sort.Strings(got[:2])
+	expected := "debug:out,error:oops,error:Command failed: exit status 3"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, ","))
+	}
+	if metadata := rec.entries[2].Details["metadata"].(map[string]string); metadata["exitCode"] != "3" {
+		t.Errorf("Expected exit code metadata, got %v", metadata)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	sink := &lockedSink{}
+	l := New(WithSinks(sink), WithLevel(LevelInfo))
+
+	ctx, group := Group(context.Background())
+	l.Info(ctx, "step 1")
+	l.Debug(ctx, "filtered")
+	innerCtx, inner := Group(ctx)
+	l.Info(innerCtx, "step 2")
+	inner.Close()
+	l.Info(context.Background(), "unrelated")
+
+	if len(sink.entries) != 1 || sink.entries[0].Message != "unrelated" {
+		t.Fatalf("Expected grouped entries to be held back, got %v", sink.entries)
+	}
+	group.Close()
+	l.Info(ctx, "after close")
+
+	var got []string
+	for _, e := range sink.entries {
+		got = append(got, e.Message.(string))
+	}
+	if strings.Join(got, ",") != "unrelated,step 1,step 2,after close" {
+		t.Errorf("Unexpected order: %v", got)
+	}
+}
+
+func TestGroup_Concurrent(t *testing.T) {
+	sink := &lockedSink{}
+	l := New(WithSinks(sink))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			ctx, group := Group(context.Background())
+			defer group.Close()
+			for step := 0; step < 5; step++ {
+				l.Info(ctx, fmt.Sprint(id))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < len(sink.entries); i += 5 {
+		for _, e := range sink.entries[i : i+5] {
+			if e.Message != sink.entries[i].Message {
+				t.Fatalf("Expected each group's entries to be contiguous at %d", i)
+			}
+		}
+	}
+}
+
+func TestWriterSink_ConcurrentLoggers(t *testing.T) {
+	var buf bytes.Buffer // not safe for concurrent use on its own
+	loggers := []*StandardLogger{New(WithWriter(&buf)), New(WithWriter(&buf))}
+	large := strings.Repeat("x", 64<<10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(l *StandardLogger) {
+			defer wg.Done()
+			l.Info(context.Background(), large)
+		}(loggers[i%2])
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("Expected 20 whole entries, got %d lines", len(lines))
+	}
+	for _, line := range lines {
+		if entry := decodeEntry(t, line); entry["message"] != large {
+			t.Fatal("Expected each entry intact")
+		}
+	}
+}
+
+func TestBindGoroutine(t *testing.T) {
+	lc := NewLogContext(LogContextData{SessionID: "s1"})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+
+	unbind := BindGoroutine(ctx)
+	bound := GetLogContext(context.Background()).data.SessionID
+	other := make(chan string)
+	go func() { other <- GetLogContext(context.Background()).data.SessionID }()
+	otherSession := <-other
+	cleared := GetLogContext(ClearLogContext(context.Background()))
+	unbind()
+
+	if bound != "s1" {
+		t.Errorf("Expected the bound context without a ctx, got %q", bound)
+	}
+	if otherSession != "" {
+		t.Errorf("Expected other goroutines unaffected, got %q", otherSession)
+	}
+	if GetLogContext(context.Background()) != emptyLogContext {
+		t.Error("Expected unbind to remove the binding")
+	}
+	if cleared != emptyLogContext {
+		t.Error("Expected an explicit context to win over the binding")
+	}
+}
+
+func TestDumpActiveContexts(t *testing.T) {
+	TrackActiveContexts(true)
+	defer TrackActiveContexts(false)
+
+	var dump bytes.Buffer
+	lc := NewLogContext(LogContextData{SessionID: "s1", Category: "jobs"})
+	_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, DumpActiveContexts(&dump)
+	})
+
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected one active scope, got %q", dump.String())
+	}
+	entry := decodeEntry(t, lines[0])
+	if entry["sessionId"] != "s1" || entry["operationId"] == nil || entry["runningFor"] == nil {
+		t.Errorf("Unexpected dump %s", lines[0])
+	}
+
+	dump.Reset()
+	DumpActiveContexts(&dump)
+	if dump.Len() != 0 {
+		t.Errorf("Expected no scopes after return, got %q", dump.String())
+	}
+}
+
+func TestOnceAndEveryN(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink)))
+	defer SetDefault(previous)
+	defer ResetOccurrences()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		Once(ctx, "once").Warn("deprecated")
+		EveryN(ctx, "every", 2).Info("item", errors.New("skipped"))
+	}
+	ResetOccurrences("once")
+	Once(ctx, "once").Warn("deprecated")
+
+	var got []string
+	for _, entry := range sink.entries {
+		got = append(got, fmt.Sprint(entry.Message, entry.Details["fields"]))
+	}
+	expected := []string{
+		"deprecated<nil>",
+		"item skippedmap[occurrences:1]",
+		"item skippedmap[occurrences:3]",
+		"item skippedmap[occurrences:5]",
+		"deprecated<nil>",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestWithRecentEntries(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithLevel(LevelInfo), WithRecentEntries(2))
+	ctx := context.Background()
+
+	l.Info(ctx, "one")
+	l.Debug(ctx, "two")
+	l.Error(ctx, "three")
+
+	if len(sink.entries) != 2 {
+		t.Fatalf("Expected the debug entry to be filtered, got %d entries", len(sink.entries))
+	}
+	recent := l.Recent()
+	if len(recent) != 2 || recent[0].Message != "two" || recent[1].Message != "three" {
+		t.Fatalf("Unexpected recent entries %+v", recent)
+	}
+	if recent[0].Details["filtered"] != true || recent[0].Details["timestamp"] == nil {
+		t.Errorf("Expected filtered entry to be marked and timestamped, got %v", recent[0].Details)
+	}
+
+	var dump bytes.Buffer
+	if err := l.DumpRecent(&dump); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	if len(lines) != 2 || decodeEntry(t, lines[1])["message"] != "three" {
+		t.Errorf("Unexpected dump %q", dump.String())
+	}
+}
+
+func TestWithRecentEntries_Redacted(t *testing.T) {
+	l := New(WithSinks(&recordingSink{}), WithLevel(LevelInfo), WithRecentEntries(4))
+	if err := l.ApplyConfig(Config{Level: LevelInfo, Redact: []string{"password"}}); err != nil {
+		t.Fatal(err)
+	}
+	lc := NewLogContext(LogContextData{Metadata: map[string]string{"password": "hunter2"}})
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+	l.Debug(ctx, "connecting")
+	l.Info(ctx, "connected")
+
+	rec := httptest.NewRecorder()
+	RecentHandler(l).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); strings.Contains(body, "hunter2") || strings.Count(body, redactedValue) != 2 {
+		t.Errorf("Expected both recent entries redacted, got %s", body)
+	}
+
+	// Keys added later are masked in entries kept before.
+	l.Debug(context.Background(), "retrying", F("token", "abc"))
+	if err := l.ApplyConfig(Config{Level: LevelInfo, Redact: []string{"password", "token"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Recent()[2].Details["fields"].(map[string]interface{})["token"]; got != redactedValue {
+		t.Errorf("Expected the current configuration to apply, got %v", got)
+	}
+}
+
+func TestRouterSink(t *testing.T) {
+	audit, pager, file := &recordingSink{}, &recordingSink{}, &recordingSink{}
+	router := NewRouterSink(nil,
+		Route{Category: "audit", Sinks: []Sink{audit}},
+		Route{Levels: []LogLevel{LevelError}, Sinks: []Sink{pager, file}},
+		Route{Levels: []LogLevel{LevelDebug, LevelInfo}, Sinks: []Sink{file}},
+	)
+	l := New(WithSinks(router))
+
+	ctx := context.Background()
+	auditCtx := context.WithValue(ctx, logContextKey, NewLogContext(LogContextData{Category: "audit.login"}))
+	l.Error(auditCtx, "login denied")
+	l.Error(ctx, "boom")
+	l.Debug(ctx, "details")
+	l.Warn(ctx, "unrouted")
+
+	if got := messages(audit.entries); len(got) != 1 || got[0] != "login denied" {
+		t.Errorf("audit got %v", got)
+	}
+	if got := messages(pager.entries); len(got) != 1 || got[0] != "boom" {
+		t.Errorf("pager got %v", got)
+	}
+	if got := messages(file.entries); len(got) != 2 || got[0] != "boom" || got[1] != "details" {
+		t.Errorf("file got %v", got)
+	}
+}
+
+func TestRouterSink_Flush(t *testing.T) {
+	routed, fallback := &flushingSink{}, &flushingSink{}
+	failing := &failingFlushSink{err: errors.New("disk full")}
+	router := NewRouterSink(fallback,
+		Route{Category: "audit", Sinks: []Sink{routed, failing}},
+		Route{Sinks: []Sink{routed}},
+	)
+	tenants := NewTenantSink(func(string) (Sink, error) { return &flushingSink{}, nil }, router)
+	if err := tenants.Flush(); err == nil || err.Error() != "disk full" {
+		t.Errorf("Expected the wrapped sink's error, got %v", err)
+	}
+	if routed.flushes != 1 || fallback.flushes != 1 {
+		t.Errorf("Expected each nested sink flushed once, got %d and %d", routed.flushes, fallback.flushes)
+	}
+}
+
+type failingFlushSink struct {
+	recordingSink
+	err error
+}
+
+func (s *failingFlushSink) Flush() error { return s.err }
+
+func TestRetention(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithHooks(Retention("30d",
+		RetentionRule{Category: "audit", Class: "7y"},
+		RetentionRule{Tags: []string{"verbose"}, Class: "3d"},
+	)))
+
+	for _, lc := range []*LogContext{
+		NewLogContext(LogContextData{Category: "audit.login"}),
+		NewLogContext(LogContextData{}).WithTags("verbose"),
+		NewLogContext(LogContextData{Category: "http"}),
+	} {
+		l.Info(context.WithValue(context.Background(), logContextKey, lc), "entry")
+	}
+
+	for i, want := range []string{"7y", "3d", "30d"} {
+		if got := sink.entries[i].Details["retention"]; got != want {
+			t.Errorf("Entry %d: expected retention %q, got %v", i, want, got)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink))
+	started := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	l.Info(context.Background(), "Upload done", DurationField("upload", 1500*time.Millisecond),
+		BytesField("size", 3<<20), TimeField("started", started), F("retries", 2))
+
+	entry := sink.entries[0]
+	if entry.Message != "Upload done" {
+		t.Errorf("Expected fields kept out of the message, got %q", entry.Message)
+	}
+	fields := entry.Details["fields"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"upload_ms":       1500.0,
+		"upload":          "1.5s",
+		"size_bytes":      int64(3 << 20),
+		"size":            "3.0 MiB",
+		"started_unix_ms": started.UnixMilli(),
+		"started":         "2024-03-01T12:00:00Z",
+		"retries":         2,
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected %v, got %v", expected, fields)
+	}
+}
+
+type throttledError struct{}
+
+func (throttledError) Error() string      { return "throttled" }
+func (throttledError) ErrorClass() string { return ErrorClassRetryable }
+
+func TestErrorClass(t *testing.T) {
+	errBadInput := errors.New("bad input")
+	SetErrorClassifier(func(err error) string {
+		if errors.Is(err, errBadInput) {
+			return ErrorClassClient
+		}
+		return ""
+	})
+	defer SetErrorClassifier(nil)
+
+	sink := &recordingSink{}
+	l := New(WithSinks(sink))
+	ctx := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{}).WithErrorClass("dependency"))
+
+	l.Error(ctx, "Call failed:", fmt.Errorf("wrapped: %w", throttledError{}))
+	l.Error(ctx, "Rejected:", errBadInput)
+	l.Error(ctx, "Lookup failed:", errors.New("timeout"))
+	l.Error(context.Background(), "Crashed")
+	l.Error(ctx, map[string]interface{}{"orderId": 7})
+	l.Error(context.Background(), map[string]interface{}{"orderId": 8})
+	l.Warn(ctx, "Not an error entry")
+
+	for i, want := range []interface{}{ErrorClassRetryable, ErrorClassClient, "dependency", ErrorClassInternal, "dependency", ErrorClassInternal, nil} {
+		if got := sink.entries[i].Details["errorClass"]; got != want {
+			t.Errorf("Entry %d: expected error class %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestEvent(t *testing.T) {
+	logs, events := &recordingSink{}, &recordingSink{}
+	l := New(WithSinks(logs), WithEventSinks(events), WithLevel(LevelError))
+	ctx := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{SessionID: "s1"}))
+
+	l.Event(ctx, "order.placed", F("orderId", "o-1"), F("total", 99.5))
+	l.Error(ctx, "Not an event")
+
+	if len(events.entries) != 1 || len(logs.entries) != 1 {
+		t.Fatalf("Expected the event in the event sink only, got %d events and %d logs", len(events.entries), len(logs.entries))
+	}
+	event := events.entries[0]
+	if event.Event != "order.placed" || event.Message != nil || event.SessionID != "s1" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if fields := event.Details["fields"].(map[string]interface{}); fields["orderId"] != "o-1" || fields["total"] != 99.5 {
+		t.Errorf("Unexpected event fields %v", fields)
+	}
 }
@@ -0,0 +1,126 @@
+// Package loggertest captures entries from the logger package and compares
+// them against golden files.
+package loggertest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+var update = flag.Bool("loggertest.update", false, "rewrite golden files with the captured entries")
+
+// FixedTime is the clock reading of every entry captured by Install.
+var FixedTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Recorder is a Sink that keeps every entry in memory.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []logger.LogOutput
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Write(output logger.LogOutput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, output)
+	return nil
+}
+
+// Entries returns the captured entries in emission order.
+func (r *Recorder) Entries() []logger.LogOutput {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]logger.LogOutput(nil), r.entries...)
+}
+
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Install makes a deterministic logger, recording into the returned Recorder,
+// the default for the rest of the test. Timestamps come from FixedTime. opts
+// are applied after the recorder and clock, so they can override them.
+func Install(t testing.TB, opts ...logger.Option) *Recorder {
+	t.Helper()
+	rec := NewRecorder()
+	base := []logger.Option{
+		logger.WithSinks(rec),
+		logger.WithClock(func() time.Time { return FixedTime }),
+	}
+	previous := logger.Default()
+	logger.SetDefault(logger.New(append(base, opts...)...))
+	t.Cleanup(func() { logger.SetDefault(previous) })
+	return rec
+}
+
+// JSON renders the captured entries as newline-delimited JSON with sorted
+// keys. Volatile values are made stable: operation IDs are replaced by
+// "op-1", "op-2", ... in order of first appearance.
+func (r *Recorder) JSON() ([]byte, error) {
+	ids := map[string]string{}
+	stable := func(id string) string {
+		if id == "" {
+			return ""
+		}
+		if _, ok := ids[id]; !ok {
+			ids[id] = fmt.Sprintf("op-%d", len(ids)+1)
+		}
+		return ids[id]
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range r.Entries() {
+		entry.OperationID = stable(entry.OperationID)
+		entry.ParentOperationID = stable(entry.ParentOperationID)
+
+		sorted, err := logger.JSONEncoder{SortKeys: true}.Encode(entry)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(sorted)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// AssertGolden compares the captured entries with the golden file at path
+// (relative to the test's package, usually under testdata/). Run the tests
+// with -loggertest.update to rewrite it.
+func AssertGolden(t testing.TB, r *Recorder, path string) {
+	t.Helper()
+	got, err := r.JSON()
+	if err != nil {
+		t.Fatalf("loggertest: encode entries: %v", err)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("loggertest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("loggertest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("loggertest: read golden file (run with -loggertest.update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("loggertest: entries differ from %s\n--- got\n%s--- want\n%s", path, got, want)
+	}
+}
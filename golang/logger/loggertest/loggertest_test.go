@@ -0,0 +1,76 @@
+package loggertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+func TestAssertGolden(t *testing.T) {
+	rec := Install(t)
+
+	ctx := context.Background()
+	logCtx := logger.NewLogContext(logger.LogContextData{SessionID: "req-1"}).
+		WithCategory("orders").
+		WithTags("checkout", "api").
+		WithMetadata(map[string]string{"orderId": "o-1", "userId": "42"})
+
+	_, _ = logger.WithLogContext(ctx, logCtx, func(ctx context.Context) (struct{}, error) {
+		logger.Info(ctx, "Placing order")
+		nested := logger.GetLogContext(ctx).WithTags("payments")
+		_, _ = logger.WithLogContext(ctx, nested, func(ctx context.Context) (struct{}, error) {
+			logger.Error(ctx, "Charge failed", errors.New("card declined"))
+			return struct{}{}, nil
+		})
+		return struct{}{}, nil
+	})
+
+	AssertGolden(t, rec, "testdata/order.golden")
+}
+
+func TestInstall_RestoresDefault(t *testing.T) {
+	previous := logger.Default()
+	t.Run("installed", func(t *testing.T) {
+		Install(t)
+		if logger.Default() == previous {
+			t.Error("Install should replace the default logger")
+		}
+	})
+	if logger.Default() != previous {
+		t.Error("Install should restore the default logger on cleanup")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	rec := Install(t)
+
+	ctx := context.Background()
+	for _, order := range []string{"1", "2"} {
+		logCtx := logger.NewLogContext(logger.LogContextData{}).
+			WithCategory("orders.checkout").
+			WithTags("payments").
+			WithMetadata(map[string]string{"orderId": order})
+		_, _ = logger.WithLogContext(ctx, logCtx, func(ctx context.Context) (struct{}, error) {
+			logger.Info(ctx, "Charging order", order)
+			logger.Error(ctx, "Charge failed", errors.New("card declined"))
+			return struct{}{}, nil
+		})
+	}
+
+	payments := rec.Query().Tag("payments").Category("orders")
+	if n := payments.Count(); n != 4 {
+		t.Errorf("Expected 4 payment entries, got %d", n)
+	}
+	errs := payments.Level(logger.LevelError).MetadataEquals("orderId", "1").Entries()
+	if len(errs) != 1 || errs[0].Message != "Charge failed card declined" {
+		t.Errorf("Expected the failure for order 1, got %v", errs)
+	}
+	if first, ok := payments.MessageContains("Charging").First(); !ok || first.Message != "Charging order1" {
+		t.Errorf("Expected the first charge, got %v", first)
+	}
+	if rec.Query().Tag("refunds").Exists() {
+		t.Error("Expected no refund entries")
+	}
+}
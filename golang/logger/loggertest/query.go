@@ -0,0 +1,135 @@
+package loggertest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+// Query selects captured entries by chaining conditions, all of which must
+// hold:
+//
+//	errs := rec.Query().Level(logger.LevelError).Tag("payments").MetadataEquals("orderId", "1").Entries()
+//
+// Each method returns a new Query, so a partial query can be reused.
+type Query struct {
+	rec        *Recorder
+	conditions []func(logger.LogOutput) bool
+}
+
+// Query starts a query over the entries captured so far and later.
+func (r *Recorder) Query() Query {
+	return Query{rec: r}
+}
+
+// Where adds an arbitrary condition.
+func (q Query) Where(cond func(logger.LogOutput) bool) Query {
+	return Query{rec: q.rec, conditions: append(slices.Clip(q.conditions), cond)}
+}
+
+// Level matches entries at any of levels.
+func (q Query) Level(levels ...logger.LogLevel) Query {
+	return q.Where(func(e logger.LogOutput) bool { return slices.Contains(levels, e.Level) })
+}
+
+// MinLevel matches entries at level or above.
+func (q Query) MinLevel(level logger.LogLevel) Query {
+	return q.Where(func(e logger.LogOutput) bool { return e.Level.AtLeast(level) })
+}
+
+// Tag matches entries carrying every tag.
+func (q Query) Tag(tags ...string) Query {
+	return q.Where(func(e logger.LogOutput) bool {
+		entryTags, _ := e.Details["tags"].([]string)
+		for _, tag := range tags {
+			if !slices.Contains(entryTags, tag) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Category matches entries in category or nested below it.
+func (q Query) Category(category string) Query {
+	return q.Where(func(e logger.LogOutput) bool {
+		entryCategory, _ := e.Details["category"].(string)
+		return logger.CategoryHasPrefix(entryCategory, category)
+	})
+}
+
+func (q Query) Session(id string) Query {
+	return q.Where(func(e logger.LogOutput) bool { return e.SessionID == id })
+}
+
+func (q Query) Event(name string) Query {
+	return q.Where(func(e logger.LogOutput) bool { return e.Event == name })
+}
+
+// MetadataEquals matches entries whose metadata key holds value. Collected
+// keys (logger.MetadataCollect) match on any of their values.
+func (q Query) MetadataEquals(key, value string) Query {
+	return q.Where(func(e logger.LogOutput) bool {
+		switch metadata := e.Details["metadata"].(type) {
+		case map[string]string:
+			v, ok := metadata[key]
+			return ok && v == value
+		case map[string]interface{}:
+			switch v := metadata[key].(type) {
+			case string:
+				return v == value
+			case []string:
+				return slices.Contains(v, value)
+			}
+		}
+		return false
+	})
+}
+
+// MessageContains matches entries whose message contains substr.
+func (q Query) MessageContains(substr string) Query {
+	return q.Where(func(e logger.LogOutput) bool {
+		return e.Message != nil && strings.Contains(fmt.Sprint(e.Message), substr)
+	})
+}
+
+// Entries returns the matching entries in emission order.
+func (q Query) Entries() []logger.LogOutput {
+	var matches []logger.LogOutput
+	for _, entry := range q.rec.Entries() {
+		if q.matches(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+func (q Query) Count() int {
+	return len(q.Entries())
+}
+
+func (q Query) Exists() bool {
+	_, ok := q.First()
+	return ok
+}
+
+// First returns the earliest matching entry.
+func (q Query) First() (logger.LogOutput, bool) {
+	for _, entry := range q.rec.Entries() {
+		if q.matches(entry) {
+			return entry, true
+		}
+	}
+	return logger.LogOutput{}, false
+}
+
+func (q Query) matches(entry logger.LogOutput) bool {
+	for _, cond := range q.conditions {
+		if !cond(entry) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package logreader
+
+import (
+	"slices"
+	"time"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+// Filter selects entries.
+type Filter func(Entry) bool
+
+// Match reports whether entry passes every filter.
+func Match(entry Entry, filters ...Filter) bool {
+	for _, filter := range filters {
+		if !filter(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// Session selects the entries of a session.
+func Session(id string) Filter {
+	return func(e Entry) bool { return e.SessionID == id }
+}
+
+// Operation selects the entries of an operation.
+func Operation(id string) Filter {
+	return func(e Entry) bool { return e.OperationID == id }
+}
+
+// MinLevel selects entries at level or above.
+func MinLevel(level logger.LogLevel) Filter {
+	return func(e Entry) bool { return e.Level.AtLeast(level) }
+}
+
+// Levels selects entries at exactly one of levels.
+func Levels(levels ...logger.LogLevel) Filter {
+	return func(e Entry) bool { return slices.Contains(levels, e.Level) }
+}
+
+// Between selects entries timestamped in [from, to). A zero bound is open.
+// Entries without a timestamp never match.
+func Between(from, to time.Time) Filter {
+	return func(e Entry) bool {
+		if e.Time.IsZero() {
+			return false
+		}
+		return (from.IsZero() || !e.Time.Before(from)) && (to.IsZero() || e.Time.Before(to))
+	}
+}
+
+// Category selects entries in category or nested below it.
+func Category(category string) Filter {
+	return func(e Entry) bool { return logger.CategoryHasPrefix(e.Category(), category) }
+}
+
+// Tag selects entries carrying every tag.
+func Tag(tags ...string) Filter {
+	return func(e Entry) bool {
+		for _, tag := range tags {
+			if !slices.Contains(e.Tags(), tag) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Metadata selects entries whose metadata key holds value.
+func Metadata(key, value string) Filter {
+	return func(e Entry) bool {
+		v, ok := e.Metadata()[key]
+		return ok && v == value
+	}
+}
@@ -0,0 +1,180 @@
+// Package logreader parses the logger package's JSON output back into
+// entries, for tools that post-process logs.
+package logreader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+// maxLineSize bounds a single entry; longer lines fail with bufio.ErrTooLong.
+const maxLineSize = 16 << 20
+
+// Entry is a parsed log line.
+type Entry struct {
+	logger.LogOutput
+	// Line is the 1-based line number the entry was read from.
+	Line int
+	// Time is details.timestamp, or zero when missing or unparsable.
+	Time time.Time
+}
+
+// Category returns details.category.
+func (e Entry) Category() string {
+	category, _ := e.Details["category"].(string)
+	return category
+}
+
+// Tags returns details.tags.
+func (e Entry) Tags() []string {
+	tags, _ := e.Details["tags"].([]string)
+	return tags
+}
+
+// Metadata returns details.metadata. Keys collected under
+// logger.MetadataCollect hold their latest value.
+func (e Entry) Metadata() map[string]string {
+	raw, _ := e.Details["metadata"].(map[string]interface{})
+	if len(raw) == 0 {
+		return nil
+	}
+	metadata := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			metadata[k] = v
+		case []interface{}:
+			if len(v) > 0 {
+				metadata[k] = fmt.Sprint(v[len(v)-1])
+			}
+		default:
+			metadata[k] = fmt.Sprint(v)
+		}
+	}
+	return metadata
+}
+
+// SyntaxError reports a line that is not a JSON log entry.
+type SyntaxError struct {
+	Line int
+	Err  error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("logreader: line %d: %v", e.Line, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses one JSON entry.
+func Parse(line []byte) (Entry, error) {
+	var entry Entry
+	if err := json.Unmarshal(line, &entry.LogOutput); err != nil {
+		return Entry{}, err
+	}
+	if entry.Level == "" {
+		return Entry{}, fmt.Errorf("missing level")
+	}
+	if entry.Details == nil {
+		entry.Details = make(map[string]interface{})
+	}
+	if list, ok := entry.Details["tags"].([]interface{}); ok {
+		tags := make([]string, 0, len(list))
+		for _, tag := range list {
+			if s, ok := tag.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+		entry.Details["tags"] = tags
+	}
+	if ts, ok := entry.Details["timestamp"].(string); ok {
+		entry.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	return entry, nil
+}
+
+// Reader reads entries from newline-delimited JSON. Blank lines are skipped.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &Reader{scanner: scanner}
+}
+
+// Next returns the next entry, io.EOF after the last one, or a *SyntaxError
+// for a malformed line, after which reading can continue.
+func (r *Reader) Next() (Entry, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		entry, err := Parse(line)
+		if err != nil {
+			return Entry{}, &SyntaxError{Line: r.line, Err: err}
+		}
+		entry.Line = r.line
+		return entry, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Entry{}, err
+	}
+	return Entry{}, io.EOF
+}
+
+// All iterates over the entries matching every filter. Malformed lines are
+// yielded as *SyntaxError and reading continues; a read error ends the
+// iteration after being yielded.
+func (r *Reader) All(filters ...Filter) iter.Seq2[Entry, error] {
+	return func(yield func(Entry, error) bool) {
+		for {
+			entry, err := r.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				if _, ok := err.(*SyntaxError); !ok {
+					yield(Entry{}, err)
+					return
+				}
+				if !yield(Entry{}, err) {
+					return
+				}
+				continue
+			}
+			if Match(entry, filters...) && !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ReadAll returns the entries of r matching every filter, skipping malformed
+// lines.
+func ReadAll(r io.Reader, filters ...Filter) ([]Entry, error) {
+	var entries []Entry
+	for entry, err := range NewReader(r).All(filters...) {
+		if _, ok := err.(*SyntaxError); ok {
+			continue
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,96 @@
+package logreader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/peterzzshi/context-based-logger/logger"
+)
+
+func TestReader_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := logger.New(logger.WithWriter(&buf), logger.WithClock(func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}))
+
+	lc := logger.NewLogContext(logger.LogContextData{}).
+		WithSessionID("req-1").
+		WithCategory("http.request").
+		WithTags("api").
+		WithMetadata(map[string]string{"orderId": "42"})
+	_, _ = logger.WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+		l.Info(ctx, "started")
+		l.Error(ctx, "failed", errors.New("boom"))
+		return struct{}{}, nil
+	})
+	l.Warn(context.Background(), "unrelated")
+	buf.WriteString("not json\n\n")
+
+	r := NewReader(bytes.NewReader(buf.Bytes()))
+	first, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Message != "started" || first.SessionID != "req-1" || first.Line != 1 ||
+		first.Category() != "http.request" || !reflect.DeepEqual(first.Tags(), []string{"api"}) ||
+		first.Metadata()["orderId"] != "42" || !first.Time.Equal(time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected entry %+v", first)
+	}
+
+	var syntaxErr *SyntaxError
+	var messages []string
+	for entry, err := range r.All() {
+		if errors.As(err, &syntaxErr) {
+			continue
+		}
+		messages = append(messages, entry.Message.(string))
+	}
+	if strings.Join(messages, ",") != "failed boom,unrelated" || syntaxErr == nil || syntaxErr.Line != 4 {
+		t.Errorf("Expected the remaining entries and a syntax error on line 4, got %v, %v", messages, syntaxErr)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+
+	filtered, err := ReadAll(bytes.NewReader(buf.Bytes()),
+		Session("req-1"),
+		MinLevel(logger.LevelWarn),
+		Between(time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC), time.Time{}),
+	)
+	if err != nil || len(filtered) != 1 || filtered[0].Level != logger.LevelError {
+		t.Errorf("Expected the session's error entry, got %v, %v", filtered, err)
+	}
+}
+
+func TestTimelines(t *testing.T) {
+	input := `{"level":"info","message":"request","sessionId":"s1","operationId":"a","details":{"timestamp":"2024-01-01T00:00:00Z"}}
+{"level":"info","message":"other","sessionId":"s2","operationId":"x","details":{"timestamp":"2024-01-01T00:00:01Z"}}
+{"level":"debug","message":"query","sessionId":"s1","operationId":"b","parentOperationId":"a","details":{"timestamp":"2024-01-01T00:00:02Z"}}
+{"level":"info","message":"orphan","sessionId":"s1","operationId":"c","parentOperationId":"gone","details":{"timestamp":"2024-01-01T00:00:05Z"}}
+{"level":"info","message":"no session","details":{}}
+`
+	entries, err := ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	timelines := Timelines(entries)
+	if len(timelines) != 2 || timelines[0].ID != "s1" || timelines[1].ID != "s2" {
+		t.Fatalf("Expected sessions s1 and s2, got %v", timelines)
+	}
+	s1 := timelines[0]
+	if s1.Duration() != 5*time.Second || len(s1.Scopes) != 2 || s1.Scopes[1].ID != "c" {
+		t.Fatalf("Expected two outermost operations over 5s, got %+v", s1)
+	}
+	a := s1.Scopes[0]
+	if a.ID != "a" || len(a.Children) != 1 || a.Children[0].ID != "b" || a.Duration() != 2*time.Second {
+		t.Errorf("Expected b nested in a spanning 2s, got %+v", a)
+	}
+}
@@ -0,0 +1,121 @@
+package logreader
+
+import (
+	"sort"
+	"time"
+)
+
+// Timeline is a session's entries, nested by operation.
+type Timeline struct {
+	ID string
+	// Scopes are the session's outermost operations, in start order.
+	// Entries logged outside any operation are grouped under one with an
+	// empty ID.
+	Scopes     []*Scope
+	Start, End time.Time
+}
+
+// Duration is the time between the session's first and last timestamped
+// entries.
+func (s *Timeline) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Scope is one operation, the entries of a WithLogContext scope and its
+// nested operations.
+type Scope struct {
+	ID, ParentID string
+	Entries      []Entry
+	Children     []*Scope
+	// Start and End span the timestamped entries of the operation and its
+	// children.
+	Start, End time.Time
+}
+
+func (o *Scope) Duration() time.Duration {
+	return o.End.Sub(o.Start)
+}
+
+// Timelines groups entries by session ID, in order of each session's
+// first entry, and nests each session's operations by parent operation ID.
+// An operation whose parent has no entries becomes outermost. Entries
+// without a session ID are left out.
+func Timelines(entries []Entry) []*Timeline {
+	var sessions []*Timeline
+	bySession := map[string][]Entry{}
+	for _, entry := range entries {
+		if entry.SessionID == "" {
+			continue
+		}
+		if _, ok := bySession[entry.SessionID]; !ok {
+			sessions = append(sessions, &Timeline{ID: entry.SessionID})
+		}
+		bySession[entry.SessionID] = append(bySession[entry.SessionID], entry)
+	}
+	for _, session := range sessions {
+		session.Scopes = nestOperations(bySession[session.ID])
+		for _, op := range session.Scopes {
+			session.Start, session.End = widen(session.Start, session.End, op.Start, op.End)
+		}
+	}
+	return sessions
+}
+
+func nestOperations(entries []Entry) []*Scope {
+	var order []*Scope
+	byID := map[string]*Scope{}
+	for _, entry := range entries {
+		op, ok := byID[entry.OperationID]
+		if !ok {
+			op = &Scope{ID: entry.OperationID, ParentID: entry.ParentOperationID}
+			byID[entry.OperationID] = op
+			order = append(order, op)
+		}
+		op.Entries = append(op.Entries, entry)
+		op.Start, op.End = widen(op.Start, op.End, entry.Time, entry.Time)
+	}
+
+	var roots []*Scope
+	for _, op := range order {
+		parent, ok := byID[op.ParentID]
+		if op.ID == "" || !ok || parent == op {
+			roots = append(roots, op)
+			continue
+		}
+		parent.Children = append(parent.Children, op)
+	}
+	for _, root := range roots {
+		settle(root)
+	}
+	sortByStart(roots)
+	return roots
+}
+
+// settle widens each operation's span to cover its children and orders the
+// children by start time.
+func settle(op *Scope) {
+	for _, child := range op.Children {
+		settle(child)
+		op.Start, op.End = widen(op.Start, op.End, child.Start, child.End)
+	}
+	sortByStart(op.Children)
+}
+
+func sortByStart(ops []*Scope) {
+	sort.SliceStable(ops, func(i, j int) bool {
+		if ops[i].Start.IsZero() || ops[j].Start.IsZero() {
+			return false
+		}
+		return ops[i].Start.Before(ops[j].Start)
+	})
+}
+
+func widen(start, end, from, to time.Time) (time.Time, time.Time) {
+	if !from.IsZero() && (start.IsZero() || from.Before(start)) {
+		start = from
+	}
+	if !to.IsZero() && (end.IsZero() || to.After(end)) {
+		end = to
+	}
+	return start, end
+}
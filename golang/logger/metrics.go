@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type OTLPMetricsOptions struct {
+	// Endpoint is the OTLP/HTTP metrics URL. Defaults to
+	// http://localhost:4318/v1/metrics.
+	Endpoint string
+	// Header is sent with each export, e.g. for authorization.
+	Header http.Header
+	// Resource attributes identify the process, e.g. "service.name".
+	Resource map[string]string
+	// Tag marks log entries to convert in addition to events. Defaults to
+	// "metric".
+	Tag string
+	// Buckets are the histogram bucket bounds. Defaults to bounds suited to
+	// millisecond durations, 5 to 10000.
+	Buckets []float64
+	// ExportInterval defaults to 10s.
+	ExportInterval time.Duration
+	// Client performs the export. Defaults to a client with a 10s timeout.
+	Client *http.Client
+}
+
+// OTLPMetricsSink turns entries into OpenTelemetry metrics, so one
+// instrumentation call yields both a log and a metric. Each Event, and each
+// entry tagged Tag, increments a counter named after the event (or the
+// entry's category, "log" without one) and records its numeric fields
+// (details.fields) in histograms named <name>.<field>. Metrics are exported
+// with cumulative temporality over OTLP/HTTP JSON; other entries are ignored,
+// so the sink is added alongside the usual ones.
+type OTLPMetricsSink struct {
+	opts  OTLPMetricsOptions
+	start time.Time
+
+	mu         sync.Mutex
+	counters   map[string]int64
+	histograms map[string]*histogram
+	err        error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type histogram struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+var defaultMetricBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+func NewOTLPMetricsSink(opts OTLPMetricsOptions) *OTLPMetricsSink {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "http://localhost:4318/v1/metrics"
+	}
+	if opts.Tag == "" {
+		opts.Tag = "metric"
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = defaultMetricBuckets
+	}
+	if opts.ExportInterval <= 0 {
+		opts.ExportInterval = 10 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &OTLPMetricsSink{
+		opts:       opts,
+		start:      time.Now(),
+		counters:   make(map[string]int64),
+		histograms: make(map[string]*histogram),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write reports the error of a previous periodic export, if any.
+func (s *OTLPMetricsSink) Write(output LogOutput) error {
+	name, ok := s.metricName(output)
+	if !ok {
+		return nil
+	}
+	fields, _ := output.Details["fields"].(map[string]interface{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[name]++
+	for key, value := range fields {
+		if v, ok := metricValue(value); ok {
+			s.observe(name+"."+key, v)
+		}
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+func (s *OTLPMetricsSink) metricName(output LogOutput) (string, bool) {
+	if output.Event != "" {
+		return output.Event, true
+	}
+	tags, _ := output.Details["tags"].([]string)
+	for _, tag := range tags {
+		if tag == s.opts.Tag {
+			if category, _ := output.Details["category"].(string); category != "" {
+				return category, true
+			}
+			return "log", true
+		}
+	}
+	return "", false
+}
+
+func (s *OTLPMetricsSink) observe(name string, v float64) {
+	h := s.histograms[name]
+	if h == nil {
+		h = &histogram{buckets: make([]uint64, len(s.opts.Buckets)+1)}
+		s.histograms[name] = h
+	}
+	h.count++
+	h.sum += v
+	h.buckets[sort.SearchFloat64s(s.opts.Buckets, v)]++
+}
+
+func metricValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (s *OTLPMetricsSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.ExportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Flush exports the current metric values now.
+func (s *OTLPMetricsSink) Flush() error {
+	s.mu.Lock()
+	metrics := s.metricsLocked(time.Now())
+	s.mu.Unlock()
+	if len(metrics) == 0 {
+		return nil
+	}
+	err := otlpExport(s.opts.Client, s.opts.Endpoint, s.opts.Header, map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource": otlpResource(s.opts.Resource),
+			"scopeMetrics": []interface{}{map[string]interface{}{
+				"scope":   otlpScope,
+				"metrics": metrics,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("otlp: export of %d metrics: %w", len(metrics), err)
+	}
+	return nil
+}
+
+// Close stops periodic exports and exports the final values.
+func (s *OTLPMetricsSink) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.Flush()
+}
+
+// otlpCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const otlpCumulative = 2
+
+func (s *OTLPMetricsSink) metricsLocked(now time.Time) []interface{} {
+	start := strconv.FormatInt(s.start.UnixNano(), 10)
+	end := strconv.FormatInt(now.UnixNano(), 10)
+	var metrics []interface{}
+	for _, name := range sortedKeys(s.counters) {
+		metrics = append(metrics, map[string]interface{}{
+			"name": name,
+			"sum": map[string]interface{}{
+				"aggregationTemporality": otlpCumulative,
+				"isMonotonic":            true,
+				"dataPoints": []interface{}{map[string]interface{}{
+					"startTimeUnixNano": start,
+					"timeUnixNano":      end,
+					"asInt":             strconv.FormatInt(s.counters[name], 10),
+				}},
+			},
+		})
+	}
+	for _, name := range sortedKeys(s.histograms) {
+		h := s.histograms[name]
+		buckets := make([]string, len(h.buckets))
+		for i, n := range h.buckets {
+			buckets[i] = strconv.FormatUint(n, 10)
+		}
+		metrics = append(metrics, map[string]interface{}{
+			"name": name,
+			"histogram": map[string]interface{}{
+				"aggregationTemporality": otlpCumulative,
+				"dataPoints": []interface{}{map[string]interface{}{
+					"startTimeUnixNano": start,
+					"timeUnixNano":      end,
+					"count":             strconv.FormatUint(h.count, 10),
+					"sum":               h.sum,
+					"bucketCounts":      buckets,
+					"explicitBounds":    s.opts.Buckets,
+				}},
+			},
+		})
+	}
+	return metrics
+}
@@ -0,0 +1,223 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// LogLevelHeader asks Middleware to log the request at the given level
+	// (e.g. "debug"). It is ignored unless SetLogLevelHeaderPolicy allows it.
+	LogLevelHeader = "X-Log-Level"
+)
+
+var levelHeaderPolicy atomic.Pointer[func(r *http.Request) bool]
+
+// SetLogLevelHeaderPolicy lets Middleware honor LogLevelHeader on requests
+// for which allow returns true, e.g. those from an internal network or
+// carrying a support token. Honoring it unconditionally would let any client
+// turn on debug logging. Passing nil disables the header again.
+func SetLogLevelHeaderPolicy(allow func(r *http.Request) bool) {
+	if allow == nil {
+		levelHeaderPolicy.Store(nil)
+		return
+	}
+	levelHeaderPolicy.Store(&allow)
+}
+
+// requestMinLevel returns the level requested through LogLevelHeader when
+// the policy allows it.
+func requestMinLevel(r *http.Request) LogLevel {
+	level, err := ParseLevel(r.Header.Get(LogLevelHeader))
+	if err != nil {
+		return ""
+	}
+	if allow := levelHeaderPolicy.Load(); allow == nil || !(*allow)(r) {
+		return ""
+	}
+	return level
+}
+
+// RouteResolver returns the route pattern a third-party router matched r to,
+// such as "/users/{id}", or "" when it does not know it.
+type RouteResolver func(r *http.Request) string
+
+var routeResolver atomic.Pointer[RouteResolver]
+
+// SetRouteResolver lets Middleware find the route pattern of requests served
+// by a router other than http.ServeMux, so entries can be aggregated by
+// route rather than by raw path. Register Middleware with the router's own
+// middleware chain so the router has matched the request when the resolver
+// runs:
+//
+//	logger.SetRouteResolver(func(r *http.Request) string {
+//		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+//			return rctx.RoutePattern()
+//		}
+//		return ""
+//	})
+//	r.Use(logger.Middleware)
+//
+// With gorilla/mux, use the template of mux.CurrentRoute(r). Passing nil
+// removes the resolver.
+func SetRouteResolver(resolve RouteResolver) {
+	if resolve == nil {
+		routeResolver.Store(nil)
+		return
+	}
+	routeResolver.Store(&resolve)
+}
+
+type routeKey struct{}
+
+// requestRoute holds the route pattern a handler reported with SetRoute.
+type requestRoute struct {
+	mu      sync.Mutex
+	pattern string
+}
+
+// SetRoute reports the route pattern matched for the request Middleware is
+// serving in ctx, for routers whose pattern is not reachable from the
+// *http.Request, such as Echo's c.Path(). It takes precedence over the
+// resolver.
+func SetRoute(ctx context.Context, pattern string) {
+	if route, ok := ctx.Value(routeKey{}).(*requestRoute); ok {
+		route.mu.Lock()
+		route.pattern = pattern
+		route.mu.Unlock()
+	}
+}
+
+// resolveRoute returns the route pattern of r: the one reported with
+// SetRoute, then the resolver's, then http.ServeMux's.
+func resolveRoute(r *http.Request) string {
+	if route, ok := r.Context().Value(routeKey{}).(*requestRoute); ok {
+		route.mu.Lock()
+		pattern := route.pattern
+		route.mu.Unlock()
+		if pattern != "" {
+			return pattern
+		}
+	}
+	if resolve := routeResolver.Load(); resolve != nil {
+		if pattern := (*resolve)(r); pattern != "" {
+			return pattern
+		}
+	}
+	return r.Pattern
+}
+
+// Middleware scopes a LogContext to each request, taking the trace from a
+// traceparent header, and emits one access log entry when the handler returns.
+// The route pattern, when known, is added as "route" metadata next to the
+// raw path; see SetRouteResolver. Handlers that set fields on the request's
+// Canonical line get it emitted after the access entry, with the method,
+// route, status and duration added.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		sessionID := r.Header.Get(RequestIDHeader)
+		if sessionID == "" {
+			sessionID = newID()
+		}
+		logCtx := GetLogContext(r.Context()).
+			WithSessionID(sessionID).
+			WithCategory("http").
+			WithMetadata(map[string]string{
+				"method": r.Method,
+				"path":   r.URL.Path,
+			})
+		if traceparent := r.Header.Get(TraceParentHeader); traceparent != "" {
+			logCtx = logCtx.WithTraceParent(traceparent)
+		}
+		if level := requestMinLevel(r); level != "" {
+			logCtx = logCtx.WithMinLevel(level)
+		}
+		if route := resolveRoute(r); route != "" {
+			logCtx = logCtx.WithMetadata(map[string]string{"route": route})
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		parent, line := WithCanonicalLine(context.WithValue(r.Context(), routeKey{}, &requestRoute{}))
+		_, _ = WithLogContext(parent, logCtx, func(ctx context.Context) (struct{}, error) {
+			req := r.WithContext(ctx)
+			next.ServeHTTP(rec, req)
+			route := resolveRoute(req)
+			if route != "" && GetLogContext(ctx).data.Metadata["route"] != route {
+				ctx = context.WithValue(ctx, logContextKey, GetLogContext(ctx).WithMetadata(map[string]string{"route": route}))
+			}
+			LogAccess(ctx, AccessRecord{
+				Time:       start,
+				RemoteAddr: r.RemoteAddr,
+				Method:     r.Method,
+				Route:      route,
+				Path:       r.URL.RequestURI(),
+				Protocol:   r.Proto,
+				Status:     rec.status,
+				Bytes:      rec.bytes,
+				Duration:   time.Since(start),
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+			})
+			line.setDefaults(map[string]interface{}{
+				"method":     r.Method,
+				"route":      route,
+				"status":     rec.status,
+				"durationMs": float64(time.Since(start)) / float64(time.Millisecond),
+			})
+			line.Emit(ctx)
+			return struct{}{}, nil
+		})
+	})
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends buffered data to the client, for streaming handlers that
+// assert http.Flusher.
+func (r *responseRecorder) Flush() {
+	r.wroteHeader = true
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack hands the connection to the handler, e.g. for a WebSocket upgrade,
+// which is logged with status 101 unless a status was written.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil && !r.wroteHeader {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+type NetworkSinkOptions struct {
+	// Encoder renders each entry; JSONEncoder when nil. Entries are sent one
+	// per datagram on UDP and newline delimited on TCP, except for binary
+	// encoders, which are self-delimiting.
+	Encoder Encoder
+	// WriteTimeout bounds each write. Defaults to 5s.
+	WriteTimeout time.Duration
+	// BufferSize is the number of entries held in memory while the
+	// destination is unreachable. Defaults to 1000.
+	BufferSize int
+	// MaxBackoff caps the delay between reconnect attempts. Defaults to 30s.
+	MaxBackoff time.Duration
+}
+
+// NetworkSink ships entries over TCP or UDP (e.g. to a Logstash or Fluent Bit
+// tcp/udp input). While the destination is down, entries are kept in a ring
+// buffer, dropping the oldest when full, and replayed once it reconnects.
+type NetworkSink struct {
+	network string
+	address string
+	opts    NetworkSinkOptions
+	dial    func(network, address string, timeout time.Duration) (net.Conn, error)
+
+	mu        sync.Mutex
+	conn      net.Conn
+	pending   *ring[[]byte]
+	backoff   time.Duration
+	nextDial  time.Time
+	dropped   uint64
+	lastError error
+}
+
+func NewNetworkSink(network, address string, opts NetworkSinkOptions) *NetworkSink {
+	if opts.Encoder == nil {
+		opts.Encoder = JSONEncoder{}
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 5 * time.Second
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	return &NetworkSink{
+		network: network,
+		address: address,
+		opts:    opts,
+		dial:    net.DialTimeout,
+		pending: newRing[[]byte](opts.BufferSize),
+	}
+}
+
+// Write never blocks longer than one dial plus one write timeout. Entries
+// that cannot be delivered are buffered rather than reported as errors, so a
+// FailoverSink never fails over from a NetworkSink; SinkState reports the
+// last delivery error instead.
+func (s *NetworkSink) Write(output LogOutput) error {
+	encoded, err := s.opts.Encoder.Encode(output)
+	if err != nil {
+		return err
+	}
+	if s.network != "udp" {
+		encoded = frame(s.opts.Encoder, encoded)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending.push(encoded) {
+		s.dropped++
+	}
+	s.flushLocked()
+	return nil
+}
+
+// Flush attempts to deliver buffered entries immediately.
+func (s *NetworkSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+	return s.lastError
+}
+
+// Buffered reports how many entries are waiting for the destination.
+func (s *NetworkSink) Buffered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending.len()
+}
+
+// Dropped reports how many entries were discarded because the buffer was full.
+func (s *NetworkSink) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *NetworkSink) SinkState() SinkState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := SinkState{
+		Name:     s.network + "://" + s.address,
+		Buffered: s.pending.len(),
+		Dropped:  s.dropped,
+	}
+	if s.lastError != nil {
+		state.LastError = s.lastError.Error()
+	}
+	return state
+}
+
+func (s *NetworkSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *NetworkSink) flushLocked() {
+	if s.conn == nil && !s.connectLocked() {
+		return
+	}
+	for {
+		entry, ok := s.pending.peek()
+		if !ok {
+			return
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.opts.WriteTimeout))
+		if _, err := s.conn.Write(entry); err != nil {
+			s.disconnectLocked(err)
+			return
+		}
+		s.pending.pop()
+	}
+}
+
+func (s *NetworkSink) connectLocked() bool {
+	if time.Now().Before(s.nextDial) {
+		return false
+	}
+	conn, err := s.dial(s.network, s.address, s.opts.WriteTimeout)
+	if err != nil {
+		s.disconnectLocked(err)
+		return false
+	}
+	s.conn = conn
+	s.backoff = 0
+	s.lastError = nil
+	return true
+}
+
+func (s *NetworkSink) disconnectLocked(err error) {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	s.lastError = err
+	if s.backoff == 0 {
+		s.backoff = 100 * time.Millisecond
+	} else if s.backoff *= 2; s.backoff > s.opts.MaxBackoff {
+		s.backoff = s.opts.MaxBackoff
+	}
+	s.nextDial = time.Now().Add(s.backoff)
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNetworkSink_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer listener.Close()
+
+	sink := NewNetworkSink("tcp", listener.Addr().String(), NetworkSinkOptions{})
+	defer sink.Close()
+
+	if err := sink.Write(LogOutput{Level: LevelInfo, Message: "shipped"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if line != `{"level":"info","message":"shipped","details":null}`+"\n" {
+		t.Errorf("Unexpected line %q", line)
+	}
+}
+
+func TestNetworkSink_BuffersDuringOutage(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	up := false
+	sink := NewNetworkSink("tcp", "collector:5170", NetworkSinkOptions{BufferSize: 2})
+	sink.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		if !up {
+			return nil, errors.New("connection refused")
+		}
+		return client, nil
+	}
+
+	for _, msg := range []string{"one", "two", "three"} {
+		_ = sink.Write(LogOutput{Level: LevelInfo, Message: msg})
+		sink.nextDial = time.Time{}
+	}
+	if sink.Buffered() != 2 || sink.Dropped() != 1 {
+		t.Fatalf("Expected 2 buffered and 1 dropped, got %d and %d", sink.Buffered(), sink.Dropped())
+	}
+
+	up = true
+	received := make(chan string, 2)
+	go func() {
+		reader := bufio.NewReader(server)
+		for i := 0; i < 2; i++ {
+			line, _ := reader.ReadString('\n')
+			received <- line
+		}
+	}()
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, msg := range []string{"two", "three"} {
+		expected := `{"level":"info","message":"` + msg + `","details":null}` + "\n"
+		if line := <-received; line != expected {
+			t.Errorf("Expected %q, got %q", expected, line)
+		}
+	}
+	if sink.Buffered() != 0 {
+		t.Errorf("Expected buffer to drain, got %d", sink.Buffered())
+	}
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// occurrences counts calls per Once/EveryN key. Keys are meant to be fixed
+// strings naming a call site, so the registry stays small.
+var occurrences sync.Map // string -> *atomic.Uint64
+
+func occurrence(key string) uint64 {
+	counter, ok := occurrences.Load(key)
+	if !ok {
+		counter, _ = occurrences.LoadOrStore(key, new(atomic.Uint64))
+	}
+	return counter.(*atomic.Uint64).Add(1)
+}
+
+// ResetOccurrences forgets the counts behind Once and EveryN for keys, or
+// for every key when none are given.
+func ResetOccurrences(keys ...string) {
+	if len(keys) == 0 {
+		occurrences.Clear()
+		return
+	}
+	for _, key := range keys {
+		occurrences.Delete(key)
+	}
+}
+
+// Gated logs through the default logger if its gate passed, and otherwise
+// does nothing. See Once and EveryN.
+type Gated struct {
+	ctx    context.Context
+	pass   bool
+	fields []Field
+}
+
+// Once logs only the first call for key in the process, for warnings that
+// would otherwise repeat on every item of a loop:
+//
+//	logger.Once(ctx, "legacy-config").Warn("legacy config format is deprecated")
+func Once(ctx context.Context, key string) Gated {
+	return Gated{ctx: ctx, pass: occurrence(key) == 1}
+}
+
+// EveryN logs the 1st, (n+1)th, (2n+1)th... call for key, adding
+// details.fields.occurrences with the call's count.
+func EveryN(ctx context.Context, key string, n int) Gated {
+	if n < 1 {
+		n = 1
+	}
+	count := occurrence(key)
+	return Gated{ctx: ctx, pass: (count-1)%uint64(n) == 0, fields: []Field{F("occurrences", count)}}
+}
+
+func (g Gated) Debug(args ...interface{}) {
+	g.log(LevelDebug, args)
+}
+
+func (g Gated) Info(args ...interface{}) {
+	g.log(LevelInfo, args)
+}
+
+func (g Gated) Warn(args ...interface{}) {
+	g.log(LevelWarn, args)
+}
+
+func (g Gated) Error(args ...interface{}) {
+	g.log(LevelError, args)
+}
+
+func (g Gated) log(level LogLevel, args []interface{}) {
+	if !g.pass {
+		return
+	}
+	for _, field := range g.fields {
+		args = append(args[:len(args):len(args)], field)
+	}
+	Default().Log(g.ctx, level, args...)
+}
@@ -0,0 +1,352 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hook runs for every entry a logger emits, before it reaches the sinks.
+// Hooks may modify the entry.
+type Hook func(ctx context.Context, output *LogOutput)
+
+// Option configures a StandardLogger created by New.
+type Option func(*StandardLogger)
+
+// WithWriter writes entries to w, encoded with the logger's encoder.
+func WithWriter(w io.Writer) Option {
+	return func(l *StandardLogger) {
+		l.writer = w
+	}
+}
+
+// WithEncoder sets the encoder for the logger's writer (stdout unless
+// WithWriter is given).
+func WithEncoder(e Encoder) Option {
+	return func(l *StandardLogger) {
+		l.encoder = e
+	}
+}
+
+// WithSinks sends entries to sinks in addition to any writer.
+func WithSinks(sinks ...Sink) Option {
+	return func(l *StandardLogger) {
+		l.sinks = append(l.sinks, sinks...)
+	}
+}
+
+// WithLevel drops entries below level.
+func WithLevel(level LogLevel) Option {
+	return func(l *StandardLogger) {
+		l.level.store(level)
+	}
+}
+
+// WithCategoryLevel sets the minimum level for a category and everything
+// below it in the dot-separated hierarchy: "http" applies to "http.request"
+// but not to "https". The most specific match wins over WithLevel.
+func WithCategoryLevel(category string, level LogLevel) Option {
+	return func(l *StandardLogger) {
+		t := *l.currentTuning()
+		t.categoryLevels = cloneMap(t.categoryLevels, 1)
+		t.categoryLevels[category] = level
+		l.tuning.Store(&t)
+	}
+}
+
+// WithHooks adds hooks, run in order for every emitted entry.
+func WithHooks(hooks ...Hook) Option {
+	return func(l *StandardLogger) {
+		l.hooks = append(l.hooks, hooks...)
+	}
+}
+
+// WithClock sets the time source for entry timestamps, e.g. a fixed time in
+// tests. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(l *StandardLogger) {
+		l.now = now
+	}
+}
+
+// TimestampOptions controls how entry timestamps are rendered.
+type TimestampOptions struct {
+	// Location renders timestamps in this zone, e.g. time.Local, instead of
+	// UTC. Timestamps stay RFC 3339, with the zone's offset.
+	Location *time.Location
+	// DateTimeFields adds separate "localDate" (2006-01-02) and "localTime"
+	// (15:04:05) details in the same zone, for sinks and audit formats that
+	// require them.
+	DateTimeFields bool
+}
+
+// WithTimestamps sets how the logger renders entry timestamps.
+func WithTimestamps(opts TimestampOptions) Option {
+	return func(l *StandardLogger) {
+		l.timestamps = opts
+	}
+}
+
+// WithEventSinks sends the entries emitted by Event to sinks instead of the
+// logger's other sinks, e.g. an analytics pipeline.
+func WithEventSinks(sinks ...Sink) Option {
+	return func(l *StandardLogger) {
+		l.eventSinks = append(l.eventSinks, sinks...)
+	}
+}
+
+// WithErrorHandler handles this logger's internal failures instead of the
+// handler set by SetErrorHandler.
+func WithErrorHandler(handler func(err error)) Option {
+	return func(l *StandardLogger) {
+		l.errorHandler = handler
+	}
+}
+
+// StandardLogger is the built-in Logger. A logger created without writer,
+// encoder or sink options writes to the package-level sinks. It is safe for
+// concurrent use: entries are written to its sinks one at a time, in the
+// order they were emitted.
+type StandardLogger struct {
+	writer  io.Writer
+	encoder Encoder
+	sinks   []Sink
+	level   levelVar
+	hooks   []Hook
+	now     func() time.Time
+
+	// tokenizedKeys are the lower-case keys WithTokenizer hooks replace.
+	tokenizedKeys []string
+
+	timestamps TimestampOptions
+
+	eventSinks   []Sink
+	tuning       atomic.Pointer[tuning]
+	errorHandler func(error)
+	stats        *loggerStats
+	recent       *recentEntries
+	unsampled    *UnsampledPolicy
+	suppressions suppressions
+	levelFlags   LevelFlags
+
+	// configMu guards the configurations ApplyConfig and WatchFlags apply,
+	// which are merged into tuning.
+	configMu   sync.Mutex
+	baseConfig Config
+	flagConfig Config
+
+	// writeMu serializes writes to the sinks, keeping entries whole and a
+	// Group's entries contiguous.
+	writeMu sync.Mutex
+}
+
+func New(opts ...Option) *StandardLogger {
+	l := &StandardLogger{now: time.Now, stats: &loggerStats{}}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.writer != nil || l.encoder != nil {
+		w := l.writer
+		if w == nil {
+			w = os.Stdout
+		}
+		l.sinks = append([]Sink{WriterSink{W: w, Encoder: l.encoder}}, l.sinks...)
+	}
+	return l
+}
+
+// SetLevel changes the logger's minimum level while it is in use.
+func (l *StandardLogger) SetLevel(level LogLevel) {
+	l.level.store(level)
+}
+
+func (l *StandardLogger) Level() LogLevel {
+	return l.level.load()
+}
+
+// activeSinks returns the logger's own sinks, or the package sinks when it
+// has none.
+func (l *StandardLogger) activeSinks() []Sink {
+	if len(l.sinks) > 0 {
+		return l.sinks
+	}
+	return currentSinks()
+}
+
+// levelVar holds a level that can change while the logger is in use.
+type levelVar struct {
+	v atomic.Value
+}
+
+func (lv *levelVar) load() LogLevel {
+	level, _ := lv.v.Load().(LogLevel)
+	return level
+}
+
+func (lv *levelVar) store(level LogLevel) {
+	lv.v.Store(level)
+}
+
+func (l *StandardLogger) Debug(ctx context.Context, args ...interface{}) {
+	l.Log(ctx, LevelDebug, args...)
+}
+
+func (l *StandardLogger) Info(ctx context.Context, args ...interface{}) {
+	l.Log(ctx, LevelInfo, args...)
+}
+
+func (l *StandardLogger) Warn(ctx context.Context, args ...interface{}) {
+	l.Log(ctx, LevelWarn, args...)
+}
+
+func (l *StandardLogger) Error(ctx context.Context, args ...interface{}) {
+	l.Log(ctx, LevelError, args...)
+}
+
+func (l *StandardLogger) Log(ctx context.Context, level LogLevel, args ...interface{}) {
+	if !l.Enabled(ctx, level) {
+		l.stats.filtered.Add(1)
+		if l.recent != nil {
+			l.recordFiltered(newEntry(ctx, level, args...))
+		}
+		return
+	}
+	l.Emit(ctx, newEntry(ctx, level, args...))
+}
+
+func (l *StandardLogger) Emit(ctx context.Context, output LogOutput) {
+	l.emit(ctx, output, nil)
+}
+
+// emit runs output through the logger's filters, redaction and hooks and
+// writes it to sinks, or to the logger's own sinks when sinks is nil.
+func (l *StandardLogger) emit(ctx context.Context, output LogOutput, sinks []Sink) {
+	category, _ := output.Details["category"].(string)
+	lc := GetLogContext(ctx)
+	contextMin := l.contextMinLevel(lc)
+	if l.suppress(&output, category) || output.Event == "" && (!l.enabled(contextMin, category, output.Level) || l.sampledOut(lc, contextMin, output.Level)) {
+		l.stats.filtered.Add(1)
+		if l.recent != nil {
+			l.recordFiltered(output)
+		}
+		return
+	}
+	t := l.currentTuning()
+	if output.Event == "" && contextMin == "" && t.sampledAway(category, output.Level) {
+		l.stats.filtered.Add(1)
+		return
+	}
+	l.stamp(output.Details)
+	t.redactOutput(&output)
+	for _, hook := range l.hooks {
+		hook(ctx, &output)
+	}
+	currentLimits().apply(&output)
+	if b := lc.data.budget; b != nil {
+		keep, exceeded := b.admit(output)
+		if exceeded {
+			ctx := unbudgeted(ctx)
+			l.Emit(ctx, newEntry(ctx, LevelWarn, "Log budget exceeded, keeping only errors for the rest of the scope"))
+		}
+		if !keep {
+			l.stats.filtered.Add(1)
+			return
+		}
+	}
+	if s := lc.data.summary; s != nil {
+		s.record(output)
+	}
+	for _, sink := range lc.data.captures {
+		if err := sink.Write(output); err != nil {
+			reportError(l.errorHandler, &SinkError{Sink: sink, Err: err})
+		}
+	}
+	if l.recent != nil {
+		l.recent.push(output)
+	}
+
+	if sinks != nil {
+		l.writeMu.Lock()
+		defer l.writeMu.Unlock()
+		l.writeTo(output, sinks)
+		return
+	}
+	if group := groupFrom(ctx); group != nil {
+		group.add(l, output)
+		return
+	}
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	l.write(output)
+}
+
+// stamp sets the entry's timestamp unless the caller already did.
+func (l *StandardLogger) stamp(details map[string]interface{}) {
+	if _, ok := details["timestamp"]; ok {
+		return
+	}
+	l.stampAt(details, l.now())
+}
+
+// stampAt sets the entry's timestamp to t in the logger's location.
+func (l *StandardLogger) stampAt(details map[string]interface{}, t time.Time) {
+	loc := l.timestamps.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	details["timestamp"] = t.Format(time.RFC3339)
+	if l.timestamps.DateTimeFields {
+		details["localDate"] = t.Format(time.DateOnly)
+		details["localTime"] = t.Format(time.TimeOnly)
+	}
+}
+
+// write sends output to every sink. Callers hold writeMu.
+func (l *StandardLogger) write(output LogOutput) {
+	sinks := l.activeSinks()
+	if output.Event != "" && len(l.eventSinks) > 0 {
+		sinks = l.eventSinks
+	}
+	l.writeTo(output, sinks)
+}
+
+// writeTo sends output to sinks. Callers hold writeMu.
+func (l *StandardLogger) writeTo(output LogOutput, sinks []Sink) {
+	l.stats.emitted.Add(1)
+	failed := false
+	for _, sink := range sinks {
+		if err := sink.Write(output); err != nil {
+			failed = true
+			l.stats.recordError(err, time.Now())
+			reportError(l.errorHandler, &SinkError{Sink: sink, Err: err})
+		}
+	}
+	l.stats.setFailing(failed)
+}
+
+// Enabled reports whether entries at level from ctx's LogContext pass the
+// logger's level filters and unsampled policy.
+func (l *StandardLogger) Enabled(ctx context.Context, level LogLevel) bool {
+	lc := GetLogContext(ctx)
+	contextMin := l.contextMinLevel(lc)
+	return l.enabled(contextMin, lc.data.Category, level) && !l.sampledOut(lc, contextMin, level)
+}
+
+// enabled applies the context's minimum level when set, and otherwise the
+// logger's category and global levels.
+func (l *StandardLogger) enabled(contextMin LogLevel, category string, level LogLevel) bool {
+	if contextMin != "" {
+		return level.severity() >= contextMin.severity()
+	}
+	min := l.level.load()
+	if categoryLevels := l.currentTuning().categoryLevels; category != "" && len(categoryLevels) > 0 {
+		if categoryLevel, ok := categoryValue(categoryLevels, category); ok {
+			min = categoryLevel
+		}
+	}
+	return min == "" || level.severity() >= min.severity()
+}
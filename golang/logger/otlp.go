@@ -0,0 +1,347 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type OTLPOptions struct {
+	// Endpoint is the OTLP/HTTP logs URL. Defaults to
+	// http://localhost:4318/v1/logs.
+	Endpoint string
+	// Header is sent with each export, e.g. for authorization.
+	Header http.Header
+	// Resource attributes identify the process, e.g. "service.name".
+	Resource map[string]string
+	// MaxBatch exports once this many records are buffered. Defaults to 100.
+	MaxBatch int
+	// FlushInterval exports a partial batch this long after its first
+	// record. Defaults to 1s.
+	FlushInterval time.Duration
+	// Client performs the export. Defaults to a client with a 10s timeout.
+	Client *http.Client
+	// MaxPending is the number of full batches waiting for the exporter.
+	// Batches completed while it is full are dropped, and the loss reported
+	// by the next Write or Flush. Defaults to 10.
+	MaxPending int
+}
+
+// OTLPSink exports entries to an OpenTelemetry collector over OTLP/HTTP with
+// JSON encoding. Identity fields, category, tags and metadata become record
+// attributes; a W3C trace ID (32 hex digits) sets the record's traceId.
+// Batches are exported by a background goroutine, so a slow collector never
+// blocks the caller; Close it before exiting to export what is buffered.
+type OTLPSink struct {
+	opts OTLPOptions
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	records   []map[string]interface{}
+	timer     *time.Timer
+	pending   [][]map[string]interface{}
+	exporting bool
+	closed    bool
+	err       error
+	done      chan struct{}
+}
+
+func NewOTLPSink(opts OTLPOptions) *OTLPSink {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	if opts.MaxBatch <= 0 {
+		opts.MaxBatch = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 10
+	}
+	s := &OTLPSink{opts: opts, done: make(chan struct{})}
+	s.cond = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+// Write buffers output and returns at once, reporting the error of a
+// previous export, if any.
+func (s *OTLPSink) Write(output LogOutput) error {
+	record := otlpRecord(output)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("otlp sink closed")
+	}
+	s.records = append(s.records, record)
+	if len(s.records) >= s.opts.MaxBatch {
+		s.queueLocked()
+	} else if s.timer == nil {
+		s.timer = time.AfterFunc(s.opts.FlushInterval, s.timedFlush)
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// Flush exports buffered records now and waits for every pending export,
+// returning the first error since the last Write or Flush.
+func (s *OTLPSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queueLocked()
+	for len(s.pending) > 0 || s.exporting {
+		s.cond.Wait()
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// Close exports what is buffered and stops the exporter.
+func (s *OTLPSink) Close() error {
+	err := s.Flush()
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		s.cond.Broadcast()
+	}
+	s.mu.Unlock()
+	<-s.done
+	return err
+}
+
+func (s *OTLPSink) timedFlush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timer = nil
+	s.queueLocked()
+}
+
+// queueLocked hands the buffered records to the exporter as a batch.
+func (s *OTLPSink) queueLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.records) == 0 {
+		return
+	}
+	if len(s.pending) >= s.opts.MaxPending {
+		s.setErrLocked(fmt.Errorf("otlp: dropped %d records: %d batches waiting for export", len(s.records), len(s.pending)))
+	} else {
+		s.pending = append(s.pending, s.records)
+		s.cond.Broadcast()
+	}
+	s.records = nil
+}
+
+// setErrLocked keeps the first error until it is reported.
+func (s *OTLPSink) setErrLocked(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *OTLPSink) run() {
+	defer close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for len(s.pending) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.pending) == 0 {
+			return
+		}
+		records := s.pending[0]
+		s.pending = s.pending[1:]
+		s.exporting = true
+		s.mu.Unlock()
+		err := s.export(records)
+		s.mu.Lock()
+		s.exporting = false
+		if err != nil {
+			s.setErrLocked(err)
+		}
+		s.cond.Broadcast()
+	}
+}
+
+func (s *OTLPSink) export(records []map[string]interface{}) error {
+	err := otlpExport(s.opts.Client, s.opts.Endpoint, s.opts.Header, map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": otlpResource(s.opts.Resource),
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      otlpScope,
+				"logRecords": records,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("otlp: export of %d records: %w", len(records), err)
+	}
+	return nil
+}
+
+var otlpScope = map[string]interface{}{"name": "github.com/peterzzshi/context-based-logger"}
+
+func otlpResource(attributes map[string]string) map[string]interface{} {
+	resource := make([]map[string]interface{}, 0, len(attributes))
+	for _, k := range sortedKeys(attributes) {
+		resource = append(resource, otlpKeyValue(k, attributes[k]))
+	}
+	return map[string]interface{}{"attributes": resource}
+}
+
+// otlpExport posts an OTLP/HTTP JSON request.
+func otlpExport(client *http.Client, endpoint string, header http.Header, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+func otlpRecord(output LogOutput) map[string]interface{} {
+	record := map[string]interface{}{
+		"severityText":         string(output.Level),
+		"severityNumber":       OTelSeverity.Number(output.Level),
+		"observedTimeUnixNano": strconv.FormatInt(time.Now().UnixNano(), 10),
+	}
+	if ts, ok := output.Details["timestamp"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			record["timeUnixNano"] = strconv.FormatInt(t.UnixNano(), 10)
+		}
+	}
+	if output.Message != nil {
+		record["body"] = otlpAnyValue(output.Message)
+	} else if output.Data != nil {
+		record["body"] = otlpAnyValue(output.Data)
+	}
+
+	var attrs []map[string]interface{}
+	add := func(key string, v interface{}) {
+		if s, ok := v.(string); ok && s == "" {
+			return
+		}
+		attrs = append(attrs, otlpKeyValue(key, v))
+	}
+	if id, err := hex.DecodeString(output.TraceID); err == nil && len(id) == 16 {
+		record["traceId"] = output.TraceID
+	} else {
+		add("trace.id", output.TraceID)
+	}
+	add("event.name", output.Event)
+	add("session.id", output.SessionID)
+	add("operation.id", output.OperationID)
+	add("operation.parent_id", output.ParentOperationID)
+	add("enduser.id", output.UserID)
+	add("tenant.id", output.TenantID)
+	if output.Message != nil && output.Data != nil {
+		add("log.data", output.Data)
+	}
+
+	for _, k := range sortedKeys(output.Details) {
+		v := output.Details[k]
+		switch k {
+		case "timestamp":
+		case "category":
+			add("log.category", v)
+		case "tags":
+			add("log.tags", v)
+		case "stack":
+			add("exception.stacktrace", v)
+		case "errorChain":
+			if chain, ok := v.([]string); ok && len(chain) > 0 {
+				add("exception.message", chain[0])
+			}
+		case "metadata":
+			metadata, _ := metadataFields(v)
+			for _, key := range sortedKeys(metadata) {
+				add(key, metadata[key])
+			}
+		default:
+			add("log."+k, v)
+		}
+	}
+	if len(attrs) > 0 {
+		record["attributes"] = attrs
+	}
+	return record
+}
+
+func otlpKeyValue(key string, v interface{}) map[string]interface{} {
+	return map[string]interface{}{"key": key, "value": otlpAnyValue(v)}
+}
+
+// otlpAnyValue converts v to the OTLP JSON AnyValue form.
+func otlpAnyValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case LogLevel:
+		return map[string]interface{}{"stringValue": string(v)}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, s := range v {
+			values[i] = otlpAnyValue(s)
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, e := range v {
+			values[i] = otlpAnyValue(e)
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		values := make([]interface{}, len(keys))
+		for i, k := range keys {
+			values[i] = otlpKeyValue(k, v[k])
+		}
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": values}}
+	case nil:
+		return map[string]interface{}{}
+	}
+	if generic, ok := structuredValue(v); ok {
+		return otlpAnyValue(generic)
+	}
+	return map[string]interface{}{"stringValue": renderArg(v)}
+}
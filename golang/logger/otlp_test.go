@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOTLPSink(t *testing.T) {
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected export to %s (%s)", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("Invalid JSON: %v", err)
+		}
+		bodies = append(bodies, body)
+	}))
+	defer srv.Close()
+
+	sink := NewOTLPSink(OTLPOptions{
+		Endpoint:      srv.URL + "/v1/logs",
+		Resource:      map[string]string{"service.name": "orders"},
+		MaxBatch:      2,
+		FlushInterval: time.Hour,
+	})
+	sink.Write(LogOutput{
+		Level:     LevelError,
+		Message:   "payment failed",
+		SessionID: "s1",
+		TraceID:   "4bf92f3577b34da6a3ce929d0e0e4736",
+		Details: map[string]interface{}{
+			"timestamp": "2024-01-01T00:00:00Z",
+			"category":  "payments",
+			"metadata":  map[string]string{"orderId": "42"},
+		},
+	})
+	if len(bodies) != 0 {
+		t.Fatal("Expected the first record to be buffered")
+	}
+	if err := sink.Write(LogOutput{Level: LevelInfo, Message: map[string]interface{}{"ok": true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("Expected one export at MaxBatch, got %d", len(bodies))
+	}
+
+	got, _ := json.Marshal(bodies[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})["scopeLogs"].([]interface{})[0].(map[string]interface{})["logRecords"])
+	var records []map[string]interface{}
+	json.Unmarshal(got, &records)
+	first := records[0]
+	if first["severityNumber"] != float64(17) || first["timeUnixNano"] != "1704067200000000000" {
+		t.Errorf("Unexpected severity/time: %v", first)
+	}
+	if first["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected W3C trace ID on the record, got %v", first["traceId"])
+	}
+	attrs := map[string]interface{}{}
+	for _, a := range first["attributes"].([]interface{}) {
+		kv := a.(map[string]interface{})
+		attrs[kv["key"].(string)] = kv["value"].(map[string]interface{})["stringValue"]
+	}
+	if attrs["session.id"] != "s1" || attrs["log.category"] != "payments" || attrs["orderId"] != "42" {
+		t.Errorf("Unexpected attributes: %v", attrs)
+	}
+	if _, ok := records[1]["body"].(map[string]interface{})["kvlistValue"]; !ok {
+		t.Errorf("Expected structured body as kvlist, got %v", records[1]["body"])
+	}
+}
+
+func TestOTLPSink_ExportsInBackground(t *testing.T) {
+	release := make(chan struct{})
+	var exports atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		exports.Add(1)
+	}))
+	defer srv.Close()
+
+	sink := NewOTLPSink(OTLPOptions{Endpoint: srv.URL, MaxBatch: 1, MaxPending: 1, FlushInterval: time.Hour})
+	done := make(chan struct{})
+	var errs []error
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			errs = append(errs, sink.Write(LogOutput{Level: LevelInfo, Message: "queued"}))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected writes not to wait for the collector")
+	}
+
+	close(release)
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := errors.Join(errs...); err == nil || !strings.Contains(err.Error(), "dropped 1 records") {
+		t.Errorf("Expected the dropped batches to be reported, got %v", err)
+	}
+	if n := exports.Load(); n < 1 || n > 2 {
+		t.Errorf("Expected the exporting and pending batches only, got %d exports", n)
+	}
+}
+
+func TestOTLPMetricsSink(t *testing.T) {
+	var body struct {
+		ResourceMetrics []struct {
+			ScopeMetrics []struct {
+				Metrics []struct {
+					Name string
+					Sum  *struct {
+						DataPoints []struct{ AsInt string }
+					}
+					Histogram *struct {
+						DataPoints []struct {
+							Count        string
+							Sum          float64
+							BucketCounts []string
+						}
+					}
+				}
+			}
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			t.Errorf("Unexpected export to %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Invalid JSON: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewOTLPMetricsSink(OTLPMetricsOptions{Endpoint: srv.URL + "/v1/metrics", Buckets: []float64{10, 100}})
+	l := New(WithSinks(sink))
+	ctx := context.Background()
+	l.Event(ctx, "order.placed", F("total", 12.5))
+	l.Event(ctx, "order.placed", F("total", 150), F("note", "gift"))
+	tagged := NewLogContext(LogContextData{Category: "jobs.sync"}).WithTags("metric")
+	l.Info(context.WithValue(ctx, logContextKey, tagged), "Synced", DurationField("sync", 5*time.Millisecond))
+	l.Info(ctx, "Not a metric")
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, m := range body.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		switch {
+		case m.Sum != nil:
+			got[m.Name] = m.Sum.DataPoints[0].AsInt
+		case m.Histogram != nil:
+			p := m.Histogram.DataPoints[0]
+			got[m.Name] = fmt.Sprintf("%s/%g/%v", p.Count, p.Sum, p.BucketCounts)
+		}
+	}
+	expected := map[string]string{
+		"order.placed":       "2",
+		"order.placed.total": "2/162.5/[0 1 1]",
+		"jobs.sync":          "1",
+		"jobs.sync.sync_ms":  "1/5/[1 0 0]",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,333 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ECSEncoder renders entries in Elastic Common Schema, so Elasticsearch and
+// Kibana index them without ingest pipelines. Metadata and the context IDs
+// with no ECS equivalent (session, operation) become labels.
+type ECSEncoder struct{}
+
+// ecsVersion is the ECS version the field mapping follows.
+const ecsVersion = "8.11.0"
+
+func (ECSEncoder) Encode(output LogOutput) ([]byte, error) {
+	fields := map[string]interface{}{
+		"log.level":   string(output.Level),
+		"ecs.version": ecsVersion,
+	}
+	if output.Message != nil {
+		message, err := profileMessage(output.Message)
+		if err != nil {
+			return nil, err
+		}
+		fields["message"] = message
+	}
+	if output.Data != nil {
+		fields["data"] = output.Data
+	}
+	labels := make(map[string]interface{})
+	setIf(fields, "event.action", output.Event)
+	setIf(fields, "trace.id", output.TraceID)
+	setIf(fields, "user.id", output.UserID)
+	setIf(fields, "organization.id", output.TenantID)
+	setIf(labels, "sessionId", output.SessionID)
+	setIf(labels, "operationId", output.OperationID)
+	setIf(labels, "parentOperationId", output.ParentOperationID)
+
+	for k, v := range output.Details {
+		switch k {
+		case "timestamp":
+			fields["@timestamp"] = v
+		case "category":
+			fields["log.logger"] = v
+		case "tags":
+			fields["tags"] = v
+		case "stack":
+			fields["error.stack_trace"] = v
+		case "errorChain":
+			if chain, ok := v.([]string); ok && len(chain) > 0 {
+				fields["error.message"] = chain[0]
+			}
+		case "metadata":
+			metadata, _ := metadataFields(v)
+			for key, value := range metadata {
+				labels[key] = labelValue(value)
+			}
+		default:
+			labels[k] = labelValue(v)
+		}
+	}
+	if len(labels) > 0 {
+		fields["labels"] = labels
+	}
+	return json.Marshal(fields)
+}
+
+// profileMessage renders a message as text; vendor schemas expect a string,
+// so structured messages are embedded as JSON.
+func profileMessage(message interface{}) (string, error) {
+	if s, ok := message.(string); ok {
+		return s, nil
+	}
+	raw, err := json.Marshal(message)
+	return string(raw), err
+}
+
+// labelValue renders v as a keyword: strings as is, anything else as JSON.
+func labelValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return renderArg(v)
+	}
+	return string(raw)
+}
+
+// setIf sets key only for non-empty values, mirroring JSON omitempty.
+func setIf(m map[string]interface{}, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+// DatadogEncoder renders entries with Datadog's standard attributes (status,
+// service, env, version, dd.trace_id, dd.span_id, logger.name, usr.id,
+// error.*), so no remapping processors are needed. Empty Service, Env and
+// Version fall back to DD_SERVICE, DD_ENV and DD_VERSION.
+type DatadogEncoder struct {
+	Service string
+	Env     string
+	Version string
+}
+
+// The metadata keys WithDatadogSpan stores the trace and span IDs in.
+const (
+	datadogTraceKey = "dd.trace_id"
+	datadogSpanKey  = "dd.span_id"
+)
+
+func (e DatadogEncoder) Encode(output LogOutput) ([]byte, error) {
+	fields := map[string]interface{}{
+		"status": string(output.Level),
+	}
+	if output.Message != nil {
+		message, err := profileMessage(output.Message)
+		if err != nil {
+			return nil, err
+		}
+		fields["message"] = message
+	}
+	if output.Data != nil {
+		fields["data"] = output.Data
+	}
+	setIf(fields, "evt.name", output.Event)
+	dd := make(map[string]interface{})
+	setIf(dd, "service", firstNonEmpty(e.Service, os.Getenv("DD_SERVICE")))
+	setIf(dd, "env", firstNonEmpty(e.Env, os.Getenv("DD_ENV")))
+	setIf(dd, "version", firstNonEmpty(e.Version, os.Getenv("DD_VERSION")))
+	for k, v := range dd {
+		fields[k] = v
+	}
+	setIf(dd, "trace_id", output.TraceID)
+	setIf(fields, "sessionId", output.SessionID)
+	setIf(fields, "operationId", output.OperationID)
+	setIf(fields, "parentOperationId", output.ParentOperationID)
+	setIf(fields, "tenantId", output.TenantID)
+	if output.UserID != "" {
+		fields["usr"] = map[string]interface{}{"id": output.UserID}
+	}
+
+	details := make(map[string]interface{})
+	errorFields := make(map[string]interface{})
+	for k, v := range output.Details {
+		switch k {
+		case "timestamp":
+			fields["timestamp"] = v
+		case "category":
+			fields["logger"] = map[string]interface{}{"name": v}
+		case "tags":
+			if tags, ok := v.([]string); ok {
+				fields["ddtags"] = strings.Join(tags, ",")
+			}
+		case "stack":
+			errorFields["stack"] = v
+		case "errorChain":
+			if chain, ok := v.([]string); ok && len(chain) > 0 {
+				errorFields["message"] = chain[0]
+			}
+		case "metadata":
+			metadata, _ := metadataFields(v)
+			for key, field := range map[string]string{datadogTraceKey: "trace_id", datadogSpanKey: "span_id"} {
+				if id, ok := metadata[key]; ok {
+					dd[field] = id
+					metadata = cloneMap(metadata, 0)
+					delete(metadata, key)
+				}
+			}
+			if len(metadata) > 0 {
+				fields["metadata"] = metadata
+			}
+		default:
+			details[k] = v
+		}
+	}
+	if len(dd) > 0 {
+		fields["dd"] = dd
+	}
+	if len(errorFields) > 0 {
+		fields["error"] = errorFields
+	}
+	if len(details) > 0 {
+		fields["details"] = details
+	}
+	return json.Marshal(fields)
+}
+
+// DatadogSpanContext is satisfied by dd-trace-go's ddtrace.SpanContext.
+type DatadogSpanContext interface {
+	SpanID() uint64
+	TraceID() uint64
+}
+
+// WithDatadogSpan correlates entries with a dd-trace-go span: its decimal
+// trace and span IDs are carried as "dd.trace_id" and "dd.span_id" metadata,
+// which DatadogEncoder emits as its dd attributes. The context's TraceID is
+// left alone, since it holds W3C trace IDs for propagation and other
+// encoders.
+//
+//	if span, ok := tracer.SpanFromContext(ctx); ok {
+//		lc = lc.WithDatadogSpan(span.Context())
+//	}
+func (lc *LogContext) WithDatadogSpan(span DatadogSpanContext) *LogContext {
+	return lc.WithoutMetadata(datadogTraceKey, datadogSpanKey).
+		WithMetadata(map[string]string{
+			datadogTraceKey: strconv.FormatUint(span.TraceID(), 10),
+			datadogSpanKey:  strconv.FormatUint(span.SpanID(), 10),
+		})
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// GCPEncoder renders entries as Google Cloud Logging structured payloads, so
+// the GKE and Cloud Run logging agents parse severity, trace and source
+// location natively. Error entries are typed as ReportedErrorEvents for Error
+// Reporting. An empty ProjectID falls back to GOOGLE_CLOUD_PROJECT; without
+// one, trace IDs stay in labels. Source locations need the SourceLocation
+// hook.
+type GCPEncoder struct {
+	ProjectID string
+	// Service names the service in Error Reporting. Defaults to K_SERVICE.
+	Service string
+}
+
+var gcpSeverity = map[LogLevel]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARNING",
+	LevelError: "ERROR",
+}
+
+// gcpReserved lists the payload fields GCPEncoder sets itself, or that Cloud
+// Logging treats specially. Details with these names are nested under
+// "details" so they cannot replace them.
+var gcpReserved = map[string]bool{
+	"time": true, "timestamp": true, "severity": true, "message": true,
+	"data": true, "event": true, "stack_trace": true, "@type": true,
+	"serviceContext": true, "context": true, "details": true,
+}
+
+const gcpReportedErrorEvent = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+func (e GCPEncoder) Encode(output LogOutput) ([]byte, error) {
+	severity, ok := gcpSeverity[output.Level]
+	if !ok {
+		severity = "DEFAULT"
+	}
+	fields := map[string]interface{}{
+		"severity": severity,
+	}
+	if output.Message != nil {
+		message, err := profileMessage(output.Message)
+		if err != nil {
+			return nil, err
+		}
+		fields["message"] = message
+	}
+	if output.Data != nil {
+		fields["data"] = output.Data
+	}
+
+	setIf(fields, "event", output.Event)
+	labels := make(map[string]interface{})
+	setIf(labels, "sessionId", output.SessionID)
+	setIf(labels, "operationId", output.OperationID)
+	setIf(labels, "parentOperationId", output.ParentOperationID)
+	setIf(labels, "userId", output.UserID)
+	setIf(labels, "tenantId", output.TenantID)
+	if project := firstNonEmpty(e.ProjectID, os.Getenv("GOOGLE_CLOUD_PROJECT")); project != "" && output.TraceID != "" {
+		fields["logging.googleapis.com/trace"] = "projects/" + project + "/traces/" + output.TraceID
+	} else {
+		setIf(labels, "traceId", output.TraceID)
+	}
+
+	var caller map[string]interface{}
+	collisions := make(map[string]interface{})
+	for k, v := range output.Details {
+		switch k {
+		case "timestamp":
+			fields["time"] = v
+		case "category":
+			labels["category"] = labelValue(v)
+		case "tags":
+			if tags, ok := v.([]string); ok {
+				labels["tags"] = strings.Join(tags, ",")
+			}
+		case "caller":
+			caller, _ = v.(map[string]interface{})
+			fields["logging.googleapis.com/sourceLocation"] = caller
+		case "stack":
+			fields["stack_trace"] = v
+		default:
+			if gcpReserved[k] || strings.HasPrefix(k, "logging.googleapis.com/") {
+				collisions[k] = v
+			} else {
+				fields[k] = v
+			}
+		}
+	}
+	if len(collisions) > 0 {
+		fields["details"] = collisions
+	}
+	if len(labels) > 0 {
+		fields["logging.googleapis.com/labels"] = labels
+	}
+
+	if output.Level == LevelError {
+		fields["@type"] = gcpReportedErrorEvent
+		if service := firstNonEmpty(e.Service, os.Getenv("K_SERVICE")); service != "" {
+			fields["serviceContext"] = map[string]string{"service": service}
+		}
+		if caller != nil {
+			fields["context"] = map[string]interface{}{"reportLocation": map[string]interface{}{
+				"filePath":     caller["file"],
+				"lineNumber":   caller["line"],
+				"functionName": caller["function"],
+			}}
+		}
+	}
+	return json.Marshal(fields)
+}
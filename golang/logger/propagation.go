@@ -0,0 +1,205 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Message headers InjectLogContext writes alongside RequestIDHeader (the
+// session), LogLevelHeader (the context's MinLevel) and TraceParentHeader.
+const (
+	OperationIDHeader = "X-Operation-ID"
+	UserIDHeader      = "X-User-ID"
+	TenantIDHeader    = "X-Tenant-ID"
+	// TraceIDHeader carries trace IDs that do not fit a traceparent.
+	TraceIDHeader  = "X-Trace-ID"
+	LogTagsHeader  = "X-Log-Tags"
+	MetadataHeader = "X-Log-Metadata"
+)
+
+// HeaderCarrier is the message header shape the context travels in.
+// nats.Header and http.Header satisfy it; MapCarrier and TableCarrier adapt
+// plain maps and RabbitMQ's amqp.Table.
+type HeaderCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// MapCarrier adapts a map of string headers, e.g. one copied to and from SQS
+// message attributes.
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) string { return c[key] }
+
+func (c MapCarrier) Set(key, value string) { c[key] = value }
+
+// TableCarrier adapts RabbitMQ message headers: TableCarrier(msg.Headers).
+// The table must be non-nil when publishing.
+type TableCarrier map[string]interface{}
+
+func (c TableCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+func (c TableCarrier) Set(key, value string) { c[key] = value }
+
+// InjectLogContext writes ctx's LogContext to a message's headers, so the
+// consumer can continue the session with ExtractLogContext or HandleMessage.
+// Dynamic metadata from providers is not propagated.
+func InjectLogContext(ctx context.Context, headers HeaderCarrier) {
+	injectLogContext(GetLogContext(ctx).data, headers)
+}
+
+func injectLogContext(data LogContextData, headers HeaderCarrier) {
+	setHeader(headers, RequestIDHeader, data.SessionID)
+	setHeader(headers, OperationIDHeader, data.OperationID)
+	setHeader(headers, UserIDHeader, data.UserID)
+	setHeader(headers, TenantIDHeader, data.TenantID)
+	setHeader(headers, LogLevelHeader, string(data.MinLevel))
+	if data.Tags.Len() > 0 {
+		headers.Set(LogTagsHeader, strings.Join(data.Tags.Slice(), ","))
+	}
+	if len(data.Metadata) > 0 {
+		if raw, err := json.Marshal(data.Metadata); err == nil {
+			headers.Set(MetadataHeader, string(raw))
+		}
+	}
+	injectTrace(data, headers)
+}
+
+// injectTrace writes the context's trace as a traceparent, or as X-Trace-ID
+// when the trace ID is not a W3C one.
+func injectTrace(data LogContextData, headers HeaderCarrier) {
+	if traceparent, ok := formatTraceParent(data); ok {
+		headers.Set(TraceParentHeader, traceparent)
+	} else {
+		setHeader(headers, TraceIDHeader, data.TraceID)
+	}
+}
+
+func setHeader(headers HeaderCarrier, key, value string) {
+	if value != "" {
+		headers.Set(key, value)
+	}
+}
+
+// formatTraceParent renders the context's trace as a traceparent whose
+// parent ID is the operation ID, when the trace ID is a W3C one.
+func formatTraceParent(data LogContextData) (string, bool) {
+	parent := data.OperationID
+	if len(parent) != 16 {
+		parent = newID()
+	}
+	flags := "01"
+	if data.traceSampling == traceUnsampled {
+		flags = "00"
+	}
+	traceparent := "00-" + data.TraceID + "-" + parent + "-" + flags
+	if _, _, ok := parseTraceParent(traceparent); !ok {
+		return "", false
+	}
+	return traceparent, true
+}
+
+var messageLevelPolicy atomic.Pointer[func(headers HeaderCarrier) bool]
+
+// SetMessageLogLevelPolicy lets ExtractLogContext and HandleMessage honor
+// LogLevelHeader on messages for which allow returns true, e.g. those from
+// trusted queues. Like SetLogLevelHeaderPolicy for requests, it keeps any
+// producer from turning on debug logging in consumers. Passing nil disables
+// the header again.
+func SetMessageLogLevelPolicy(allow func(headers HeaderCarrier) bool) {
+	if allow == nil {
+		messageLevelPolicy.Store(nil)
+		return
+	}
+	messageLevelPolicy.Store(&allow)
+}
+
+// messageMinLevel returns the level a message's LogLevelHeader carries when
+// the policy allows it.
+func messageMinLevel(headers HeaderCarrier) LogLevel {
+	level, err := ParseLevel(headers.Get(LogLevelHeader))
+	if err != nil {
+		return ""
+	}
+	if allow := messageLevelPolicy.Load(); allow == nil || !(*allow)(headers) {
+		return ""
+	}
+	return level
+}
+
+// ExtractLogContext rebuilds the LogContext InjectLogContext wrote to a
+// message's headers. Its OperationID is the producer's, so a scope opened
+// from it with WithLogContext becomes the producer operation's child.
+// Headers that are missing or malformed are ignored, as is the min level
+// unless SetMessageLogLevelPolicy allows it.
+func ExtractLogContext(headers HeaderCarrier) *LogContext {
+	data := LogContextData{
+		SessionID:   headers.Get(RequestIDHeader),
+		OperationID: headers.Get(OperationIDHeader),
+		UserID:      headers.Get(UserIDHeader),
+		TenantID:    headers.Get(TenantIDHeader),
+		TraceID:     headers.Get(TraceIDHeader),
+		MinLevel:    messageMinLevel(headers),
+	}
+	if tags := headers.Get(LogTagsHeader); tags != "" {
+		data.Tags = NewTagSet(strings.Split(tags, ",")...)
+	}
+	if raw := headers.Get(MetadataHeader); raw != "" {
+		var metadata map[string]string
+		if json.Unmarshal([]byte(raw), &metadata) == nil {
+			data.Metadata = metadata
+		}
+	}
+	lc := NewLogContext(data)
+	if traceparent := headers.Get(TraceParentHeader); traceparent != "" {
+		lc = lc.WithTraceParent(traceparent)
+	}
+	return lc
+}
+
+// HandleMessage runs handler for a message consumed from queue in a new
+// operation continuing the producer's LogContext, the consumer-side
+// counterpart of Middleware. Entries are categorized "mq" and carry the
+// queue as metadata; a final entry records the duration at debug, or the
+// error at error level.
+//
+//	sub, _ := nc.Subscribe("orders", func(msg *nats.Msg) {
+//		_ = logger.HandleMessage(ctx, "orders", msg.Header, func(ctx context.Context) error {
+//			return process(ctx, msg.Data)
+//		})
+//	})
+func HandleMessage(ctx context.Context, queue string, headers HeaderCarrier, handler func(context.Context) error) error {
+	producer := ExtractLogContext(headers)
+	if producer.data.SessionID == "" {
+		producer = producer.WithSessionID(newID())
+	}
+	lc := producer.
+		WithCategory("mq").
+		WithMetadata(map[string]string{"queue": queue})
+
+	_, err := WithLogContext(context.WithValue(ctx, logContextKey, producer), lc, func(ctx context.Context) (struct{}, error) {
+		start := time.Now()
+		err := handler(ctx)
+		result := map[string]string{"durationMs": strconv.FormatInt(time.Since(start).Milliseconds(), 10)}
+		ctx = context.WithValue(ctx, logContextKey, GetLogContext(ctx).WithMetadata(result))
+		if err != nil {
+			Error(ctx, "Message handling failed:", err)
+		} else {
+			Debug(ctx, "Message handled")
+		}
+		return struct{}{}, err
+	})
+	return err
+}
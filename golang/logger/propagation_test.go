@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestInjectExtractLogContext(t *testing.T) {
+	lc := NewLogContext(LogContextData{
+		SessionID:   "s1",
+		OperationID: "0123456789abcdef",
+		TenantID:    "acme",
+		TraceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+		MinLevel:    LevelDebug,
+		Tags:        NewTagSet("checkout"),
+		Metadata:    map[string]string{"orderId": "o-1"},
+	}).WithTraceSampled(false)
+	ctx := context.WithValue(context.Background(), logContextKey, lc)
+	SetMessageLogLevelPolicy(func(HeaderCarrier) bool { return true })
+	defer SetMessageLogLevelPolicy(nil)
+
+	for name, headers := range map[string]HeaderCarrier{
+		"map":   MapCarrier{},
+		"table": TableCarrier{},
+		"http":  http.Header{},
+	} {
+		InjectLogContext(ctx, headers)
+		if got := headers.Get(TraceParentHeader); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-0123456789abcdef-00" {
+			t.Errorf("%s: unexpected traceparent %q", name, got)
+		}
+		data := ExtractLogContext(headers).data
+		if data.SessionID != "s1" || data.OperationID != "0123456789abcdef" || data.TenantID != "acme" ||
+			data.TraceID != lc.data.TraceID || data.traceSampling != traceUnsampled || data.MinLevel != LevelDebug ||
+			!data.Tags.Has("checkout") || data.Metadata["orderId"] != "o-1" {
+			t.Errorf("%s: expected the context to round-trip, got %+v", name, data)
+		}
+	}
+
+	headers := MapCarrier{}
+	InjectLogContext(context.WithValue(ctx, logContextKey, lc.WithTraceID("req-7")), headers)
+	if headers[TraceParentHeader] != "" || ExtractLogContext(headers).TraceID() != "req-7" {
+		t.Errorf("Expected a non-W3C trace ID to travel in its own header, got %v", headers)
+	}
+}
+
+func TestExtractLogContext_LevelPolicy(t *testing.T) {
+	headers := MapCarrier{RequestIDHeader: "s1", LogLevelHeader: "debug", "X-Queue": "internal"}
+	if level := ExtractLogContext(headers).data.MinLevel; level != "" {
+		t.Errorf("Expected the level header ignored without a policy, got %q", level)
+	}
+
+	SetMessageLogLevelPolicy(func(headers HeaderCarrier) bool { return headers.Get("X-Queue") == "internal" })
+	defer SetMessageLogLevelPolicy(nil)
+	if level := ExtractLogContext(headers).data.MinLevel; level != LevelDebug {
+		t.Errorf("Expected the allowed level header honored, got %q", level)
+	}
+	headers["X-Queue"] = "public"
+	if level := ExtractLogContext(headers).data.MinLevel; level != "" {
+		t.Errorf("Expected the level header ignored when the policy denies it, got %q", level)
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	previous := Default()
+	rec := &lockedSink{}
+	SetDefault(New(WithLevel(LevelDebug), WithSinks(rec)))
+	defer SetDefault(previous)
+
+	headers := MapCarrier{RequestIDHeader: "s1", OperationIDHeader: "producer-op"}
+	var handled *LogContext
+	err := HandleMessage(context.Background(), "orders", headers, func(ctx context.Context) error {
+		handled = GetLogContext(ctx)
+		return errors.New("boom")
+	})
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("Expected the handler's error, got %v", err)
+	}
+	if handled.data.SessionID != "s1" || handled.data.ParentOperationID != "producer-op" || handled.data.Category != "mq" {
+		t.Errorf("Expected the handler to continue the producer's context, got %+v", handled.data)
+	}
+	if len(rec.entries) != 1 || rec.entries[0].Level != LevelError || rec.entries[0].SessionID != "s1" {
+		t.Errorf("Expected one error entry for the failed message, got %v", rec.entries)
+	}
+}
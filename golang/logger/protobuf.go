@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"encoding/binary"
+	"encoding/json"
+)
+
+// ProtobufEncoder renders entries as contextlogger.v1.LogEntry messages (see
+// proto/logentry.proto), each prefixed with its varint length so a stream of
+// entries can be split again, as with Java's writeDelimitedTo.
+type ProtobufEncoder struct{}
+
+func (ProtobufEncoder) Encode(output LogOutput) ([]byte, error) {
+	msg, err := marshalLogEntry(output)
+	if err != nil {
+		return nil, err
+	}
+	return append(binary.AppendUvarint(nil, uint64(len(msg))), msg...), nil
+}
+
+func (ProtobufEncoder) binary() {}
+
+// Field numbers of contextlogger.v1.LogEntry.
+const (
+	pbLevel = iota + 1
+	pbMessage
+	pbMessageJSON
+	pbSessionID
+	pbOperationID
+	pbParentOperationID
+	pbUserID
+	pbTenantID
+	pbTraceID
+	pbCategory
+	pbTags
+	pbMetadata
+	pbTimestamp
+	pbStack
+	pbErrorChain
+	pbTruncated
+	pbDetailsJSON
+	pbDataJSON
+	pbSchemaVersion
+	pbEvent
+)
+
+// marshalLogEntry encodes output as an undelimited LogEntry message.
+func marshalLogEntry(output LogOutput) ([]byte, error) {
+	var b []byte
+	if output.SchemaVersion != 0 {
+		b = binary.AppendUvarint(appendProtoTag(b, pbSchemaVersion, 0), uint64(output.SchemaVersion))
+	}
+	b = appendProtoString(b, pbLevel, string(output.Level))
+	switch message := output.Message.(type) {
+	case nil:
+	case string:
+		b = appendProtoString(b, pbMessage, message)
+	default:
+		raw, err := json.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		b = appendProtoBytes(b, pbMessageJSON, raw)
+	}
+	if output.Data != nil {
+		raw, err := json.Marshal(output.Data)
+		if err != nil {
+			return nil, err
+		}
+		b = appendProtoBytes(b, pbDataJSON, raw)
+	}
+	b = appendProtoString(b, pbEvent, output.Event)
+	b = appendProtoString(b, pbSessionID, output.SessionID)
+	b = appendProtoString(b, pbOperationID, output.OperationID)
+	b = appendProtoString(b, pbParentOperationID, output.ParentOperationID)
+	b = appendProtoString(b, pbUserID, output.UserID)
+	b = appendProtoString(b, pbTenantID, output.TenantID)
+	b = appendProtoString(b, pbTraceID, output.TraceID)
+
+	rest := make(map[string]interface{})
+	for _, k := range sortedKeys(output.Details) {
+		v := output.Details[k]
+		switch k {
+		case "category":
+			s, _ := v.(string)
+			b = appendProtoString(b, pbCategory, s)
+		case "tags":
+			tags, _ := v.([]string)
+			for _, tag := range tags {
+				b = appendProtoBytes(b, pbTags, []byte(tag))
+			}
+		case "metadata":
+			metadata, _ := metadataFields(v)
+			for _, key := range sortedKeys(metadata) {
+				value, ok := metadata[key].(string)
+				if !ok {
+					raw, err := json.Marshal(metadata[key])
+					if err != nil {
+						return nil, err
+					}
+					value = string(raw)
+				}
+				entry := appendProtoString(nil, 1, key)
+				entry = appendProtoString(entry, 2, value)
+				b = appendProtoBytes(b, pbMetadata, entry)
+			}
+		case "timestamp":
+			s, _ := v.(string)
+			b = appendProtoString(b, pbTimestamp, s)
+		case "stack":
+			s, _ := v.(string)
+			b = appendProtoString(b, pbStack, s)
+		case "errorChain":
+			chain, _ := v.([]string)
+			for _, e := range chain {
+				b = appendProtoBytes(b, pbErrorChain, []byte(e))
+			}
+		case "truncated":
+			if truncated, _ := v.(bool); truncated {
+				b = append(appendProtoTag(b, pbTruncated, 0), 1)
+			}
+		default:
+			rest[k] = v
+		}
+	}
+	if len(rest) > 0 {
+		raw, err := json.Marshal(rest)
+		if err != nil {
+			return nil, err
+		}
+		b = appendProtoBytes(b, pbDetailsJSON, raw)
+	}
+	return b, nil
+}
+
+func appendProtoTag(b []byte, field int, wireType byte) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+// appendProtoString appends a string field, omitting it when empty as proto3
+// does for default values.
+func appendProtoString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendProtoBytes(b, field, []byte(s))
+}
+
+func appendProtoBytes(b []byte, field int, v []byte) []byte {
+	b = appendProtoTag(b, field, 2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithRecentEntries keeps the last n entries in memory, including those the
+// level filters dropped (marked "filtered": true in their details), so a
+// crash report or admin endpoint can show what led up to a failure even
+// when the sinks only receive info and above.
+func WithRecentEntries(n int) Option {
+	return func(l *StandardLogger) {
+		if n > 0 {
+			l.recent = &recentEntries{entries: newRing[LogOutput](n)}
+		}
+	}
+}
+
+type recentEntries struct {
+	mu      sync.Mutex
+	entries *ring[LogOutput]
+}
+
+func (r *recentEntries) push(output LogOutput) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries.push(output)
+}
+
+// recordFiltered keeps an entry the level filters dropped. Filtered entries
+// skip hooks and limits, which only run for emitted entries, but are
+// redacted like them, and their tokenized keys are redacted too.
+func (l *StandardLogger) recordFiltered(output LogOutput) {
+	details := cloneMap(output.Details, 2)
+	l.stamp(details)
+	details["filtered"] = true
+	output.Details = details
+	l.currentTuning().redactOutput(&output)
+	l.redactTokenized(&output)
+	l.recent.push(output)
+}
+
+// Recent returns the entries kept by WithRecentEntries, oldest first,
+// redacted with the current configuration, so keys added to Redact since an
+// entry was kept are masked too.
+func (l *StandardLogger) Recent() []LogOutput {
+	if l.recent == nil {
+		return nil
+	}
+	l.recent.mu.Lock()
+	entries := l.recent.entries.slice()
+	l.recent.mu.Unlock()
+	t := l.currentTuning()
+	if len(t.redact) == 0 {
+		return entries
+	}
+	for i := range entries {
+		entries[i].Details = cloneMap(entries[i].Details, 0)
+		t.redactOutput(&entries[i])
+	}
+	return entries
+}
+
+// DumpRecent writes the entries kept by WithRecentEntries as JSON lines,
+// e.g. from a panic handler before exiting.
+func (l *StandardLogger) DumpRecent(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, output := range l.Recent() {
+		if err := enc.Encode(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecentHandler serves the entries kept by WithRecentEntries as JSON lines.
+func RecentHandler(l *StandardLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_ = l.DumpRecent(w)
+	})
+}
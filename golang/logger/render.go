@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Renderer turns a log argument into message text. It returns false for
+// values it does not handle so the next renderer can try.
+type Renderer func(v interface{}) (string, bool)
+
+var (
+	renderersMu sync.RWMutex
+	renderers   []Renderer
+)
+
+// RegisterRenderer adds a renderer consulted before the built-in rules.
+// Renderers registered later take precedence.
+func RegisterRenderer(r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers = append([]Renderer{r}, renderers...)
+}
+
+// renderArg renders one argument: custom renderers first, then errors and
+// Stringers by their text, then json.Marshalers as JSON, then fmt.Sprint.
+func renderArg(v interface{}) string {
+	renderersMu.RLock()
+	custom := renderers
+	renderersMu.RUnlock()
+	for _, r := range custom {
+		if s, ok := r(v); ok {
+			return s
+		}
+	}
+
+	switch v := v.(type) {
+	case error:
+		return errorText(v)
+	case fmt.Stringer:
+		return callMethod(v, v.String)
+	case json.Marshaler:
+		return callMethod(v, func() string {
+			b, err := v.MarshalJSON()
+			if err != nil {
+				return fmt.Sprint(v)
+			}
+			return string(b)
+		})
+	}
+	return fmt.Sprint(v)
+}
+
+// errorText returns err's message, like err.Error() but safe for nil
+// pointers.
+func errorText(err error) string {
+	return callMethod(err, err.Error)
+}
+
+// callMethod calls one of v's rendering methods. If it panics, v is
+// rendered by fmt.Sprint instead, which prints "<nil>" for a nil pointer
+// whose method has a value receiver, as fmt does for its own calls.
+func callMethod(v interface{}, method func() string) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = fmt.Sprint(v)
+		}
+	}()
+	return method()
+}
+
+// renderArgs joins rendered arguments with fmt.Sprint's spacing rule: a space
+// between operands when neither is a string.
+func renderArgs(args ...interface{}) string {
+	var out []byte
+	for i, arg := range args {
+		if i > 0 && !isStringArg(arg) && !isStringArg(args[i-1]) {
+			out = append(out, ' ')
+		}
+		out = append(out, renderArg(arg)...)
+	}
+	return string(out)
+}
+
+func isStringArg(arg interface{}) bool {
+	return arg != nil && reflect.TypeOf(arg).Kind() == reflect.String
+}
+
+// trailingError returns the error argument that supplies the stack: the only
+// argument, or the last one.
+func trailingError(args ...interface{}) error {
+	if len(args) == 0 {
+		return nil
+	}
+	err, _ := args[len(args)-1].(error)
+	return err
+}
+
+// errorChain lists the messages of err and each error it wraps, outermost
+// first. It returns nil when err wraps nothing.
+func errorChain(err error) []string {
+	var chain []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		chain = append(chain, errorText(e))
+	}
+	if len(chain) < 2 {
+		return nil
+	}
+	return chain
+}
+
+// joinedErrors renders the errors joined into err, by errors.Join or any
+// error with an Unwrap() []error method, found at or wrapped by err. Each is
+// an object with its message and type, plus its own errorChain and nested
+// errors. It returns nil when err joins nothing.
+func joinedErrors(err error) []interface{} {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if multi, ok := e.(interface{ Unwrap() []error }); ok {
+			return errorObjects(multi.Unwrap())
+		}
+	}
+	return nil
+}
+
+func errorObjects(errs []error) []interface{} {
+	var objects []interface{}
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		object := map[string]interface{}{
+			"message": errorText(err),
+			"type":    fmt.Sprintf("%T", err),
+		}
+		if chain := errorChain(err); chain != nil {
+			object["errorChain"] = chain
+		}
+		if nested := joinedErrors(err); nested != nil {
+			object["errors"] = nested
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
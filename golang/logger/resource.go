@@ -0,0 +1,253 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resource describes where the process runs, with OpenTelemetry semantic
+// convention keys such as "container.id", "k8s.pod.name" and
+// "cloud.region". It is a map[string]string, so it can also be an OTLP
+// sink's Resource.
+type Resource map[string]string
+
+// ResourceOptions configures DetectResource.
+type ResourceOptions struct {
+	// Cloud queries the AWS, GCP and Azure instance metadata services for
+	// the provider, region, zone, account and instance. Outside a cloud this
+	// waits for Timeout, so it is off by default.
+	Cloud bool
+	// Timeout bounds the cloud queries. Defaults to 500ms.
+	Timeout time.Duration
+}
+
+// Files and endpoints DetectResource reads, replaced in tests.
+var (
+	resourceRoot          = "/"
+	awsMetadataEndpoint   = "http://169.254.169.254"
+	gcpMetadataEndpoint   = "http://metadata.google.internal"
+	azureMetadataEndpoint = "http://169.254.169.254"
+)
+
+// DetectResource detects the process's resource once, typically at
+// startup, saving Kubernetes users a sidecar to enrich entries:
+//
+//   - host.name from the OS.
+//   - container.id from /proc/self/cgroup or /proc/self/mountinfo.
+//   - k8s.pod.name, k8s.namespace.name, k8s.node.name and k8s.pod.uid from
+//     the downward API, exposed as the POD_NAME, POD_NAMESPACE, NODE_NAME
+//     and POD_UID environment variables, falling back to the hostname, the
+//     service account's namespace and the pod's cgroup.
+//   - cloud.* and host.id from instance metadata, when opts.Cloud is set.
+//
+// Add it to every entry with its Hook:
+//
+//	resource := logger.DetectResource(ctx, logger.ResourceOptions{Cloud: true})
+//	l := logger.New(logger.WithHooks(resource.Hook()))
+func DetectResource(ctx context.Context, opts ResourceOptions) Resource {
+	r := Resource{}
+	if host, err := os.Hostname(); err == nil {
+		r["host.name"] = host
+	}
+	cgroup := readResourceFile("proc/self/cgroup")
+	if id := containerID(cgroup, readResourceFile("proc/self/mountinfo")); id != "" {
+		r["container.id"] = id
+	}
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" || os.Getenv("POD_NAME") != "" {
+		r.setIf("k8s.pod.name", firstNonEmpty(os.Getenv("POD_NAME"), r["host.name"]))
+		r.setIf("k8s.namespace.name", firstNonEmpty(os.Getenv("POD_NAMESPACE"),
+			strings.TrimSpace(readResourceFile("var/run/secrets/kubernetes.io/serviceaccount/namespace"))))
+		r.setIf("k8s.node.name", os.Getenv("NODE_NAME"))
+		r.setIf("k8s.pod.uid", firstNonEmpty(os.Getenv("POD_UID"), podUID(cgroup)))
+	}
+
+	if opts.Cloud {
+		timeout := opts.Timeout
+		if timeout <= 0 {
+			timeout = 500 * time.Millisecond
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		for k, v := range detectCloud(ctx) {
+			r[k] = v
+		}
+	}
+	return r
+}
+
+// Hook returns a Hook adding the resource to entries as details.resource.
+func (r Resource) Hook() Hook {
+	return func(_ context.Context, output *LogOutput) {
+		if len(r) > 0 {
+			output.Details["resource"] = map[string]string(r)
+		}
+	}
+}
+
+func (r Resource) setIf(key, value string) {
+	if value != "" {
+		r[key] = value
+	}
+}
+
+func readResourceFile(path string) string {
+	raw, err := os.ReadFile(filepath.Join(resourceRoot, path))
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+var (
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+	// mountinfoIDPattern finds the container directory runtimes bind-mount
+	// files such as /etc/hostname from, which cgroup v2 namespaces hide.
+	mountinfoIDPattern = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+	podUIDPattern      = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+func containerID(cgroup, mountinfo string) string {
+	for _, line := range strings.Split(cgroup, "\n") {
+		if id := containerIDPattern.FindString(line); id != "" {
+			return id
+		}
+	}
+	if m := mountinfoIDPattern.FindStringSubmatch(mountinfo); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func podUID(cgroup string) string {
+	if m := podUIDPattern.FindStringSubmatch(cgroup); m != nil {
+		return strings.ReplaceAll(m[1], "_", "-")
+	}
+	return ""
+}
+
+// detectCloud queries the providers' metadata services concurrently,
+// returning the first that answers.
+func detectCloud(ctx context.Context) Resource {
+	detectors := []func(context.Context) Resource{detectAWS, detectGCP, detectAzure}
+	results := make([]Resource, len(detectors))
+	var wg sync.WaitGroup
+	for i, detect := range detectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = detect(ctx)
+		}()
+	}
+	wg.Wait()
+	for _, r := range results {
+		if len(r) > 0 {
+			return r
+		}
+	}
+	return nil
+}
+
+func metadataGet(ctx context.Context, method, url string, header map[string]string) ([]byte, bool) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, false
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	return body, err == nil && resp.StatusCode == http.StatusOK
+}
+
+// detectAWS reads the EC2 instance identity document with IMDSv2.
+func detectAWS(ctx context.Context) Resource {
+	token, ok := metadataGet(ctx, http.MethodPut, awsMetadataEndpoint+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if !ok {
+		return nil
+	}
+	raw, ok := metadataGet(ctx, http.MethodGet, awsMetadataEndpoint+"/latest/dynamic/instance-identity/document",
+		map[string]string{"X-aws-ec2-metadata-token": string(token)})
+	var doc struct {
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		AccountID        string `json:"accountId"`
+	}
+	if !ok || json.Unmarshal(raw, &doc) != nil {
+		return nil
+	}
+	r := Resource{"cloud.provider": "aws", "cloud.platform": "aws_ec2"}
+	r.setIf("cloud.region", doc.Region)
+	r.setIf("cloud.availability_zone", doc.AvailabilityZone)
+	r.setIf("cloud.account.id", doc.AccountID)
+	r.setIf("host.id", doc.InstanceID)
+	r.setIf("host.type", doc.InstanceType)
+	return r
+}
+
+// detectGCP reads the Compute Engine metadata server.
+func detectGCP(ctx context.Context) Resource {
+	header := map[string]string{"Metadata-Flavor": "Google"}
+	raw, ok := metadataGet(ctx, http.MethodGet, gcpMetadataEndpoint+"/computeMetadata/v1/instance/?recursive=true", header)
+	var instance struct {
+		ID          json.Number `json:"id"`
+		Zone        string      `json:"zone"`
+		MachineType string      `json:"machineType"`
+	}
+	if !ok || json.Unmarshal(raw, &instance) != nil {
+		return nil
+	}
+	// Zones and machine types are resource paths, e.g.
+	// "projects/123/zones/us-central1-a".
+	zone := instance.Zone[strings.LastIndex(instance.Zone, "/")+1:]
+	r := Resource{"cloud.provider": "gcp", "cloud.platform": "gcp_compute_engine"}
+	r.setIf("cloud.availability_zone", zone)
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		r["cloud.region"] = zone[:i]
+	}
+	r.setIf("host.id", instance.ID.String())
+	r.setIf("host.type", instance.MachineType[strings.LastIndex(instance.MachineType, "/")+1:])
+	if project, ok := metadataGet(ctx, http.MethodGet, gcpMetadataEndpoint+"/computeMetadata/v1/project/project-id", header); ok {
+		r.setIf("cloud.account.id", string(project))
+	}
+	return r
+}
+
+// detectAzure reads the Azure Instance Metadata Service.
+func detectAzure(ctx context.Context) Resource {
+	raw, ok := metadataGet(ctx, http.MethodGet, azureMetadataEndpoint+"/metadata/instance/compute?api-version=2021-02-01",
+		map[string]string{"Metadata": "true"})
+	var compute struct {
+		VMID           string `json:"vmId"`
+		VMSize         string `json:"vmSize"`
+		Location       string `json:"location"`
+		Zone           string `json:"zone"`
+		SubscriptionID string `json:"subscriptionId"`
+	}
+	if !ok || json.Unmarshal(raw, &compute) != nil || compute.VMID == "" {
+		return nil
+	}
+	r := Resource{"cloud.provider": "azure", "cloud.platform": "azure_vm"}
+	r.setIf("cloud.region", compute.Location)
+	r.setIf("cloud.availability_zone", compute.Zone)
+	r.setIf("cloud.account.id", compute.SubscriptionID)
+	r.setIf("host.id", compute.VMID)
+	r.setIf("host.type", compute.VMSize)
+	return r
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectResource(t *testing.T) {
+	root := t.TempDir()
+	const id = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	files := map[string]string{
+		"proc/self/cgroup": "0::/kubepods.slice/kubepods-pod1b2c3d4e_0000_1111_2222_333344445555.slice/cri-containerd-" + id + ".scope\n",
+		"var/run/secrets/kubernetes.io/serviceaccount/namespace": "payments\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	aws := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/api/token":
+			w.Write([]byte("token"))
+		case "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"instanceId":"i-123","region":"eu-west-1","availabilityZone":"eu-west-1a","accountId":"42"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer aws.Close()
+	previous := [...]string{resourceRoot, awsMetadataEndpoint, gcpMetadataEndpoint, azureMetadataEndpoint}
+	defer func() {
+		resourceRoot, awsMetadataEndpoint, gcpMetadataEndpoint, azureMetadataEndpoint = previous[0], previous[1], previous[2], previous[3]
+	}()
+	resourceRoot, awsMetadataEndpoint, gcpMetadataEndpoint, azureMetadataEndpoint = root, aws.URL, aws.URL, aws.URL
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAME", "api-7f9c")
+	t.Setenv("NODE_NAME", "")
+
+	r := DetectResource(context.Background(), ResourceOptions{Cloud: true})
+	want := map[string]string{
+		"container.id":       id,
+		"k8s.pod.name":       "api-7f9c",
+		"k8s.namespace.name": "payments",
+		"k8s.pod.uid":        "1b2c3d4e-0000-1111-2222-333344445555",
+		"cloud.provider":     "aws",
+		"cloud.region":       "eu-west-1",
+		"host.id":            "i-123",
+	}
+	for k, v := range want {
+		if r[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, r[k])
+		}
+	}
+	if _, ok := r["k8s.node.name"]; ok {
+		t.Error("Expected no node name without NODE_NAME")
+	}
+
+	sink := &recordingSink{}
+	New(WithSinks(sink), WithHooks(r.Hook())).Info(context.Background(), "Started")
+	if got := sink.entries[0].Details["resource"].(map[string]string)["k8s.pod.name"]; got != "api-7f9c" {
+		t.Errorf("Expected the resource on the entry, got %v", sink.entries[0].Details["resource"])
+	}
+}
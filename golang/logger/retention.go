@@ -0,0 +1,40 @@
+package logger
+
+import "context"
+
+// RetentionRule assigns a retention class to the entries it matches. Every
+// set criterion must match.
+type RetentionRule struct {
+	// Category matches this category and those nested below it.
+	Category string
+	// Tags must all be present on the entry.
+	Tags []string
+	// MinLevel matches entries at or above this level.
+	MinLevel LogLevel
+	// Class is stamped as details.retention, e.g. "30d" or "audit-7y".
+	Class string
+}
+
+// Retention returns a Hook stamping each entry with details.retention, the
+// class of the first matching rule or defaultClass when none matches (no
+// field when defaultClass is empty), so downstream pipelines can apply
+// lifecycle policies decided at the source:
+//
+//	logger.WithHooks(logger.Retention("30d",
+//		logger.RetentionRule{Category: "audit", Class: "7y"},
+//		logger.RetentionRule{Tags: []string{"debug-trace"}, Class: "3d"},
+//	))
+func Retention(defaultClass string, rules ...RetentionRule) Hook {
+	return func(_ context.Context, output *LogOutput) {
+		class := defaultClass
+		for _, rule := range rules {
+			if (AlertRule{MinLevel: rule.MinLevel, Tags: rule.Tags, Category: rule.Category}).Matches(*output) {
+				class = rule.Class
+				break
+			}
+		}
+		if class != "" {
+			output.Details["retention"] = class
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"strconv"
+)
+
+// Retry calls fn up to attempts times until it succeeds. Each attempt runs
+// in its own scope whose entries carry "attempt" and "maxAttempts" metadata.
+// If every attempt fails, one Error entry summarizing the failure is logged
+// and the last error is returned. Retry stops early when ctx is done, but
+// fn is always attempted at least once, even when attempts is less than 1
+// or ctx is already done.
+func Retry[T any](ctx context.Context, attempts int, fn func(context.Context) (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+	attempts = max(attempts, 1)
+	maxAttempts := strconv.Itoa(attempts)
+	made := 0
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 && ctx.Err() != nil {
+			break
+		}
+		made = attempt
+		attemptCtx := GetLogContext(ctx).WithMetadata(map[string]string{
+			"attempt":     strconv.Itoa(attempt),
+			"maxAttempts": maxAttempts,
+		})
+		result, err = WithLogContext(ctx, attemptCtx, fn)
+		if err == nil {
+			return result, nil
+		}
+	}
+
+	summaryCtx := GetLogContext(ctx).WithMetadata(map[string]string{
+		"attempts":    strconv.Itoa(made),
+		"maxAttempts": maxAttempts,
+	})
+	_, _ = WithLogContext(ctx, summaryCtx, func(ctx context.Context) (struct{}, error) {
+		Error(ctx, "Failed after "+strconv.Itoa(made)+" attempts:", err)
+		return struct{}{}, nil
+	})
+	return result, err
+}
@@ -0,0 +1,62 @@
+package logger
+
+// ring is a fixed-capacity FIFO that overwrites its oldest element when full.
+type ring[T any] struct {
+	items []T
+	start int
+	size  int
+}
+
+func newRing[T any](capacity int) *ring[T] {
+	return &ring[T]{items: make([]T, capacity)}
+}
+
+// push appends v, reporting whether an older element was overwritten.
+func (r *ring[T]) push(v T) (overwrote bool) {
+	if len(r.items) == 0 {
+		return true
+	}
+	end := (r.start + r.size) % len(r.items)
+	r.items[end] = v
+	if r.size == len(r.items) {
+		r.start = (r.start + 1) % len(r.items)
+		return true
+	}
+	r.size++
+	return false
+}
+
+// pop removes and returns the oldest element.
+func (r *ring[T]) pop() (T, bool) {
+	var zero T
+	if r.size == 0 {
+		return zero, false
+	}
+	v := r.items[r.start]
+	r.items[r.start] = zero
+	r.start = (r.start + 1) % len(r.items)
+	r.size--
+	return v, true
+}
+
+// peek returns the oldest element without removing it.
+func (r *ring[T]) peek() (T, bool) {
+	var zero T
+	if r.size == 0 {
+		return zero, false
+	}
+	return r.items[r.start], true
+}
+
+// slice returns the elements oldest first.
+func (r *ring[T]) slice() []T {
+	out := make([]T, r.size)
+	for i := range out {
+		out[i] = r.items[(r.start+i)%len(r.items)]
+	}
+	return out
+}
+
+func (r *ring[T]) len() int {
+	return r.size
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"reflect"
+)
+
+// Route sends the entries it matches to Sinks. Every set criterion must
+// match.
+type Route struct {
+	// Levels lists the levels routed; empty matches every level.
+	Levels []LogLevel
+	// Category matches this category and those nested below it.
+	Category string
+	// Match, when set, is consulted last for anything else.
+	Match func(output LogOutput) bool
+
+	Sinks []Sink
+}
+
+func (r Route) matches(output LogOutput) bool {
+	if len(r.Levels) > 0 {
+		found := false
+		for _, level := range r.Levels {
+			if level == output.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Category != "" {
+		category, _ := output.Details["category"].(string)
+		if !CategoryHasPrefix(category, r.Category) {
+			return false
+		}
+	}
+	return r.Match == nil || r.Match(output)
+}
+
+// RouterSink sends each entry to the sinks of the first route that matches
+// it, or to Fallback when none does (dropping it when Fallback is nil).
+// Order routes from most to least specific:
+//
+//	logger.NewRouterSink(nil,
+//		logger.Route{Category: "audit", Sinks: []logger.Sink{auditFile}},
+//		logger.Route{Levels: []logger.LogLevel{logger.LevelError}, Sinks: []logger.Sink{pagerDuty, file}},
+//		logger.Route{Sinks: []logger.Sink{file}},
+//	)
+type RouterSink struct {
+	Fallback Sink
+
+	routes []Route
+}
+
+func NewRouterSink(fallback Sink, routes ...Route) *RouterSink {
+	return &RouterSink{Fallback: fallback, routes: routes}
+}
+
+func (s *RouterSink) Write(output LogOutput) error {
+	for _, route := range s.routes {
+		if !route.matches(output) {
+			continue
+		}
+		var errs []error
+		for _, sink := range route.Sinks {
+			if err := sink.Write(output); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	if s.Fallback == nil {
+		return nil
+	}
+	return s.Fallback.Write(output)
+}
+
+// sinks returns every distinct sink the router writes to. Sinks that cannot
+// be compared are assumed distinct.
+func (s *RouterSink) sinks() []Sink {
+	var all []Sink
+	seen := make(map[Sink]bool)
+	add := func(sink Sink) {
+		if sink == nil {
+			return
+		}
+		if reflect.ValueOf(sink).Comparable() {
+			if seen[sink] {
+				return
+			}
+			seen[sink] = true
+		}
+		all = append(all, sink)
+	}
+	for _, route := range s.routes {
+		for _, sink := range route.Sinks {
+			add(sink)
+		}
+	}
+	add(s.Fallback)
+	return all
+}
+
+// Reopen reopens every routed sink that implements Reopener.
+func (s *RouterSink) Reopen() error {
+	var errs []error
+	for _, sink := range s.sinks() {
+		if r, ok := sink.(Reopener); ok {
+			errs = append(errs, r.Reopen())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes every routed sink that implements Flush.
+func (s *RouterSink) Flush() error {
+	var errs []error
+	for _, sink := range s.sinks() {
+		if f, ok := sink.(interface{ Flush() error }); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every routed sink that implements io.Closer.
+func (s *RouterSink) Close() error {
+	var errs []error
+	for _, sink := range s.sinks() {
+		if c, ok := sink.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
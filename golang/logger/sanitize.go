@@ -0,0 +1,224 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SanitizePolicy decides how SanitizingEncoder neutralizes control
+// characters and invalid UTF-8.
+type SanitizePolicy int
+
+const (
+	// SanitizeEscape renders them visibly: a newline as `\n`, ESC as `\x1b`,
+	// an invalid byte as `\xff`.
+	SanitizeEscape SanitizePolicy = iota
+	// SanitizeStrip removes them, along with whole ANSI escape sequences.
+	SanitizeStrip
+	// SanitizeReplace substitutes U+FFFD.
+	SanitizeReplace
+)
+
+// SanitizingEncoder wraps an encoder (JSONEncoder by default) and neutralizes
+// control characters, ANSI escape sequences, Unicode line separators and
+// bidirectional overrides, and invalid UTF-8 in every string of the entry,
+// including map keys, before encoding. User-controlled strings then cannot
+// forge entries, recolor a terminal or hide text. Stack traces keep their
+// line breaks.
+type SanitizingEncoder struct {
+	Encoder Encoder
+	Policy  SanitizePolicy
+}
+
+func (e SanitizingEncoder) Encode(output LogOutput) ([]byte, error) {
+	s := sanitizer{policy: e.Policy}
+	output.Message = s.value(output.Message)
+	output.Data = s.value(output.Data)
+	output.Event = s.string(output.Event)
+	output.SessionID = s.string(output.SessionID)
+	output.OperationID = s.string(output.OperationID)
+	output.ParentOperationID = s.string(output.ParentOperationID)
+	output.UserID = s.string(output.UserID)
+	output.TenantID = s.string(output.TenantID)
+	output.TraceID = s.string(output.TraceID)
+
+	details := make(map[string]interface{}, len(output.Details))
+	for k, v := range output.Details {
+		if stack, ok := v.(string); ok && k == "stack" {
+			lines := strings.Split(stack, "\n")
+			for i, line := range lines {
+				lines[i] = s.string(line)
+			}
+			details[k] = strings.Join(lines, "\n")
+			continue
+		}
+		details[s.string(k)] = s.value(v)
+	}
+	output.Details = details
+	return e.inner().Encode(output)
+}
+
+func (e SanitizingEncoder) inner() Encoder {
+	if e.Encoder == nil {
+		return JSONEncoder{}
+	}
+	return e.Encoder
+}
+
+// Unwrap returns the wrapped encoder, so framing follows it.
+func (e SanitizingEncoder) Unwrap() Encoder {
+	return e.inner()
+}
+
+type sanitizer struct {
+	policy SanitizePolicy
+}
+
+// value sanitizes the strings in v. Types other than the generic JSON
+// shapes are converted to them first, so strings in structs are covered.
+func (s sanitizer) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, json.Number:
+		return v
+	case string:
+		return s.string(v)
+	case []string:
+		clean := make([]string, len(v))
+		for i, item := range v {
+			clean[i] = s.string(item)
+		}
+		return clean
+	case map[string]string:
+		clean := make(map[string]string, len(v))
+		for k, item := range v {
+			clean[s.string(k)] = s.string(item)
+		}
+		return clean
+	case map[string]interface{}:
+		clean := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			clean[s.string(k)] = s.value(item)
+		}
+		return clean
+	case []interface{}:
+		clean := make([]interface{}, len(v))
+		for i, item := range v {
+			clean[i] = s.value(item)
+		}
+		return clean
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return v
+	}
+	return s.value(generic)
+}
+
+func (s sanitizer) string(str string) string {
+	if isClean(str) {
+		return str
+	}
+	var b strings.Builder
+	for i := 0; i < len(str); {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			switch s.policy {
+			case SanitizeEscape:
+				fmt.Fprintf(&b, `\x%02x`, str[i])
+			case SanitizeReplace:
+				b.WriteRune(utf8.RuneError)
+			}
+		case r == 0x1b && s.policy == SanitizeStrip:
+			size += ansiSequenceLen(str[i+size:])
+		case unsafeRune(r):
+			switch s.policy {
+			case SanitizeEscape:
+				b.WriteString(escapeRune(r))
+			case SanitizeReplace:
+				b.WriteRune(utf8.RuneError)
+			}
+		default:
+			b.WriteString(str[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+func isClean(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if c := str[i]; c < 0x20 && c != '\t' || c == 0x7f || c >= 0x80 {
+			return isCleanUnicode(str)
+		}
+	}
+	return true
+}
+
+func isCleanUnicode(str string) bool {
+	if !utf8.ValidString(str) {
+		return false
+	}
+	for _, r := range str {
+		if unsafeRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// unsafeRune reports control characters other than tab, line and paragraph
+// separators, and bidirectional embeddings, overrides and isolates.
+func unsafeRune(r rune) bool {
+	switch {
+	case r == '\t':
+		return false
+	case r < 0x20, r >= 0x7f && r <= 0x9f:
+		return true
+	case r == 0x2028, r == 0x2029:
+		return true
+	case r >= 0x202a && r <= 0x202e, r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
+
+func escapeRune(r rune) string {
+	switch r {
+	case '\n':
+		return `\n`
+	case '\r':
+		return `\r`
+	}
+	if r <= 0xff {
+		return fmt.Sprintf(`\x%02x`, r)
+	}
+	return fmt.Sprintf(`\u%04x`, r)
+}
+
+// ansiSequenceLen returns the length of the rest of an ANSI escape sequence
+// following ESC: a CSI sequence ("[31m"), or a single character.
+func ansiSequenceLen(rest string) int {
+	if rest == "" {
+		return 0
+	}
+	if rest[0] != '[' {
+		return 1
+	}
+	for i := 1; i < len(rest); i++ {
+		if c := rest[i]; c >= 0x40 && c <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(rest)
+}
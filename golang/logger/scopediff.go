@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+var logScopeDiffs atomic.Bool
+
+// LogScopeDiffs turns on a debug entry when each WithLogContext scope starts
+// and ends, with details.contextDiff listing the tags, metadata and fields
+// the scope added, changed or removed. It helps find out why an entry is
+// missing an expected field, e.g. because WithLogContext replaced the outer
+// context instead of merging it. Scopes that change nothing log nothing.
+func LogScopeDiffs(enabled bool) {
+	logScopeDiffs.Store(enabled)
+}
+
+// ContextDiff is the change from one LogContext to another. Operation IDs,
+// which change with every scope, and metadata providers are not compared.
+type ContextDiff struct {
+	AddedTags   []string
+	RemovedTags []string
+	// Metadata holds added and changed metadata with the new values.
+	Metadata        map[string]string
+	RemovedMetadata []string
+	// Fields holds changed context fields ("category", "sessionId",
+	// "userId", "tenantId", "traceId", "minLevel", "errorClass") with the new
+	// value, empty when unset.
+	Fields map[string]string
+}
+
+// DiffLogContext returns the change from from to to.
+func DiffLogContext(from, to *LogContext) ContextDiff {
+	var diff ContextDiff
+	diff.AddedTags = to.data.Tags.Without(from.data.Tags.tags...).Slice()
+	diff.RemovedTags = from.data.Tags.Without(to.data.Tags.tags...).Slice()
+	for k, v := range to.data.Metadata {
+		if old, ok := from.data.Metadata[k]; !ok || old != v {
+			if diff.Metadata == nil {
+				diff.Metadata = make(map[string]string)
+			}
+			diff.Metadata[k] = v
+		}
+	}
+	for _, k := range sortedKeys(from.data.Metadata) {
+		if _, ok := to.data.Metadata[k]; !ok {
+			diff.RemovedMetadata = append(diff.RemovedMetadata, k)
+		}
+	}
+	for _, field := range []struct {
+		key      string
+		from, to string
+	}{
+		{"category", from.data.Category, to.data.Category},
+		{"sessionId", from.data.SessionID, to.data.SessionID},
+		{"userId", from.data.UserID, to.data.UserID},
+		{"tenantId", from.data.TenantID, to.data.TenantID},
+		{"traceId", from.data.TraceID, to.data.TraceID},
+		{"minLevel", string(from.data.MinLevel), string(to.data.MinLevel)},
+		{"errorClass", from.data.ErrorClass, to.data.ErrorClass},
+	} {
+		if field.from != field.to {
+			if diff.Fields == nil {
+				diff.Fields = make(map[string]string)
+			}
+			diff.Fields[field.key] = field.to
+		}
+	}
+	return diff
+}
+
+// Empty reports whether the contexts were equal.
+func (d ContextDiff) Empty() bool {
+	return len(d.AddedTags) == 0 && len(d.RemovedTags) == 0 && len(d.Metadata) == 0 &&
+		len(d.RemovedMetadata) == 0 && len(d.Fields) == 0
+}
+
+func (d ContextDiff) details() map[string]interface{} {
+	details := make(map[string]interface{})
+	if len(d.AddedTags) > 0 {
+		details["addedTags"] = d.AddedTags
+	}
+	if len(d.RemovedTags) > 0 {
+		details["removedTags"] = d.RemovedTags
+	}
+	if len(d.Metadata) > 0 {
+		details["metadata"] = d.Metadata
+	}
+	if len(d.RemovedMetadata) > 0 {
+		details["removedMetadata"] = d.RemovedMetadata
+	}
+	if len(d.Fields) > 0 {
+		details["fields"] = d.Fields
+	}
+	return details
+}
+
+// traceScopeDiff logs the change a scope makes on entry, and the change
+// undone on exit, both from inside the scope so they carry its operation ID.
+func traceScopeDiff(ctx context.Context, outer, inner *LogContext) (done func()) {
+	if !logScopeDiffs.Load() {
+		return func() {}
+	}
+	logScopeDiff(ctx, "entering scope", DiffLogContext(outer, inner))
+	return func() { logScopeDiff(ctx, "leaving scope", DiffLogContext(inner, outer)) }
+}
+
+func logScopeDiff(ctx context.Context, message string, diff ContextDiff) {
+	if diff.Empty() {
+		return
+	}
+	output := newEntry(ctx, LevelDebug, message)
+	output.Details["contextDiff"] = diff.details()
+	Default().Emit(ctx, output)
+}
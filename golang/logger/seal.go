@@ -0,0 +1,274 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// Sealer encrypts encoded entries for SealedEncoder.
+type Sealer interface {
+	Seal(plaintext []byte) ([]byte, error)
+}
+
+// Opener decrypts what the matching Sealer encrypted.
+type Opener interface {
+	Open(sealed []byte) ([]byte, error)
+}
+
+// SealedEncoder encrypts the entries Encoder encodes, for sinks holding
+// entries that only the key's owners, such as the security team, may read.
+// Each entry is written as a JSON line {"sealed":"<base64>"}, so pipelines
+// still see one entry per line; Unseal, or logview -key, recovers it.
+type SealedEncoder struct {
+	// Encoder encodes entries before they are sealed; JSONEncoder when nil.
+	Encoder Encoder
+	Sealer  Sealer
+}
+
+type sealedLine struct {
+	Sealed []byte `json:"sealed"`
+}
+
+func (e SealedEncoder) Encode(output LogOutput) ([]byte, error) {
+	enc := e.Encoder
+	if enc == nil {
+		enc = JSONEncoder{}
+	}
+	encoded, err := enc.Encode(output)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := e.Sealer.Seal(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealedLine{Sealed: sealed})
+}
+
+// Unseal decrypts a line SealedEncoder wrote, returning the entry as its
+// Encoder encoded it. Lines that are not sealed are reported with sealed
+// false and returned unchanged.
+func Unseal(o Opener, line []byte) (plaintext []byte, sealed bool, err error) {
+	var v sealedLine
+	if !bytes.HasPrefix(bytes.TrimSpace(line), []byte(`{"sealed":`)) || json.Unmarshal(line, &v) != nil {
+		return line, false, nil
+	}
+	plaintext, err = o.Open(v.Sealed)
+	return plaintext, true, err
+}
+
+var errSealed = errors.New("logger: sealed entry is malformed or was sealed with another key")
+
+const sealInfo = "context-based-logger sealed entry"
+
+// boxSealer seals to an X25519 public key with an ephemeral key per entry.
+type boxSealer struct {
+	recipient *ecdh.PublicKey
+}
+
+// NewBoxSealer seals entries so only the holder of recipient's private key
+// can open them, with NewBoxOpener: each entry gets an ephemeral X25519 key,
+// and is encrypted with AES-256-GCM under a key derived from the two with
+// HKDF-SHA256. Generate the key pair once:
+//
+//	key, _ := ecdh.X25519().GenerateKey(rand.Reader)
+//	// keep key.Bytes() secret; configure sealers with key.PublicKey().Bytes()
+func NewBoxSealer(recipient *ecdh.PublicKey) Sealer {
+	return boxSealer{recipient: recipient}
+}
+
+func (s boxSealer) Seal(plaintext []byte) ([]byte, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(s.recipient)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := boxAEAD(shared, ephemeral.PublicKey(), s.recipient)
+	if err != nil {
+		return nil, err
+	}
+	out := ephemeral.PublicKey().Bytes()
+	// Every entry has a key of its own, so a zero nonce is never reused.
+	return aead.Seal(out, make([]byte, aead.NonceSize()), plaintext, nil), nil
+}
+
+type boxOpener struct {
+	key *ecdh.PrivateKey
+}
+
+// NewBoxOpener opens entries NewBoxSealer sealed to key's public key.
+func NewBoxOpener(key *ecdh.PrivateKey) Opener {
+	return boxOpener{key: key}
+}
+
+func (o boxOpener) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < 32 {
+		return nil, errSealed
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(sealed[:32])
+	if err != nil {
+		return nil, errSealed
+	}
+	shared, err := o.key.ECDH(ephemeral)
+	if err != nil {
+		return nil, errSealed
+	}
+	aead, err := boxAEAD(shared, ephemeral, o.key.PublicKey())
+	if err != nil {
+		return nil, errSealed
+	}
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), sealed[32:], nil)
+	if err != nil {
+		return nil, errSealed
+	}
+	return plaintext, nil
+}
+
+// boxAEAD derives the entry's cipher from the shared secret, bound to the
+// ephemeral and recipient keys.
+func boxAEAD(shared []byte, ephemeral, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(ephemeral.Bytes(), recipient.Bytes()...)
+	key, err := hkdf.Key(sha256.New, shared, salt, sealInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	return gcm(key)
+}
+
+func gcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyWrapper wraps and unwraps data keys with a key management service, e.g.
+// AWS KMS Encrypt and Decrypt or Vault's transit engine.
+type KeyWrapper interface {
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// EnvelopeSealer encrypts entries with AES-256-GCM under a data key wrapped
+// by a KeyWrapper. The wrapped key travels with each entry, so the KMS is
+// called once per data key rather than per entry, and opening needs only
+// access to the KMS.
+type EnvelopeSealer struct {
+	kms KeyWrapper
+
+	mu      sync.Mutex
+	aead    cipher.AEAD
+	wrapped []byte
+}
+
+// NewEnvelopeSealer generates a data key and wraps it with kms.
+func NewEnvelopeSealer(kms KeyWrapper) (*EnvelopeSealer, error) {
+	s := &EnvelopeSealer{kms: kms}
+	if err := s.Rotate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Rotate switches to a new data key, e.g. periodically or after a number of
+// entries, to limit what one unwrapped key exposes.
+func (s *EnvelopeSealer) Rotate() error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	wrapped, err := s.kms.WrapKey(key)
+	if err != nil {
+		return err
+	}
+	if len(wrapped) > 0xffff {
+		return errors.New("logger: wrapped data key is too long")
+	}
+	aead, err := gcm(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.aead, s.wrapped = aead, wrapped
+	s.mu.Unlock()
+	return nil
+}
+
+// Seal writes the wrapped key's length and the wrapped key, then a random
+// nonce and the ciphertext.
+func (s *EnvelopeSealer) Seal(plaintext []byte) ([]byte, error) {
+	s.mu.Lock()
+	aead, wrapped := s.aead, s.wrapped
+	s.mu.Unlock()
+
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(wrapped)))
+	out = append(out, wrapped...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// EnvelopeOpener opens entries EnvelopeSealer sealed, unwrapping each data
+// key with the KMS once.
+type EnvelopeOpener struct {
+	kms KeyWrapper
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+func NewEnvelopeOpener(kms KeyWrapper) *EnvelopeOpener {
+	return &EnvelopeOpener{kms: kms, keys: map[string]cipher.AEAD{}}
+}
+
+func (o *EnvelopeOpener) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < 2 {
+		return nil, errSealed
+	}
+	n := int(binary.BigEndian.Uint16(sealed))
+	if len(sealed) < 2+n {
+		return nil, errSealed
+	}
+	wrapped, rest := sealed[2:2+n], sealed[2+n:]
+
+	o.mu.Lock()
+	aead, ok := o.keys[string(wrapped)]
+	o.mu.Unlock()
+	if !ok {
+		key, err := o.kms.UnwrapKey(wrapped)
+		if err != nil {
+			return nil, err
+		}
+		if aead, err = gcm(key); err != nil {
+			return nil, err
+		}
+		o.mu.Lock()
+		o.keys[string(wrapped)] = aead
+		o.mu.Unlock()
+	}
+
+	if len(rest) < aead.NonceSize() {
+		return nil, errSealed
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errSealed
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestSealedEncoder_Box(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	l := New(WithSinks(&WriterSink{W: &buf, Encoder: SealedEncoder{Sealer: NewBoxSealer(key.PublicKey())}}))
+	l.Info(context.Background(), "card 4111 declined")
+
+	line := bytes.TrimSpace(buf.Bytes())
+	if bytes.Contains(line, []byte("declined")) || !bytes.HasPrefix(line, []byte(`{"sealed":"`)) {
+		t.Fatalf("Expected a sealed line, got %s", line)
+	}
+	plaintext, sealed, err := Unseal(NewBoxOpener(key), line)
+	if err != nil || !sealed {
+		t.Fatalf("Expected the line to open, got sealed=%v err=%v", sealed, err)
+	}
+	if got := decodeEntry(t, string(plaintext))["message"]; got != "card 4111 declined" {
+		t.Errorf("Expected the original entry, got %v", got)
+	}
+
+	other, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	if _, _, err := Unseal(NewBoxOpener(other), line); err == nil {
+		t.Error("Expected another key to fail to open the entry")
+	}
+	if plain, sealed, _ := Unseal(NewBoxOpener(key), []byte(`{"level":"info"}`)); sealed || string(plain) != `{"level":"info"}` {
+		t.Error("Expected unsealed lines to pass through")
+	}
+}
+
+// xorKMS stands in for a KMS, counting the keys it unwraps.
+type xorKMS struct{ unwrapped int }
+
+func (k *xorKMS) WrapKey(dataKey []byte) ([]byte, error) { return xor(dataKey), nil }
+
+func (k *xorKMS) UnwrapKey(wrapped []byte) ([]byte, error) {
+	k.unwrapped++
+	return xor(wrapped), nil
+}
+
+func xor(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5a
+	}
+	return out
+}
+
+func TestEnvelopeSealer(t *testing.T) {
+	kms := &xorKMS{}
+	sealer, err := NewEnvelopeSealer(kms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opener := NewEnvelopeOpener(kms)
+
+	var sealed [][]byte
+	for _, text := range []string{"one", "two"} {
+		s, err := sealer.Seal([]byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed = append(sealed, s)
+	}
+	if err := sealer.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := sealer.Seal([]byte("three"))
+	sealed = append(sealed, s)
+
+	var opened []string
+	for _, s := range sealed {
+		plaintext, err := opener.Open(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opened = append(opened, string(plaintext))
+	}
+	if strings.Join(opened, ",") != "one,two,three" {
+		t.Errorf("Unexpected plaintexts %v", opened)
+	}
+	if kms.unwrapped != 2 {
+		t.Errorf("Expected one unwrap per data key, got %d", kms.unwrapped)
+	}
+	if _, err := opener.Open(sealed[0][:len(sealed[0])-1]); err == nil {
+		t.Error("Expected a truncated entry to fail")
+	}
+}
@@ -0,0 +1,296 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CEFEncoder renders entries in ArcSight's Common Event Format:
+//
+//	CEF:0|Vendor|Product|Version|category|message|severity|extensions
+//
+// The event name, or else the category, is the event class ID. The
+// extensions map the timestamp to rt, the category to cat, the user to suser
+// and the error chain to reason; the session, operation, trace and tenant
+// IDs and the tags take cs1 to cs5, labelled with their names; metadata and
+// other details become ArcSight additional data, "ad.<key>".
+type CEFEncoder struct {
+	// Vendor and Product identify the device; they default to
+	// "context-based-logger" and the executable's name.
+	Vendor  string
+	Product string
+	Version string
+}
+
+func (e CEFEncoder) Encode(output LogOutput) ([]byte, error) {
+	message, err := siemMessage(output)
+	if err != nil {
+		return nil, err
+	}
+	vendor, product := siemDevice(e.Vendor, e.Product)
+	var b strings.Builder
+	b.WriteString("CEF:0")
+	for _, field := range []string{vendor, product, e.Version, siemEventID(output), truncateRunes(message, 512)} {
+		b.WriteByte('|')
+		b.WriteString(cefHeader(field))
+	}
+	b.WriteByte('|')
+	b.WriteString(strconv.Itoa(siemSeverity(output.Level)))
+	b.WriteByte('|')
+
+	ext := &siemExtensions{sep: " ", escape: cefValue}
+	if ms, ok := siemTime(output); ok {
+		ext.add("rt", strconv.FormatInt(ms.UnixMilli(), 10))
+	}
+	ext.add("msg", message)
+	if category, ok := output.Details["category"].(string); ok {
+		ext.add("cat", category)
+	}
+	ext.add("suser", output.UserID)
+	if chain, ok := output.Details["errorChain"].([]string); ok && len(chain) > 0 {
+		ext.add("reason", chain[0])
+	}
+	custom := 1
+	for _, field := range siemContext(output) {
+		if field[1] != "" {
+			n := strconv.Itoa(custom)
+			ext.add("cs"+n, field[1])
+			ext.add("cs"+n+"Label", field[0])
+			custom++
+		}
+	}
+	siemDetails(output, func(key string, value string) {
+		ext.add("ad."+key, value)
+	})
+	b.WriteString(ext.String())
+	return []byte(b.String()), nil
+}
+
+// LEEFEncoder renders entries in IBM QRadar's Log Event Extended Format
+// 1.0, tab-separated:
+//
+//	LEEF:1.0|Vendor|Product|Version|category|attributes
+//
+// The timestamp becomes devTime, the level sev, the category cat and the
+// user usrName; the message, error, context IDs, tags, metadata and other
+// details keep their names as custom attributes, which QRadar extracts with
+// custom properties.
+type LEEFEncoder struct {
+	// Vendor and Product identify the device; they default to
+	// "context-based-logger" and the executable's name.
+	Vendor  string
+	Product string
+	Version string
+}
+
+// leefTime is LEEF's default devTimeFormat, "MMM dd yyyy HH:mm:ss.SSS zzz".
+const leefTime = "Jan 02 2006 15:04:05.000 MST"
+
+func (e LEEFEncoder) Encode(output LogOutput) ([]byte, error) {
+	message, err := siemMessage(output)
+	if err != nil {
+		return nil, err
+	}
+	vendor, product := siemDevice(e.Vendor, e.Product)
+	var b strings.Builder
+	b.WriteString("LEEF:1.0")
+	for _, field := range []string{vendor, product, e.Version, siemEventID(output)} {
+		b.WriteByte('|')
+		b.WriteString(cefHeader(field))
+	}
+	b.WriteByte('|')
+
+	ext := &siemExtensions{sep: "\t", escape: leefValue}
+	if t, ok := siemTime(output); ok {
+		ext.add("devTime", t.Format(leefTime))
+	}
+	ext.add("sev", strconv.Itoa(siemSeverity(output.Level)))
+	if category, ok := output.Details["category"].(string); ok {
+		ext.add("cat", category)
+	}
+	ext.add("usrName", output.UserID)
+	ext.add("msg", message)
+	if chain, ok := output.Details["errorChain"].([]string); ok && len(chain) > 0 {
+		ext.add("error", chain[0])
+	}
+	for _, field := range siemContext(output) {
+		ext.add(field[0], field[1])
+	}
+	siemDetails(output, ext.add)
+	b.WriteString(ext.String())
+	return []byte(b.String()), nil
+}
+
+// siemExtensions accumulates key=value pairs, skipping empty values.
+type siemExtensions struct {
+	sep    string
+	escape func(string) string
+	b      strings.Builder
+}
+
+func (x *siemExtensions) add(key, value string) {
+	if value == "" {
+		return
+	}
+	if x.b.Len() > 0 {
+		x.b.WriteString(x.sep)
+	}
+	x.b.WriteString(siemKey(key))
+	x.b.WriteByte('=')
+	x.b.WriteString(x.escape(value))
+}
+
+func (x *siemExtensions) String() string { return x.b.String() }
+
+func siemDevice(vendor, product string) (string, string) {
+	if vendor == "" {
+		vendor = "context-based-logger"
+	}
+	if product == "" {
+		product = filepath.Base(os.Args[0])
+	}
+	return vendor, product
+}
+
+func siemEventID(output LogOutput) string {
+	if output.Event != "" {
+		return output.Event
+	}
+	if category, ok := output.Details["category"].(string); ok && category != "" {
+		return category
+	}
+	return "log"
+}
+
+func siemMessage(output LogOutput) (string, error) {
+	if output.Message == nil {
+		return output.Event, nil
+	}
+	return profileMessage(output.Message)
+}
+
+// siemSeverity maps levels onto the 0-10 scale both formats use.
+func siemSeverity(level LogLevel) int {
+	switch level {
+	case LevelDebug:
+		return 1
+	case LevelWarn:
+		return 6
+	case LevelError:
+		return 9
+	}
+	return 3
+}
+
+func siemTime(output LogOutput) (time.Time, bool) {
+	s, ok := output.Details["timestamp"].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// siemContext lists the context IDs and tags without an equivalent in the
+// formats' dictionaries.
+func siemContext(output LogOutput) [][2]string {
+	tags, _ := output.Details["tags"].([]string)
+	return [][2]string{
+		{"sessionId", output.SessionID},
+		{"operationId", output.OperationID},
+		{"traceId", output.TraceID},
+		{"tenantId", output.TenantID},
+		{"tags", strings.Join(tags, ",")},
+	}
+}
+
+// siemDetails calls add for metadata keys, then the other details not
+// mapped to header or dictionary fields, flattened with dotted keys.
+func siemDetails(output LogOutput, add func(key, value string)) {
+	if metadata, ok := metadataFields(output.Details["metadata"]); ok {
+		for _, k := range sortedKeys(metadata) {
+			add(k, siemString(metadata[k]))
+		}
+	}
+	for _, k := range sortedKeys(output.Details) {
+		switch k {
+		case "timestamp", "category", "tags", "metadata", "errorChain":
+			continue
+		}
+		siemFlatten(k, output.Details[k], add)
+	}
+	if output.Data != nil {
+		add("data", siemString(output.Data))
+	}
+}
+
+func siemFlatten(key string, value interface{}, add func(key, value string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			siemFlatten(key+"."+k, v[k], add)
+		}
+	case map[string]string:
+		for _, k := range sortedKeys(v) {
+			add(key+"."+k, v[k])
+		}
+	case []string:
+		add(key, strings.Join(v, ","))
+	default:
+		add(key, siemString(v))
+	}
+}
+
+func siemString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		if raw, err := json.Marshal(v); err == nil {
+			return string(raw)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// siemKey replaces the characters keys cannot hold.
+func siemKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '|' || r == '\\' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+var (
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefValueEscaper  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+	leefValueEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+func cefHeader(s string) string { return cefHeaderEscaper.Replace(s) }
+
+func cefValue(s string) string { return cefValueEscaper.Replace(s) }
+
+// leefValue replaces the delimiter and line breaks, which LEEF cannot
+// escape.
+func leefValue(s string) string { return leefValueEscaper.Replace(s) }
+
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,46 @@
+package logger
+
+import "testing"
+
+func siemOutput() LogOutput {
+	return LogOutput{
+		Level:     LevelError,
+		Message:   "payment failed: a|b=c",
+		SessionID: "s1",
+		UserID:    "u1",
+		Details: map[string]interface{}{
+			"timestamp":  "2024-01-01T00:00:00Z",
+			"category":   "payments",
+			"tags":       []string{"billing", "eu"},
+			"metadata":   map[string]string{"orderId": "42"},
+			"errorChain": []string{"charge: declined"},
+			"fields":     map[string]interface{}{"attempt": 2},
+		},
+	}
+}
+
+func TestCEFEncoder(t *testing.T) {
+	encoded, err := CEFEncoder{Vendor: "Acme", Product: "orders", Version: "1.2"}.Encode(siemOutput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CEF:0|Acme|orders|1.2|payments|payment failed: a\|b=c|9|` +
+		`rt=1704067200000 msg=payment failed: a|b\=c cat=payments suser=u1 reason=charge: declined ` +
+		`cs1=s1 cs1Label=sessionId cs2=billing,eu cs2Label=tags ad.orderId=42 ad.fields.attempt=2`
+	if string(encoded) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, encoded)
+	}
+}
+
+func TestLEEFEncoder(t *testing.T) {
+	encoded, err := LEEFEncoder{Vendor: "Acme", Product: "orders", Version: "1.2"}.Encode(siemOutput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "LEEF:1.0|Acme|orders|1.2|payments|" +
+		"devTime=Jan 01 2024 00:00:00.000 UTC\tsev=9\tcat=payments\tusrName=u1\tmsg=payment failed: a|b=c\terror=charge: declined\t" +
+		"sessionId=s1\ttags=billing,eu\torderId=42\tfields.attempt=2"
+	if string(encoded) != want {
+		t.Errorf("Expected:\n%q\ngot:\n%q", want, encoded)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals gives the default logger conventional daemon signal handling
+// until stop is called:
+//
+//   - SIGHUP reopens sinks implementing Reopener, e.g. files moved by logrotate.
+//   - SIGUSR1 toggles the level between debug and its previous value.
+//   - SIGTERM flushes buffered sinks, then is redelivered so the process
+//     terminates as usual, or the application's own handler receives it.
+//
+// Failures go to the internal error handler.
+func HandleSignals() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGTERM)
+
+	go func() {
+		var previous LogLevel
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-signals:
+				l := defaultStandardLogger()
+				switch sig {
+				case syscall.SIGHUP:
+					for _, sink := range l.activeSinks() {
+						if r, ok := sink.(Reopener); ok {
+							if err := r.Reopen(); err != nil {
+								reportError(l.errorHandler, fmt.Errorf("reopen %T: %w", sink, err))
+							}
+						}
+					}
+				case syscall.SIGUSR1:
+					if level := l.Level(); level != LevelDebug {
+						previous = level
+						l.SetLevel(LevelDebug)
+					} else {
+						l.SetLevel(previous)
+					}
+				case syscall.SIGTERM:
+					flushSinks(l)
+					signal.Stop(signals)
+					_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,96 @@
+//go:build unix
+
+package logger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestHandleSignals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := NewFileSink(path, JSONEncoder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	l := New(WithSinks(file), WithLevel(LevelWarn))
+	previous := Default()
+	SetDefault(l)
+	defer SetDefault(previous)
+
+	stop := HandleSignals()
+	defer stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	waitFor(t, func() bool { return l.Level() == LevelDebug })
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	waitFor(t, func() bool { return l.Level() == LevelWarn })
+
+	l.Warn(context.Background(), "before rotation")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	waitFor(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	})
+	l.Warn(context.Background(), "after rotation")
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "before rotation") || !strings.Contains(string(current), "after rotation") {
+		t.Errorf("Expected entries split across the rotation, got %q and %q", rotated, current)
+	}
+}
+
+func TestHandleSignals_ReopensWrappedSinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := NewFileSink(path, JSONEncoder{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	async, err := NewAsyncSink(file, AsyncOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := NewFailoverSink(async, &recordingSink{}, FailoverOptions{})
+	defer sink.Close()
+
+	l := New(WithSinks(sink))
+	previous := Default()
+	SetDefault(l)
+	defer SetDefault(previous)
+
+	stop := HandleSignals()
+	defer stop()
+
+	l.Info(context.Background(), "before rotation")
+	if err := async.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	waitFor(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	})
+	l.Info(context.Background(), "after rotation")
+	if err := async.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "before rotation") || !strings.Contains(string(current), "after rotation") {
+		t.Errorf("Expected entries split across the rotation, got %q and %q", rotated, current)
+	}
+}
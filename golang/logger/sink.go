@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Sink receives every emitted entry. A logger hands its sinks one whole entry
+// at a time, but a sink shared by several loggers (such as the package sinks)
+// is called concurrently, so implementations must be safe for concurrent use;
+// the built-in sinks are. Sinks that hold resources should also implement
+// io.Closer.
+type Sink interface {
+	Write(output LogOutput) error
+}
+
+// WriterSink encodes entries onto an io.Writer, one per line. A nil Encoder
+// uses the package encoder. Each entry is a single Write call, serialized
+// with other WriterSinks on the same writer, so entries stay whole even on
+// writers that are not safe for concurrent use.
+type WriterSink struct {
+	W       io.Writer
+	Encoder Encoder
+}
+
+func (s WriterSink) Write(output LogOutput) error {
+	enc := s.Encoder
+	if enc == nil {
+		enc = currentEncoder()
+	}
+	encoded, err := enc.Encode(output)
+	if err != nil {
+		return err
+	}
+	mu := writerLock(s.W)
+	mu.Lock()
+	defer mu.Unlock()
+	_, err = s.W.Write(frame(enc, encoded))
+	return err
+}
+
+// writerLocks serialize writes per destination writer. Writers are striped
+// by address, so the locks need no registry and never leak.
+var writerLocks [64]sync.Mutex
+
+func writerLock(w io.Writer) *sync.Mutex {
+	v := reflect.ValueOf(w)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return &writerLocks[(v.Pointer()>>4)%uintptr(len(writerLocks))]
+	}
+	return &writerLocks[0]
+}
+
+// stdoutSink is the default sink. It resolves stdout at write time so the
+// destination can be swapped.
+type stdoutSink struct{}
+
+func (stdoutSink) Write(output LogOutput) error {
+	return WriterSink{W: stdout}.Write(output)
+}
+
+var (
+	sinksMu sync.RWMutex
+	sinks   = []Sink{stdoutSink{}}
+)
+
+// AddSink sends entries to sink in addition to the existing sinks.
+func AddSink(sink Sink) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = append(append([]Sink(nil), sinks...), sink)
+}
+
+// SetSinks replaces all sinks, including the default stdout sink.
+func SetSinks(newSinks ...Sink) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = append([]Sink(nil), newSinks...)
+}
+
+func currentSinks() []Sink {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	return sinks
+}
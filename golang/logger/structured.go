@@ -0,0 +1,473 @@
+package logger
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const maxDepthMarker = "[max depth exceeded]"
+
+// Schema versions of the entry layout, emitted as schemaVersion so consumers
+// can tell layouts apart while migrating.
+const (
+	// SchemaV1 is the original layout: structured payloads as the message.
+	SchemaV1 = 1
+	// SchemaV2 keeps message a string and adds the data field.
+	SchemaV2 = 2
+
+	LatestSchemaVersion = SchemaV2
+)
+
+// SetSchemaVersion pins output to an earlier layout, so parsers keep working
+// until they are updated for the latest one.
+func SetSchemaVersion(version int) error {
+	switch version {
+	case SchemaV1:
+		SetMessageFormat(MessageLegacy)
+	case SchemaV2:
+		SetMessageFormat(MessageAndData)
+	default:
+		return fmt.Errorf("unknown schema version %d", version)
+	}
+	return nil
+}
+
+func currentSchemaVersion() int {
+	if MessageFormat(messageFormat.Load()) == MessageLegacy {
+		return SchemaV1
+	}
+	return LatestSchemaVersion
+}
+
+// MessageFormat decides where structured payloads are emitted.
+type MessageFormat int32
+
+const (
+	// MessageAndData keeps message a string and emits structured payloads in
+	// a separate data field, so search backends map message consistently.
+	MessageAndData MessageFormat = iota
+	// MessageLegacy emits structured payloads as the message itself, as
+	// earlier versions did.
+	MessageLegacy
+)
+
+var messageFormat atomic.Int32
+
+// SetMessageFormat sets where structured payloads go. The default is
+// MessageAndData; MessageLegacy emits schema version 1.
+func SetMessageFormat(format MessageFormat) {
+	messageFormat.Store(int32(format))
+}
+
+// structuredValue converts structs, maps, slices and arrays (or pointers to
+// them) into their generic JSON form so they can be emitted as objects.
+// Errors, Stringers and other scalars are left to string formatting.
+func structuredValue(v interface{}) (interface{}, bool) {
+	generic, ok, _ := toStructured(v)
+	return generic, ok
+}
+
+// toStructured is structuredValue, also reporting whether a reference cycle,
+// excessive nesting or an unencodable value was replaced with a marker.
+// Values without them take the exact encoding/json path.
+func toStructured(v interface{}) (generic interface{}, ok, cut bool) {
+	if v == nil {
+		return nil, false, false
+	}
+	switch v.(type) {
+	case error, fmt.Stringer, []byte:
+		return nil, false, false
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return nil, false, false
+	}
+
+	w := &structWalker{maxDepth: structuredMaxDepth(), path: map[visit]bool{}}
+	if w.fits(reflect.ValueOf(v), 1) {
+		if raw, err := json.Marshal(v); err == nil {
+			if decoded, err := decodeGeneric(raw); err == nil {
+				return decoded, true, false
+			}
+		}
+	}
+	w.path = map[visit]bool{}
+	generic = w.walk(reflect.ValueOf(v), 1)
+	return generic, true, w.cut
+}
+
+const (
+	cycleMarker = "[cycle]"
+	// defaultMaxStructuredDepth bounds nesting when Limits.MaxDepth is unset.
+	defaultMaxStructuredDepth = 32
+)
+
+func structuredMaxDepth() int {
+	if max := currentLimits().MaxDepth; max > 0 {
+		return max
+	}
+	return defaultMaxStructuredDepth
+}
+
+// visit identifies a pointer, map or slice on the current path.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// structWalker converts values to their generic JSON form, marking cycles,
+// nesting beyond maxDepth and unencodable values instead of failing. Depth
+// counts objects and arrays, as limitDepth does.
+type structWalker struct {
+	maxDepth int
+	path     map[visit]bool
+	cut      bool
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// enter records rv on the current path, reporting false if it is already
+// there.
+func (w *structWalker) enter(rv reflect.Value) (visit, bool) {
+	key := visit{ptr: rv.Pointer(), typ: rv.Type()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+	if w.path[key] {
+		return key, false
+	}
+	w.path[key] = true
+	return key, true
+}
+
+// fits reports whether rv is acyclic and within maxDepth.
+func (w *structWalker) fits(rv reflect.Value, depth int) bool {
+	switch rv.Kind() {
+	case reflect.Interface:
+		return rv.IsNil() || w.fits(rv.Elem(), depth)
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return true
+		}
+		key, ok := w.enter(rv)
+		if !ok {
+			return false
+		}
+		defer delete(w.path, key)
+		return w.fits(rv.Elem(), depth)
+	case reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return true
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+		if depth > w.maxDepth {
+			return false
+		}
+		key, ok := w.enter(rv)
+		if !ok {
+			return false
+		}
+		defer delete(w.path, key)
+		if rv.Kind() == reflect.Map {
+			for iter := rv.MapRange(); iter.Next(); {
+				if !w.fits(iter.Value(), depth+1) {
+					return false
+				}
+			}
+			return true
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if !w.fits(rv.Index(i), depth+1) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		if depth > w.maxDepth {
+			return false
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if !w.fits(rv.Index(i), depth+1) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+			return true
+		}
+		if depth > w.maxDepth {
+			return false
+		}
+		for i := 0; i < rv.NumField(); i++ {
+			if rv.Type().Field(i).IsExported() && !w.fits(rv.Field(i), depth+1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (w *structWalker) walk(rv reflect.Value, depth int) interface{} {
+	if !rv.IsValid() {
+		return nil
+	}
+	if rv.Kind() != reflect.Pointer && rv.Kind() != reflect.Interface &&
+		(rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType)) {
+		return w.leaf(rv)
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return w.walk(rv.Elem(), depth)
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Type().Implements(jsonMarshalerType) || rv.Type().Implements(textMarshalerType) {
+			return w.leaf(rv)
+		}
+		key, ok := w.enter(rv)
+		if !ok {
+			w.cut = true
+			return cycleMarker
+		}
+		defer delete(w.path, key)
+		return w.walk(rv.Elem(), depth)
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return w.leaf(rv)
+	case reflect.String:
+		return rv.String()
+	case reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return w.leaf(rv)
+		}
+		if depth > w.maxDepth {
+			w.cut = true
+			return maxDepthMarker
+		}
+		key, ok := w.enter(rv)
+		if !ok {
+			w.cut = true
+			return cycleMarker
+		}
+		defer delete(w.path, key)
+		if rv.Kind() == reflect.Map {
+			out := make(map[string]interface{}, rv.Len())
+			for iter := rv.MapRange(); iter.Next(); {
+				out[mapKey(iter.Key())] = w.walk(iter.Value(), depth+1)
+			}
+			return out
+		}
+		return w.array(rv, depth)
+	case reflect.Array:
+		if depth > w.maxDepth {
+			w.cut = true
+			return maxDepthMarker
+		}
+		return w.array(rv, depth)
+	case reflect.Struct:
+		if depth > w.maxDepth {
+			w.cut = true
+			return maxDepthMarker
+		}
+		out := make(map[string]interface{})
+		w.structFields(rv, depth, out)
+		return out
+	}
+	w.cut = true
+	return fmt.Sprintf("[unsupported %s]", rv.Type())
+}
+
+func (w *structWalker) array(rv reflect.Value, depth int) []interface{} {
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = w.walk(rv.Index(i), depth+1)
+	}
+	return out
+}
+
+// structFields adds rv's fields under their json names, honoring "-" and
+// omitempty and promoting the fields of untagged embedded structs.
+func (w *structWalker) structFields(rv reflect.Value, depth int, out map[string]interface{}) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := rv.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				w.structFields(embedded, depth, out)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(value) {
+			continue
+		}
+		if _, exists := out[name]; !exists {
+			out[name] = w.walk(value, depth+1)
+		}
+	}
+}
+
+// isEmptyValue mirrors encoding/json's omitempty test.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// leaf encodes a value with encoding/json, marking it if that fails.
+func (w *structWalker) leaf(rv reflect.Value) interface{} {
+	raw, err := json.Marshal(rv.Interface())
+	if err != nil {
+		w.cut = true
+		return fmt.Sprintf("[unsupported %s: %v]", rv.Type(), err)
+	}
+	generic, _ := decodeGeneric(raw)
+	return generic
+}
+
+// decodeGeneric unmarshals raw into its generic form. Integers are kept as
+// int64, or uint64 above its range, since float64 loses them past 2^53.
+func decodeGeneric(raw []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return exactNumbers(generic), nil
+}
+
+func exactNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = exactNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = exactNumbers(e)
+		}
+	}
+	return v
+}
+
+func mapKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	if marshaler, ok := key.Interface().(encoding.TextMarshaler); ok {
+		if text, err := marshaler.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(key.Interface())
+}
+
+// limitDepth replaces anything nested deeper than maxDepth levels with a
+// marker, reporting whether it did. A maxDepth of zero means unlimited.
+func limitDepth(v interface{}, maxDepth int) (interface{}, bool) {
+	if maxDepth <= 0 {
+		return v, false
+	}
+	return limitDepthAt(v, 1, maxDepth)
+}
+
+func limitDepthAt(v interface{}, depth, maxDepth int) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if depth > maxDepth {
+			return maxDepthMarker, true
+		}
+		out := make(map[string]interface{}, len(v))
+		cut := false
+		for k, child := range v {
+			var childCut bool
+			out[k], childCut = limitDepthAt(child, depth+1, maxDepth)
+			cut = cut || childCut
+		}
+		return out, cut
+	case []interface{}:
+		if depth > maxDepth {
+			return maxDepthMarker, true
+		}
+		out := make([]interface{}, len(v))
+		cut := false
+		for i, child := range v {
+			var childCut bool
+			out[i], childCut = limitDepthAt(child, depth+1, maxDepth)
+			cut = cut || childCut
+		}
+		return out, cut
+	}
+	return v, false
+}
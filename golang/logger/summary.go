@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// scopeSummary accumulates the entries of the scope a summary was requested
+// for, including its nested scopes.
+type scopeSummary struct {
+	mu         sync.Mutex
+	counts     map[LogLevel]int
+	firstError string
+}
+
+// WithSummary makes the WithLogContext scope opened with the returned
+// context log one summary entry when it ends: "Scope finished" with the
+// scope's duration, the number of entries logged at each level (nested
+// scopes included) and the first error message, a canonical line per
+// request or job without having to assemble it by hand. Counting happens in
+// StandardLogger.
+func (lc *LogContext) WithSummary() *LogContext {
+	newData := lc.copyData()
+	newData.summary = &scopeSummary{counts: make(map[LogLevel]int)}
+	return &LogContext{data: newData}
+}
+
+func (s *scopeSummary) record(output LogOutput) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[output.Level]++
+	if output.Level == LevelError && s.firstError == "" {
+		s.firstError = renderArg(output.Message)
+	}
+}
+
+// summaryScope logs the summary requested for child's scope when it ends.
+func summaryScope(inner context.Context, outer, child *LogContext) (done func()) {
+	s := child.data.summary
+	if s == nil || s == outer.data.summary {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		newData := GetLogContext(inner).copyData()
+		newData.summary = nil
+		ctx := unbudgeted(context.WithValue(inner, logContextKey, &LogContext{data: newData}))
+
+		s.mu.Lock()
+		counts := make(map[string]interface{}, len(s.counts))
+		for level, n := range s.counts {
+			counts[string(level)] = n
+		}
+		args := []interface{}{"Scope finished", DurationField("duration", time.Since(start)), F("entries", counts)}
+		if s.firstError != "" {
+			args = append(args, F("firstError", s.firstError))
+		}
+		s.mu.Unlock()
+		Info(ctx, args...)
+	}
+}
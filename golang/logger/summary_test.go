@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWithSummary(t *testing.T) {
+	sink := &recordingSink{}
+	previous := Default()
+	SetDefault(New(WithSinks(sink), WithLevel(LevelInfo)))
+	defer SetDefault(previous)
+
+	lc := NewLogContext(LogContextData{SessionID: "s1"}).WithSummary()
+	_, _ = WithLogContext(context.Background(), lc, func(ctx context.Context) (struct{}, error) {
+		Debug(ctx, "filtered")
+		Info(ctx, "started")
+		_, _ = WithLogContext(ctx, GetLogContext(ctx).WithCategory("db"), func(ctx context.Context) (struct{}, error) {
+			Error(ctx, "Query failed:", errors.New("timeout"))
+			return struct{}{}, nil
+		})
+		Error(ctx, "Request failed")
+		return struct{}{}, nil
+	})
+
+	if len(sink.entries) != 4 {
+		t.Fatalf("Expected three entries and the summary, got %v", sink.entries)
+	}
+	summary := sink.entries[3]
+	fields := summary.Details["fields"].(map[string]interface{})
+	counts := fields["entries"].(map[string]interface{})
+	if summary.Message != "Scope finished" || summary.SessionID != "s1" || fields["duration"] == nil ||
+		fmt.Sprint(counts) != "map[error:2 info:1]" || fields["firstError"] != "Query failed: timeout" {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
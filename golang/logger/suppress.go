@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Suppression silences, or downgrades, a logger's entries matching a
+// category or tags until it expires, e.g. health check noise during planned
+// maintenance. Events are not affected.
+type Suppression struct {
+	ID string `json:"id"`
+	// Category matches entries in the category or nested below it.
+	Category string `json:"category,omitempty"`
+	// Tags match entries carrying every tag.
+	Tags []string `json:"tags,omitempty"`
+	// DowngradeTo, when set, lowers matching entries to this level instead
+	// of dropping them; the level filters then apply to the new level.
+	DowngradeTo LogLevel  `json:"downgradeTo,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Until       time.Time `json:"until"`
+}
+
+func (s Suppression) matches(category string, tags []string) bool {
+	if s.Category != "" && !CategoryHasPrefix(category, s.Category) {
+		return false
+	}
+	for _, tag := range s.Tags {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// suppressions holds a logger's suppressions. The slice is replaced, never
+// modified, so Emit reads it without locking.
+type suppressions struct {
+	mu     sync.Mutex
+	active atomic.Pointer[[]Suppression]
+}
+
+// Suppress applies s for d, returning it with its ID and expiry set. s must
+// name a category or tags.
+func (l *StandardLogger) Suppress(s Suppression, d time.Duration) (Suppression, error) {
+	if s.Category == "" && len(s.Tags) == 0 {
+		return Suppression{}, errors.New("logger: suppression needs a category or tags")
+	}
+	if d <= 0 {
+		return Suppression{}, errors.New("logger: suppression needs a positive duration")
+	}
+	s.ID = newID()
+	s.Until = l.now().Add(d)
+	s.Tags = slices.Clone(s.Tags)
+
+	l.suppressions.mu.Lock()
+	defer l.suppressions.mu.Unlock()
+	active := append(l.Suppressions(), s)
+	l.suppressions.active.Store(&active)
+	return s, nil
+}
+
+// Unsuppress ends the suppression with id early, reporting whether it was
+// active.
+func (l *StandardLogger) Unsuppress(id string) bool {
+	l.suppressions.mu.Lock()
+	defer l.suppressions.mu.Unlock()
+	active := l.Suppressions()
+	remaining := slices.DeleteFunc(active, func(s Suppression) bool { return s.ID == id })
+	l.suppressions.active.Store(&remaining)
+	return len(remaining) < len(active)
+}
+
+// Suppressions returns the suppressions that have not expired.
+func (l *StandardLogger) Suppressions() []Suppression {
+	current := l.suppressions.active.Load()
+	if current == nil {
+		return nil
+	}
+	now := l.now()
+	var active []Suppression
+	for _, s := range *current {
+		if now.Before(s.Until) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// suppress applies the first matching suppression to output, reporting
+// whether it is dropped.
+func (l *StandardLogger) suppress(output *LogOutput, category string) bool {
+	current := l.suppressions.active.Load()
+	if current == nil || len(*current) == 0 || output.Event != "" {
+		return false
+	}
+	tags, _ := output.Details["tags"].([]string)
+	now := l.now()
+	for _, s := range *current {
+		if now.Before(s.Until) && s.matches(category, tags) {
+			if s.DowngradeTo == "" {
+				return true
+			}
+			if s.DowngradeTo.severity() < output.Level.severity() {
+				output.Level = s.DowngradeTo
+			}
+			return false
+		}
+	}
+	return false
+}
+
+type suppressionRequest struct {
+	Category    string   `json:"category"`
+	Tags        []string `json:"tags"`
+	DowngradeTo LogLevel `json:"downgradeTo"`
+	Reason      string   `json:"reason"`
+	// Duration is in Go's notation, e.g. "10m".
+	Duration string `json:"duration"`
+}
+
+// SuppressionHandler manages l's suppressions at runtime: GET lists the
+// active ones, POST creates one from a JSON body such as
+// {"category": "http.healthcheck", "duration": "10m"}, and DELETE ?id=
+// ends one. Mount it behind the same access control as other admin
+// endpoints.
+func SuppressionHandler(l *StandardLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, l.Suppressions())
+		case http.MethodPost:
+			var req suppressionRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			d, err := time.ParseDuration(req.Duration)
+			if err != nil {
+				http.Error(w, "invalid duration: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			switch req.DowngradeTo {
+			case "", LevelDebug, LevelInfo, LevelWarn, LevelError:
+			default:
+				http.Error(w, "invalid downgradeTo level", http.StatusBadRequest)
+				return
+			}
+			s, err := l.Suppress(Suppression{
+				Category:    req.Category,
+				Tags:        req.Tags,
+				DowngradeTo: req.DowngradeTo,
+				Reason:      req.Reason,
+			}, d)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, http.StatusCreated, s)
+		case http.MethodDelete:
+			if !l.Unsuppress(r.URL.Query().Get("id")) {
+				http.Error(w, "no such suppression", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"slices"
+	"strings"
+)
+
+// TagSet is an immutable set of tags kept in sorted order, so tags are always
+// emitted in the same order and sets are cheap to copy and compare.
+type TagSet struct {
+	tags []string
+}
+
+func NewTagSet(tags ...string) TagSet {
+	return TagSet{}.With(tags...)
+}
+
+func (s TagSet) Has(tag string) bool {
+	_, found := slices.BinarySearch(s.tags, tag)
+	return found
+}
+
+func (s TagSet) Len() int {
+	return len(s.tags)
+}
+
+// Slice returns the tags in sorted order. The slice is the caller's to keep.
+func (s TagSet) Slice() []string {
+	return slices.Clone(s.tags)
+}
+
+// With returns the set plus tags, sharing storage when nothing is added.
+func (s TagSet) With(tags ...string) TagSet {
+	var merged []string
+	for _, tag := range tags {
+		if s.Has(tag) || (merged != nil && slices.Contains(merged[len(s.tags):], tag)) {
+			continue
+		}
+		if merged == nil {
+			merged = make([]string, len(s.tags), len(s.tags)+len(tags))
+			copy(merged, s.tags)
+		}
+		merged = append(merged, tag)
+	}
+	if merged == nil {
+		return s
+	}
+	slices.Sort(merged)
+	return TagSet{tags: merged}
+}
+
+// Without returns the set minus tags.
+func (s TagSet) Without(tags ...string) TagSet {
+	var kept []string
+	for _, tag := range s.tags {
+		if !slices.Contains(tags, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return TagSet{tags: kept}
+}
+
+func (s TagSet) Union(other TagSet) TagSet {
+	return s.With(other.tags...)
+}
+
+func (s TagSet) Intersect(other TagSet) TagSet {
+	var common []string
+	for _, tag := range s.tags {
+		if other.Has(tag) {
+			common = append(common, tag)
+		}
+	}
+	return TagSet{tags: common}
+}
+
+// ContainsAll reports whether every tag of other is in the set.
+func (s TagSet) ContainsAll(other TagSet) bool {
+	for _, tag := range other.tags {
+		if !s.Has(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s TagSet) String() string {
+	return "[" + strings.Join(s.tags, " ") + "]"
+}
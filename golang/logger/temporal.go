@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+)
+
+// temporalMetadata maps the keys the Temporal SDK tags workflow and activity
+// loggers with to metadata keys.
+var temporalMetadata = map[string]string{
+	"Namespace":    "namespace",
+	"TaskQueue":    "taskQueue",
+	"WorkerID":     "workerId",
+	"WorkflowType": "workflowType",
+	"RunID":        "runId",
+	"ActivityType": "activityType",
+	"ActivityID":   "activityId",
+	"Attempt":      "attempt",
+}
+
+// TemporalLogger satisfies the Temporal Go SDK's log.Logger interface, so
+// workflow and activity logs go through the structured pipeline:
+//
+//	w := worker.New(c, "orders", worker.Options{
+//		Logger: logger.NewTemporalLogger(ctx, nil),
+//	})
+//
+// The SDK's tags become context fields: the workflow ID is the session, so a
+// workflow's entries group across workers and retries; the category is
+// "temporal.workflow" or "temporal.activity"; the run, type, task queue and
+// attempt become metadata. An "Error" value is logged as the entry's error
+// and other key-value pairs become fields.
+type TemporalLogger struct {
+	ctx    context.Context
+	logger Logger
+}
+
+// NewTemporalLogger returns an adapter logging through l, or the default
+// logger when l is nil, with ctx's LogContext as the base for every entry.
+func NewTemporalLogger(ctx context.Context, l Logger) *TemporalLogger {
+	return &TemporalLogger{ctx: ctx, logger: l}
+}
+
+func (t *TemporalLogger) Debug(msg string, keyvals ...interface{}) {
+	t.log(LevelDebug, msg, keyvals)
+}
+
+func (t *TemporalLogger) Info(msg string, keyvals ...interface{}) {
+	t.log(LevelInfo, msg, keyvals)
+}
+
+func (t *TemporalLogger) Warn(msg string, keyvals ...interface{}) {
+	t.log(LevelWarn, msg, keyvals)
+}
+
+func (t *TemporalLogger) Error(msg string, keyvals ...interface{}) {
+	t.log(LevelError, msg, keyvals)
+}
+
+func (t *TemporalLogger) log(level LogLevel, msg string, keyvals []interface{}) {
+	lc := GetLogContext(t.ctx)
+	metadata := make(map[string]string)
+	args := []interface{}{msg}
+	var err error
+	category := "temporal.workflow"
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		switch key {
+		case "WorkflowID":
+			lc = lc.WithSessionID(fmt.Sprint(value))
+			continue
+		case "ActivityID", "ActivityType":
+			category = "temporal.activity"
+		case "Error":
+			if e, ok := value.(error); ok {
+				err = e
+				continue
+			}
+		}
+		if name, ok := temporalMetadata[key]; ok {
+			metadata[name] = fmt.Sprint(value)
+			continue
+		}
+		args = append(args, F(key, value))
+	}
+	lc = lc.WithCategory(category)
+	if len(metadata) > 0 {
+		lc = lc.WithMetadata(metadata)
+	}
+	if err != nil {
+		args = append(args, err)
+	}
+
+	l := t.logger
+	if l == nil {
+		l = Default()
+	}
+	l.Log(context.WithValue(t.ctx, logContextKey, lc), level, args...)
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTemporalLogger(t *testing.T) {
+	rec := &recordingSink{}
+	l := New(WithLevel(LevelDebug), WithSinks(rec))
+	ctx := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{TenantID: "acme"}))
+	temporal := NewTemporalLogger(ctx, l)
+
+	temporal.Error("Activity error.",
+		"Namespace", "default", "WorkflowID", "order-42", "RunID", "r1",
+		"ActivityType", "Charge", "Attempt", 3, "Error", errors.New("card declined"), "amount", 12)
+
+	entry := rec.entries[0]
+	metadata := entry.Details["metadata"].(map[string]string)
+	if entry.SessionID != "order-42" || entry.TenantID != "acme" || entry.Details["category"] != "temporal.activity" {
+		t.Errorf("Expected the workflow mapped to context fields, got %+v", entry)
+	}
+	if metadata["runId"] != "r1" || metadata["activityType"] != "Charge" || metadata["attempt"] != "3" || metadata["namespace"] != "default" {
+		t.Errorf("Expected the SDK tags as metadata, got %v", metadata)
+	}
+	if entry.Message != "Activity error. card declined" {
+		t.Errorf("Expected the error in the message, got %q", entry.Message)
+	}
+	if fields := entry.Details["fields"].(map[string]interface{}); fields["amount"] != 12 {
+		t.Errorf("Expected other pairs as fields, got %v", fields)
+	}
+}
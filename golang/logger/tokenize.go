@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Tokenizer replaces a sensitive value with a token that a privileged tool
+// can turn back into it, e.g. through a vault's tokenization API. key is the
+// metadata key or field name the value was logged under.
+type Tokenizer interface {
+	Tokenize(key, value string) (string, error)
+}
+
+// WithTokenizer adds a hook replacing the values of the metadata keys and
+// fields named keys, matched case-insensitively, with tokens from t. Unlike
+// redaction, the value stays recoverable by whoever can reverse the token,
+// while support staff only see the token. Values that fail to tokenize are
+// redacted and the error reported to the logger's error handler.
+//
+// Entries the level filters drop skip hooks, so when WithRecentEntries keeps
+// them, the values of the logger's tokenized keys are redacted instead, as
+// they are in metadata a Transport propagates for the default logger.
+//
+//	logger.New(logger.WithTokenizer(logger.SealTokenizer{Sealer: sealer}, "email", "iban"))
+func WithTokenizer(t Tokenizer, keys ...string) Option {
+	keys = slices.Clone(keys)
+	for i, key := range keys {
+		keys[i] = strings.ToLower(key)
+	}
+	return func(l *StandardLogger) {
+		l.tokenizedKeys = append(l.tokenizedKeys, keys...)
+		l.hooks = append(l.hooks, func(_ context.Context, output *LogOutput) {
+			replaceValues(output, func(key string, value interface{}) (interface{}, bool) {
+				if !slices.Contains(keys, strings.ToLower(key)) {
+					return nil, false
+				}
+				s, ok := value.(string)
+				if !ok {
+					s = fmt.Sprint(value)
+				}
+				token, err := t.Tokenize(key, s)
+				if err != nil {
+					reportError(l.errorHandler, fmt.Errorf("tokenize %s: %w", key, err))
+					return redactedValue, true
+				}
+				return token, true
+			})
+		})
+	}
+}
+
+// redactTokenized redacts the values of the logger's tokenized keys in
+// entries that did not run through its hooks.
+func (l *StandardLogger) redactTokenized(output *LogOutput) {
+	if len(l.tokenizedKeys) == 0 {
+		return
+	}
+	replaceValues(output, func(key string, _ interface{}) (interface{}, bool) {
+		return redactedValue, slices.Contains(l.tokenizedKeys, strings.ToLower(key))
+	})
+}
+
+// replaceValues replaces the metadata and field values replace returns true
+// for, copying the maps first since entries may share them with contexts.
+func replaceValues(output *LogOutput, replace func(key string, value interface{}) (interface{}, bool)) {
+	for _, key := range []string{"metadata", "fields"} {
+		switch values := output.Details[key].(type) {
+		case map[string]string:
+			var replaced map[string]string
+			for k, v := range values {
+				if r, ok := replace(k, v); ok {
+					if replaced == nil {
+						replaced = cloneMap(values, 0)
+					}
+					replaced[k] = fmt.Sprint(r)
+				}
+			}
+			if replaced != nil {
+				output.Details[key] = replaced
+			}
+		case map[string]interface{}:
+			var replaced map[string]interface{}
+			for k, v := range values {
+				if r, ok := replace(k, v); ok {
+					if replaced == nil {
+						replaced = cloneMap(values, 0)
+					}
+					replaced[k] = r
+				}
+			}
+			if replaced != nil {
+				output.Details[key] = replaced
+			}
+		}
+	}
+}
+
+const sealedTokenPrefix = "sealed:"
+
+// SealTokenizer tokenizes values by encrypting them with Sealer, e.g.
+// NewBoxSealer with the security team's public key, so tokens need no
+// service to look them up: OpenTokens, or logview -key, recovers them.
+type SealTokenizer struct {
+	Sealer Sealer
+}
+
+func (t SealTokenizer) Tokenize(_, value string) (string, error) {
+	sealed, err := t.Sealer.Seal([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return sealedTokenPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// OpenTokens replaces the SealTokenizer tokens in output's metadata and
+// fields with the values they stand for, returning the errors of tokens o
+// cannot open, which are left as they are.
+func OpenTokens(o Opener, output *LogOutput) error {
+	var errs []error
+	replaceValues(output, func(key string, value interface{}) (interface{}, bool) {
+		token, ok := value.(string)
+		if !ok || !strings.HasPrefix(token, sealedTokenPrefix) {
+			return nil, false
+		}
+		sealed, err := base64.RawURLEncoding.DecodeString(token[len(sealedTokenPrefix):])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, errSealed))
+			return nil, false
+		}
+		plaintext, err := o.Open(sealed)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			return nil, false
+		}
+		return string(plaintext), true
+	})
+	return errors.Join(errs...)
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithTokenizer(SealTokenizer{Sealer: NewBoxSealer(key.PublicKey())}, "Email", "iban"))
+
+	metadata := map[string]string{"email": "ada@example.com", "plan": "pro"}
+	ctx := context.WithValue(context.Background(), logContextKey, NewLogContext(LogContextData{Metadata: metadata}))
+	l.Info(ctx, "Signed up", F("iban", "GB82WEST12345698765432"))
+
+	output := sink.entries[0]
+	email := output.Details["metadata"].(map[string]string)["email"]
+	iban, _ := output.Details["fields"].(map[string]interface{})["iban"].(string)
+	if !strings.HasPrefix(email, "sealed:") || !strings.HasPrefix(iban, "sealed:") {
+		t.Fatalf("Expected tokens, got %q and %q", email, iban)
+	}
+	if output.Details["metadata"].(map[string]string)["plan"] != "pro" || metadata["email"] != "ada@example.com" {
+		t.Error("Expected other keys and the context's metadata to be left alone")
+	}
+
+	if err := OpenTokens(NewBoxOpener(key), &output); err != nil {
+		t.Fatal(err)
+	}
+	if got := output.Details["metadata"].(map[string]string)["email"]; got != "ada@example.com" {
+		t.Errorf("Expected the email back, got %q", got)
+	}
+	if got := output.Details["fields"].(map[string]interface{})["iban"]; got != "GB82WEST12345698765432" {
+		t.Errorf("Expected the IBAN back, got %v", got)
+	}
+}
+
+type failingTokenizer struct{}
+
+func (failingTokenizer) Tokenize(string, string) (string, error) {
+	return "", errors.New("vault unavailable")
+}
+
+func TestTokenize_FailsClosed(t *testing.T) {
+	sink := &recordingSink{}
+	l := New(WithSinks(sink), WithTokenizer(failingTokenizer{}, "email"))
+	stderr := captureStderr(t, func() {
+		l.Info(context.Background(), "Signed up", F("email", "ada@example.com"))
+	})
+	if got := sink.entries[0].Details["fields"].(map[string]interface{})["email"]; got != redactedValue {
+		t.Errorf("Expected the value to be redacted, got %v", got)
+	}
+	if !strings.Contains(stderr, "vault unavailable") {
+		t.Errorf("Expected the error to be reported, got %q", stderr)
+	}
+}
+
+func TestTokenize_PerLogger(t *testing.T) {
+	var reported []error
+	tokenizing := New(WithSinks(&recordingSink{}), WithTokenizer(failingTokenizer{}, "email"),
+		WithErrorHandler(func(err error) { reported = append(reported, err) }))
+	tokenizing.Info(context.Background(), "Signed up", F("email", "ada@example.com"))
+	if len(reported) != 1 || !strings.Contains(reported[0].Error(), "vault unavailable") {
+		t.Errorf("Expected the error reported to the logger's handler, got %v", reported)
+	}
+
+	other := New(WithSinks(&recordingSink{}), WithLevel(LevelInfo), WithRecentEntries(4))
+	other.Debug(context.Background(), "Looking up", F("email", "ada@example.com"))
+	if got := other.Recent()[0].Details["fields"].(map[string]interface{})["email"]; got != "ada@example.com" {
+		t.Errorf("Expected another logger's entries left alone, got %v", got)
+	}
+}
+
+func TestTokenize_FilteredEntries(t *testing.T) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(WithSinks(&recordingSink{}), WithLevel(LevelInfo), WithRecentEntries(4),
+		WithTokenizer(SealTokenizer{Sealer: NewBoxSealer(key.PublicKey())}, "email"))
+	l.Debug(context.Background(), "Looking up", F("email", "ada@example.com"), F("plan", "pro"))
+
+	fields := l.Recent()[0].Details["fields"].(map[string]interface{})
+	if fields["email"] != redactedValue || fields["plan"] != "pro" {
+		t.Errorf("Expected the tokenized key to be redacted in the ring, got %v", fields)
+	}
+	var buf bytes.Buffer
+	if err := WriteSupportBundle(&buf, l); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := zr.Open("recent.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	recent, _ := io.ReadAll(rc)
+	if strings.Contains(string(recent), "ada@example.com") || !strings.Contains(string(recent), redactedValue) {
+		t.Errorf("Expected the tokenized key to be redacted in the bundle, got %s", recent)
+	}
+}
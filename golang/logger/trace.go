@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// TraceParentHeader is the W3C Trace Context header Middleware reads the
+// trace ID and sampling decision from.
+const TraceParentHeader = "traceparent"
+
+// traceSampling is a context's trace sampling decision, if known.
+type traceSampling int8
+
+const (
+	traceSamplingUnknown traceSampling = iota
+	traceSampled
+	traceUnsampled
+)
+
+// WithTraceSampled records whether the context's trace is sampled, e.g. from
+// an OTel span:
+//
+//	sc := trace.SpanContextFromContext(ctx)
+//	lc = lc.WithTraceID(sc.TraceID().String()).WithTraceSampled(sc.IsSampled())
+func (lc *LogContext) WithTraceSampled(sampled bool) *LogContext {
+	newData := lc.copyData()
+	newData.traceSampling = traceUnsampled
+	if sampled {
+		newData.traceSampling = traceSampled
+	}
+	return &LogContext{data: newData}
+}
+
+// TraceSampled reports the context's trace sampling decision; known is false
+// when none was recorded.
+func (lc *LogContext) TraceSampled() (sampled, known bool) {
+	return lc.data.traceSampling == traceSampled, lc.data.traceSampling != traceSamplingUnknown
+}
+
+// WithTraceParent sets the trace ID and sampling decision from a W3C
+// traceparent header value. An invalid value leaves the context unchanged.
+func (lc *LogContext) WithTraceParent(traceparent string) *LogContext {
+	traceID, sampled, ok := parseTraceParent(traceparent)
+	if !ok {
+		return lc
+	}
+	return lc.WithTraceID(traceID).WithTraceSampled(sampled)
+}
+
+// parseTraceParent parses "version-traceid-parentid-flags".
+func parseTraceParent(value string) (traceID string, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", false, false
+	}
+	for _, part := range parts[:4] {
+		if _, err := hex.DecodeString(part); err != nil || strings.ToLower(part) != part {
+			return "", false, false
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", false, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return parts[1], flags[0]&1 == 1, true
+}
+
+// UnsampledPolicy decides what happens to low-level entries logged in
+// contexts whose trace is not sampled, keeping log volume proportional to
+// trace sampling.
+type UnsampledPolicy struct {
+	// Below is the level the policy applies under; entries at or above it
+	// are always kept. Defaults to LevelWarn, covering debug and info.
+	Below LogLevel
+	// Rate is the fraction of unsampled traces whose entries are kept. The
+	// decision is made per trace ID, so a trace keeps all its entries or
+	// none. Zero suppresses them all.
+	Rate float64
+}
+
+// WithUnsampledPolicy applies policy to entries from contexts marked
+// unsampled by WithTraceSampled or WithTraceParent. A context's MinLevel
+// overrides it.
+func WithUnsampledPolicy(policy UnsampledPolicy) Option {
+	return func(l *StandardLogger) {
+		if policy.Below == "" {
+			policy.Below = LevelWarn
+		}
+		l.unsampled = &policy
+	}
+}
+
+// sampledOut reports whether the unsampled policy drops an entry at level
+// from lc, whose minimum level is contextMin.
+func (l *StandardLogger) sampledOut(lc *LogContext, contextMin, level LogLevel) bool {
+	policy := l.unsampled
+	if policy == nil || lc.data.traceSampling != traceUnsampled || contextMin != "" ||
+		level.severity() >= policy.Below.severity() {
+		return false
+	}
+	if policy.Rate <= 0 {
+		return true
+	}
+	if policy.Rate >= 1 {
+		return false
+	}
+	if lc.data.TraceID == "" {
+		return rand.Float64() >= policy.Rate
+	}
+	return float64(traceHash(lc.data.TraceID)) >= policy.Rate*math.MaxUint64
+}
+
+// traceHash spreads trace IDs evenly over uint64: FNV-1a, then the
+// SplitMix64 finalizer, since FNV alone leaves short IDs clustered.
+func traceHash(traceID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(traceID))
+	x := h.Sum64()
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
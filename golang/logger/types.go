@@ -1,5 +1,10 @@
 package logger
 
+import (
+	"fmt"
+	"strings"
+)
+
 type LogLevel string
 
 const (
@@ -9,16 +14,166 @@ const (
 	LevelError LogLevel = "error"
 )
 
+func (l LogLevel) severity() int {
+	switch l {
+	case LevelDebug:
+		return 0
+	case LevelInfo:
+		return 1
+	case LevelWarn:
+		return 2
+	case LevelError:
+		return 3
+	}
+	return 1
+}
+
+func (l LogLevel) valid() bool {
+	switch l {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+		return true
+	}
+	return false
+}
+
+// ParseLevel returns the level named by s, ignoring case and surrounding
+// space; "warning" is accepted for LevelWarn.
+func ParseLevel(s string) (LogLevel, error) {
+	level := LogLevel(strings.ToLower(strings.TrimSpace(s)))
+	if level == "warning" {
+		level = LevelWarn
+	}
+	if !level.valid() {
+		return "", fmt.Errorf("logger: unknown level %q", s)
+	}
+	return level, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler with ParseLevel, so
+// levels in config files, flags and environment variables are read the
+// same way. Empty text leaves the level unset.
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*l = ""
+		return nil
+	}
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// AtLeast reports whether l is as severe as min or more.
+func (l LogLevel) AtLeast(min LogLevel) bool {
+	return l.severity() >= min.severity()
+}
+
 type LogContextData struct {
-	Tags      map[string]bool
+	Tags      TagSet
 	Category  string
 	Metadata  map[string]string
 	SessionID string
+	// OperationID identifies the WithLogContext scope; ParentOperationID links
+	// it to the enclosing scope. Both are assigned by WithLogContext.
+	OperationID       string
+	ParentOperationID string
+	// Well-known identity fields, emitted as top-level userId, tenantId and
+	// traceId so every team uses the same names.
+	UserID   string
+	TenantID string
+	TraceID  string
+	// MinLevel, when set, replaces the logger's level filters for entries
+	// logged in this context, e.g. LevelDebug to trace one request.
+	MinLevel LogLevel
+	// ErrorClass is the error class of error-level entries logged in this
+	// context whose error is not classified otherwise; see SetErrorClassifier.
+	ErrorClass string
+
+	// metadataHistory holds earlier values of keys merged under
+	// MetadataCollect, oldest first.
+	metadataHistory map[string][]string
+	// providers compute metadata values when an entry is emitted.
+	providers map[string]func() string
+	// traceSampling is the trace's sampling decision; see WithTraceSampled.
+	traceSampling traceSampling
+	// logBodies turns on BodyLogger for requests in this context.
+	logBodies bool
+	// budget accounts for the entries of the scope it was set on; see
+	// WithBudget.
+	budget *budgetState
+	// summary accumulates the entries of the scope it was set on; see
+	// WithSummary.
+	summary *scopeSummary
+	// captures receive the entries of the scope they were attached to; see
+	// CaptureScope.
+	captures []Sink
 }
 
 type LogOutput struct {
-	Level     LogLevel               `json:"level"`
-	Message   interface{}            `json:"message,omitempty"`
-	SessionID string                 `json:"sessionId,omitempty"`
-	Details   map[string]interface{} `json:"details"`
+	// SchemaVersion identifies the layout of the entry; see SetSchemaVersion.
+	SchemaVersion int      `json:"schemaVersion,omitempty"`
+	Level         LogLevel `json:"level"`
+	// Message is a string unless SetMessageFormat(MessageLegacy) puts
+	// structured payloads in it.
+	Message interface{} `json:"message,omitempty"`
+	// Data holds the structured payload of a call logging a single struct,
+	// map or slice.
+	Data interface{} `json:"data,omitempty"`
+	// Event names the entries emitted by Event, which carry their fields in
+	// details.fields instead of a message.
+	Event string `json:"event,omitempty"`
+
+	SessionID         string                 `json:"sessionId,omitempty"`
+	OperationID       string                 `json:"operationId,omitempty"`
+	ParentOperationID string                 `json:"parentOperationId,omitempty"`
+	UserID            string                 `json:"userId,omitempty"`
+	TenantID          string                 `json:"tenantId,omitempty"`
+	TraceID           string                 `json:"traceId,omitempty"`
+	Details           map[string]interface{} `json:"details"`
+}
+
+// fields returns the entry as the generic object its JSON encoding describes.
+func (o LogOutput) fields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"level":   o.Level,
+		"details": o.Details,
+	}
+	if o.SchemaVersion != 0 {
+		fields["schemaVersion"] = o.SchemaVersion
+	}
+	if o.Message != nil {
+		fields["message"] = o.Message
+	}
+	if o.Data != nil {
+		fields["data"] = o.Data
+	}
+	if o.Event != "" {
+		fields["event"] = o.Event
+	}
+	if o.SessionID != "" {
+		fields["sessionId"] = o.SessionID
+	}
+	if o.OperationID != "" {
+		fields["operationId"] = o.OperationID
+	}
+	if o.ParentOperationID != "" {
+		fields["parentOperationId"] = o.ParentOperationID
+	}
+	if o.UserID != "" {
+		fields["userId"] = o.UserID
+	}
+	if o.TenantID != "" {
+		fields["tenantId"] = o.TenantID
+	}
+	if o.TraceID != "" {
+		fields["traceId"] = o.TraceID
+	}
+	return fields
 }
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// maxLineLength bounds how much of an unterminated line LineWriter buffers
+// before logging it anyway.
+const maxLineLength = 64 << 10
+
+// LineWriter logs each line written to it as an entry. Create one with
+// Writer.
+type LineWriter struct {
+	ctx   context.Context
+	level LogLevel
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Writer returns an io.Writer that logs every line written to it at level,
+// through the default logger, with ctx's LogContext and category (when not
+// empty). It lets writer-only code such as exec.Cmd output, http.Server's
+// ErrorLog or the standard log package produce structured entries:
+//
+//	cmd.Stderr = logger.Writer(ctx, logger.LevelWarn, "exec.git")
+//	srv.ErrorLog = log.New(logger.Writer(ctx, logger.LevelError, "http.server"), "", 0)
+//
+// Call Close to log a final line that lacks a newline.
+func Writer(ctx context.Context, level LogLevel, category string) *LineWriter {
+	if category != "" {
+		ctx = context.WithValue(ctx, logContextKey, GetLogContext(ctx).WithCategory(category))
+	}
+	return &LineWriter{ctx: ctx, level: level}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.logLine(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) >= maxLineLength {
+		w.logLine(w.buf)
+		w.buf = nil
+	}
+	if len(w.buf) == 0 {
+		w.buf = nil
+	}
+	return len(p), nil
+}
+
+// Close logs any buffered partial line.
+func (w *LineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.logLine(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *LineWriter) logLine(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if len(line) == 0 {
+		return
+	}
+	Default().Log(w.ctx, w.level, string(line))
+}